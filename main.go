@@ -1,26 +1,168 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/JaneKetko/Buses/src/accessmanager"
 	"github.com/JaneKetko/Buses/src/config"
 	"github.com/JaneKetko/Buses/src/dbmanager"
+	"github.com/JaneKetko/Buses/src/memstorage"
+	"github.com/JaneKetko/Buses/src/migrations"
 	"github.com/JaneKetko/Buses/src/routemanager"
 	"github.com/JaneKetko/Buses/src/server"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
+//busStorage - interface for storage of routes, identities of clients and responses to requests
+//with idempotency keys.
+type busStorage interface {
+	routemanager.RouteStorage
+	accessmanager.IdentityStorage
+	routemanager.IdempotencyStorage
+}
+
+//openStorage creates storage selected in config.
+func openStorage(cfg *config.Config) (busStorage, error) {
+	switch cfg.Driver {
+	case config.DriverMemory:
+		return memstorage.NewMemStorage(), nil
+	case config.DriverMySQL, config.DriverPostgres, config.DriverSQLite:
+		db, err := dbmanager.Open(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.AutoMigrate {
+			err = migrateUp(db, cfg.Driver)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dbmanager.NewDBManager(db, cfg.Driver), nil
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
+
+//migrateUp applies all pending migrations to database.
+func migrateUp(db *sql.DB, driver string) error {
+	migrator, err := migrations.NewMigrator(db, driver)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(context.Background())
+	log.Printf("%d migrations were applied", applied)
+	return err
+}
+
+//commandArgs returns arguments which follow the command in command line.
+func commandArgs(command string) ([]string, bool) {
+	for i, arg := range os.Args[1:] {
+		if arg == command {
+			return os.Args[i+2:], true
+		}
+	}
+	return nil, false
+}
+
+//migrate runs migrate subcommand: "up" applies all pending migrations,
+//"down [n]" reverts n last migrations (one by default), "version" prints current version,
+//"force <version>" sets version of database without running migrations.
+func migrate(cfg *config.Config, args []string) error {
+	if cfg.Driver == config.DriverMemory {
+		return fmt.Errorf("storage driver %q has no migrations", cfg.Driver)
+	}
+	db, err := dbmanager.Open(cfg)
+	if err != nil {
+		return err
+	}
+	migrator, err := migrations.NewMigrator(db, cfg.Driver)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if len(args) == 0 {
+		args = []string{"up"}
+	}
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		log.Printf("%d migrations were applied", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		log.Printf("%d migrations were reverted", reverted)
+		return err
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(version)
+		return nil
+	case "force":
+		if len(args) < 2 {
+			return fmt.Errorf("version of migration is required")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version of migration %q", args[1])
+		}
+		err = migrator.Force(ctx, version)
+		if err != nil {
+			return err
+		}
+		log.Printf("version of database was set to %d", version)
+		return nil
+	}
+	return fmt.Errorf("unknown migrate command %q", args[0])
+}
+
 func main() {
 
 	cfg := config.GetData()
-	db, err := dbmanager.Open(cfg)
+	if args, ok := commandArgs("migrate"); ok {
+		err := migrate(cfg, args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	storage, err := openStorage(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	dbman := dbmanager.NewDBManager(db)
-	routeman := routemanager.NewRouteManager(dbman)
-	busstation := server.NewBusStation(routeman, cfg)
+	routeman := routemanager.NewRouteManager(storage)
+	accessman := accessmanager.NewAccessManager(storage, cfg.Admins)
+	busstation := server.NewBusStation(routeman, accessman, storage, cfg)
+	if dbm, ok := storage.(*dbmanager.DBManager); ok {
+		dbm.ObserveQueries(busstation.ObserveQuery)
+		busstation.MonitorDB(dbm.DB())
+		busstation.AddCheck("database", dbm.Ping)
+	}
+
+	go routeman.StartGenerator(context.Background(), time.Duration(cfg.ScheduleHorizon)*24*time.Hour,
+		time.Duration(cfg.ScheduleInterval)*time.Minute)
+	if cfg.ArchiveAge > 0 {
+		go routeman.StartArchiver(context.Background(), time.Duration(cfg.ArchiveAge)*24*time.Hour,
+			time.Duration(cfg.ArchiveInterval)*time.Minute)
+	}
 	busstation.StartServer()
 }
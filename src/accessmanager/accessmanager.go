@@ -0,0 +1,110 @@
+package accessmanager
+
+import (
+	"context"
+	"sort"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//maxNameLength - maximal length of name of client.
+const maxNameLength = 100
+
+//IdentityStorage - interface for storing identities of clients with their roles.
+//Identity is stored only while it has roles.
+type IdentityStorage interface {
+	IdentityByName(ctx context.Context, name string) (*domain.Identity, error)
+	GetAllIdentities(ctx context.Context) ([]domain.Identity, error)
+	SaveIdentity(ctx context.Context, i *domain.Identity) error
+	DeleteIdentity(ctx context.Context, name string) error
+}
+
+//AccessManager - struct for managing roles of clients and checking their permissions.
+//Admins from config have admin role even if it isn't stored, so they can assign roles to other clients.
+type AccessManager struct {
+	storage IdentityStorage
+	admins  []string
+}
+
+//NewAccessManager creates new object of AccessManager struct.
+func NewAccessManager(storage IdentityStorage, admins []string) *AccessManager {
+	return &AccessManager{storage: storage, admins: admins}
+}
+
+//isAdmin checks if client is admin from config.
+func (a AccessManager) isAdmin(name string) bool {
+	for _, admin := range a.admins {
+		if admin == name {
+			return true
+		}
+	}
+	return false
+}
+
+//Allowed checks if client has one of roles, admins are allowed everything.
+func (a AccessManager) Allowed(ctx context.Context, name string, roles ...string) (bool, error) {
+	if a.isAdmin(name) {
+		return true, nil
+	}
+	identity, err := a.storage.IdentityByName(ctx, name)
+	if domain.ErrorKind(err) == domain.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return identity.HasRole(domain.RoleAdmin) || identity.HasRole(roles...), nil
+}
+
+//GetAllIdentities gets all stored identities ordered by name.
+func (a AccessManager) GetAllIdentities(ctx context.Context) ([]domain.Identity, error) {
+	identities, err := a.storage.GetAllIdentities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if identities == nil {
+		identities = []domain.Identity{}
+	}
+	return identities, nil
+}
+
+//GetIdentity gets stored identity by name.
+func (a AccessManager) GetIdentity(ctx context.Context, name string) (*domain.Identity, error) {
+	return a.storage.IdentityByName(ctx, name)
+}
+
+//normalizeRoles checks roles and returns them sorted without duplicates.
+func normalizeRoles(roles []string) ([]string, error) {
+	if len(roles) == 0 {
+		return nil, domain.Invalid("roles are empty")
+	}
+	unique := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if !domain.ValidRole(role) {
+			return nil, domain.Invalid("role is invalid")
+		}
+		if !(domain.Identity{Roles: unique}).HasRole(role) {
+			unique = append(unique, role)
+		}
+	}
+	sort.Strings(unique)
+	return unique, nil
+}
+
+//SaveIdentity assigns roles to client replacing roles which it had before.
+func (a *AccessManager) SaveIdentity(ctx context.Context, identity *domain.Identity) error {
+	if identity.Name == "" || len(identity.Name) > maxNameLength {
+		return domain.Invalid("name is invalid")
+	}
+	roles, err := normalizeRoles(identity.Roles)
+	if err != nil {
+		return err
+	}
+	identity.Roles = roles
+	return a.storage.SaveIdentity(ctx, identity)
+}
+
+//DeleteIdentity removes all roles of client.
+func (a *AccessManager) DeleteIdentity(ctx context.Context, name string) error {
+	return a.storage.DeleteIdentity(ctx, name)
+}
@@ -0,0 +1,134 @@
+package accessmanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/JaneKetko/Buses/src/accessmanager/mocks"
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAllowed(t *testing.T) {
+	var identitystrg mocks.IdentityStorage
+	accessman := NewAccessManager(&identitystrg, []string{"root"})
+
+	identitystrg.On("IdentityByName", mock.Anything, "alice").
+		Return(&domain.Identity{Name: "alice", Roles: []string{domain.RoleDispatcher}}, nil)
+	identitystrg.On("IdentityByName", mock.Anything, "bob").
+		Return(&domain.Identity{Name: "bob", Roles: []string{domain.RoleAdmin}}, nil)
+	identitystrg.On("IdentityByName", mock.Anything, "carol").Return(nil, domain.NotFound("no such identity"))
+	identitystrg.On("IdentityByName", mock.Anything, "dave").Return(nil, errors.New("smth bad"))
+
+	testCases := []struct {
+		name            string
+		client          string
+		roles           []string
+		expectedAllowed bool
+		expectedError   bool
+	}{
+		{
+			name:            "has role",
+			client:          "alice",
+			roles:           []string{domain.RoleCashier, domain.RoleDispatcher},
+			expectedAllowed: true,
+		},
+		{
+			name:   "has other role",
+			client: "alice",
+			roles:  []string{domain.RoleCashier},
+		},
+		{
+			name:            "stored admin",
+			client:          "bob",
+			roles:           []string{domain.RoleCashier},
+			expectedAllowed: true,
+		},
+		{
+			name:            "admin from config",
+			client:          "root",
+			roles:           []string{domain.RolePassenger},
+			expectedAllowed: true,
+		},
+		{
+			name:   "no roles",
+			client: "carol",
+			roles:  []string{domain.RolePassenger},
+		},
+		{
+			name:          "storage error",
+			client:        "dave",
+			roles:         []string{domain.RolePassenger},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			allowed, err := accessman.Allowed(context.Background(), tc.client, tc.roles...)
+			assert.Equal(t, tc.expectedError, err != nil)
+			assert.Equal(t, tc.expectedAllowed, allowed)
+		})
+	}
+}
+
+func TestSaveIdentity(t *testing.T) {
+	var identitystrg mocks.IdentityStorage
+	accessman := NewAccessManager(&identitystrg, nil)
+
+	identitystrg.On("SaveIdentity", mock.Anything, &domain.Identity{Name: "alice",
+		Roles: []string{domain.RoleCashier, domain.RolePassenger}}).Return(nil)
+
+	testCases := []struct {
+		name          string
+		identity      domain.Identity
+		expectedRoles []string
+		expectedError error
+	}{
+		{
+			name: "successful test",
+			identity: domain.Identity{Name: "alice",
+				Roles: []string{domain.RolePassenger, domain.RoleCashier, domain.RolePassenger}},
+			expectedRoles: []string{domain.RoleCashier, domain.RolePassenger},
+		},
+		{
+			name:          "empty name",
+			identity:      domain.Identity{Roles: []string{domain.RolePassenger}},
+			expectedRoles: []string{domain.RolePassenger},
+			expectedError: domain.Invalid("name is invalid"),
+		},
+		{
+			name:          "no roles",
+			identity:      domain.Identity{Name: "alice"},
+			expectedError: domain.Invalid("roles are empty"),
+		},
+		{
+			name:          "unknown role",
+			identity:      domain.Identity{Name: "alice", Roles: []string{"driver"}},
+			expectedRoles: []string{"driver"},
+			expectedError: domain.Invalid("role is invalid"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := accessman.SaveIdentity(context.Background(), &tc.identity)
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRoles, tc.identity.Roles)
+		})
+	}
+}
+
+func TestGetAllIdentities(t *testing.T) {
+	var identitystrg mocks.IdentityStorage
+	accessman := NewAccessManager(&identitystrg, nil)
+	identitystrg.On("GetAllIdentities", mock.Anything).Return(nil, nil)
+
+	identities, err := accessman.GetAllIdentities(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Identity{}, identities)
+}
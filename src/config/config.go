@@ -7,13 +7,80 @@ import (
 //Config - struct for project info.
 type Config struct {
 	PortServer int    `default:"8000"`
+	Driver     string `default:"mysql"`
 	Login      string `default:"root"`
 	Passwd     string `default:"root"`
 	Hostname   string `default:"172.17.0.2"`
 	Port       int    `default:"3306"`
 	DBName     string `default:"busstation"`
+	//SSLMode is sslmode parameter of connection to PostgreSQL.
+	SSLMode string `default:"disable"`
+	//DBFile is path to database file of SQLite driver.
+	DBFile string `default:"busstation.db"`
+	//AutoMigrate enables applying of database migrations on startup.
+	AutoMigrate bool `default:"false"`
+
+	//ScheduleHorizon is number of days for which trips are generated by schedules.
+	ScheduleHorizon int `default:"14"`
+	//ScheduleInterval is number of minutes between generations.
+	ScheduleInterval int `default:"60"`
+	//ArchiveAge is number of days after start when route is moved to archive, zero disables archiving.
+	ArchiveAge int `default:"0"`
+	//ArchiveInterval is number of minutes between archivings.
+	ArchiveInterval int `default:"60"`
+	//RequestTimeout is number of seconds for handling of request including queries to storage,
+	//zero disables the timeout.
+	RequestTimeout int `default:"10"`
+
+	//APIKeys are static keys of clients in form "name:key". Authentication is enabled if any keys
+	//or JWT keys are configured, otherwise all endpoints are available without credentials.
+	APIKeys []string
+	//JWTSecret is secret for HS256 tokens.
+	JWTSecret string
+	//JWTPublicKey is path to PEM file with RSA public key for RS256 tokens.
+	JWTPublicKey string
+	//JWTIssuer is expected issuer of tokens if it isn't empty.
+	JWTIssuer string
+	//JWTAudience is expected audience of tokens if it isn't empty.
+	JWTAudience string
+	//PublicSearch allows using of read-only search endpoints without authentication.
+	PublicSearch bool `default:"true"`
+	//Admins are names of clients which have admin role even if it isn't assigned to them.
+	//Clients of API keys are named by keys, clients of tokens are named "jwt:<subject>".
+	Admins []string
+
+	//ReadRateLimit is number of requests per minute which client can make to read endpoints,
+	//zero disables the limit. Clients are identified by name or IP address.
+	ReadRateLimit int `default:"600"`
+	//ReadBurst is number of requests which client can make to read endpoints at once.
+	ReadBurst int `default:"50"`
+	//WriteRateLimit is number of requests per minute which client can make to write endpoints,
+	//zero disables the limit.
+	WriteRateLimit int `default:"60"`
+	//WriteBurst is number of requests which client can make to write endpoints at once.
+	WriteBurst int `default:"10"`
+	//IdempotencyTTL is number of hours during which responses to requests with idempotency keys
+	//are replayed, zero disables idempotency keys.
+	IdempotencyTTL int `default:"24"`
+
+	//Metrics enables /metrics endpoint for Prometheus.
+	Metrics bool `default:"true"`
+	//HealthTimeout is number of seconds for checks of dependencies by readiness endpoint.
+	HealthTimeout int `default:"2"`
+	//ShutdownDelay is number of seconds during which server reports that it isn't ready before stopping.
+	ShutdownDelay int `default:"5"`
+	//ShutdownTimeout is number of seconds for finishing of active requests on shutdown.
+	ShutdownTimeout int `default:"30"`
 }
 
+//Storage drivers which can be selected in config.
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
 //GetData - get data from config file(new config object).
 func GetData() *Config {
 	m := multiconfig.NewWithPath("config.toml")
@@ -0,0 +1,54 @@
+package dbmanager
+
+import (
+	"context"
+	"time"
+)
+
+//ArchiveRoutes moves routes which start before time with their stops and tickets to archive tables
+//and returns number of archived routes.
+func (dbmanager *DBManager) ArchiveRoutes(ctx context.Context, before time.Time) (int, error) {
+	defer dbmanager.measure("ArchiveRoutes", time.Now())
+	tx, err := dbmanager.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer rollback(tx)
+
+	start := before.UTC().Format("2006-01-02 15:04:05")
+	res, err := tx.ExecContext(ctx, `INSERT INTO route_archive (id_route, id_points, starttime, cost, currency,
+		freeseats, allseats, duration, id_schedule, status, delay_minutes, deleted_at, version)
+		SELECT id_route, id_points, starttime, cost, currency, freeseats, allseats, duration, id_schedule, status,
+		delay_minutes, deleted_at, version FROM route WHERE starttime < ?`, start)
+	if err != nil {
+		return 0, err
+	}
+	archived, err := res.RowsAffected()
+	if err != nil || archived == 0 {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO stop_archive (id_route, position, point, arrival, departure, fare,
+		freeseats) SELECT s.id_route, s.position, s.point, s.arrival, s.departure, s.fare, s.freeseats
+		FROM stop s JOIN route r ON s.id_route = r.id_route WHERE r.starttime < ?`, start)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO ticket_archive (id_ticket, id_route, passenger, fromstop, tostop,
+		cost, currency, booked) SELECT t.id_ticket, t.id_route, t.passenger, t.fromstop, t.tostop, t.cost,
+		t.currency, t.booked FROM ticket t JOIN route r ON t.id_route = r.id_route WHERE r.starttime < ?`, start)
+	if err != nil {
+		return 0, err
+	}
+
+	//Stops and tickets are deleted with routes by foreign keys.
+	_, err = tx.ExecContext(ctx, "DELETE FROM route WHERE starttime < ?", start)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return int(archived), nil
+}
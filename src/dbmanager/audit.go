@@ -0,0 +1,108 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//encodeSnapshot returns JSON snapshot of route as text or NULL if there is no snapshot.
+func encodeSnapshot(data json.RawMessage) sql.NullString {
+	if data == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+//decodeSnapshot returns JSON snapshot of route or nil if there is no snapshot.
+func decodeSnapshot(data sql.NullString) json.RawMessage {
+	if !data.Valid {
+		return nil
+	}
+	return json.RawMessage(data.String)
+}
+
+//AddAuditEntry adds entry with JSON snapshots of route to audit log and returns its id.
+func (dbmanager *DBManager) AddAuditEntry(ctx context.Context, e *domain.AuditEntry) (int, error) {
+	defer dbmanager.measure("AddAuditEntry", time.Now())
+	before, after := encodeSnapshot(e.Before), encodeSnapshot(e.After)
+	id, err := dbmanager.conn(ctx).insert(ctx, `INSERT INTO audit_log (id_route, action, actor, changed, snapshot_before,
+		snapshot_after) VALUES(?, ?, ?, ?, ?, ?)`, "id_audit", e.RouteID, e.Action, e.Actor,
+		e.Time.UTC().Format("2006-01-02 15:04:05"), before, after)
+	if err != nil {
+		return 0, domain.Unavailable("audit entry hasn't added")
+	}
+	return int(id), nil
+}
+
+//auditConditions builds WHERE clause and its arguments for audit query.
+func auditConditions(q domain.AuditQuery) (string, []interface{}) {
+	filters := []struct {
+		apply bool
+		cond  string
+		arg   interface{}
+	}{
+		{q.RouteID != 0, "id_route=?", q.RouteID},
+		{q.Actor != "", "actor=?", q.Actor},
+		{!q.From.IsZero(), "changed>=?", q.From.UTC().Format("2006-01-02 15:04:05")},
+		{!q.To.IsZero(), "changed<?", q.To.UTC().Format("2006-01-02 15:04:05")},
+	}
+
+	var conds []string
+	var args []interface{}
+	for _, f := range filters {
+		if f.apply {
+			conds = append(conds, f.cond)
+			args = append(args, f.arg)
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+//AuditEntries finds entries of audit log by filters in query ordered by time of change.
+func (dbmanager *DBManager) AuditEntries(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	defer dbmanager.measure("AuditEntries", time.Now())
+	where, args := auditConditions(q)
+	query := `SELECT id_audit, id_route, action, actor, changed, snapshot_before, snapshot_after
+		FROM audit_log` + where + " ORDER BY id_audit"
+	if q.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, q.Limit, q.Offset)
+	}
+
+	rows, err := dbmanager.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, domain.Unavailable("data hasn't read")
+	}
+
+	defer func() {
+		err = rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	var entries []domain.AuditEntry
+	for rows.Next() {
+		var e domain.AuditEntry
+		var changed dbTime
+		var before, after sql.NullString
+		err = rows.Scan(&e.ID, &e.RouteID, &e.Action, &e.Actor, &changed, &before, &after)
+		if err != nil {
+			return nil, errors.New("no data")
+		}
+		e.Time = changed.Time
+		e.Before, e.After = decodeSnapshot(before), decodeSnapshot(after)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -1,11 +1,15 @@
 package dbmanager
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/JaneKetko/Buses/src/config"
@@ -15,65 +19,162 @@ import (
 //RouteDB - struct for describing route from db.
 type RouteDB struct {
 	idRoute    int
-	startTime  string
+	startTime  dbTime
 	cost       int
+	currency   string
 	freeSeats  int
 	allSeats   int
+	duration   int
+	idSchedule sql.NullInt64
 	idPoint    int
 	startPoint string
 	endPoint   string
+	status     string
+	delay      int
+	version    int
 }
 
 //DBManager - struct for storing database.
 type DBManager struct {
-	db *sql.DB
+	sqlDB    *sql.DB
+	db       database
+	observer QueryObserver
 }
 
-//NewDBManager - constructor for DBManager.
-func NewDBManager(db *sql.DB) *DBManager {
-	return &DBManager{db}
+//QueryObserver - function which gets name of DBManager method and duration of its call.
+type QueryObserver func(method string, d time.Duration)
+
+//selectRoutes - beginning of query for selecting routes with their points.
+const selectRoutes = `SELECT r.id_route, r.starttime, r.cost, r.currency, r.freeseats, r.allseats,
+	r.duration, r.id_schedule, p.id_points, p.startpoint, p.endpoint, r.status, r.delay_minutes,
+	r.version FROM route r JOIN points p ON r.id_points = p.id_points`
+
+//NewDBManager - constructor for DBManager with database of driver from config.
+func NewDBManager(db *sql.DB, driver string) *DBManager {
+	return &DBManager{sqlDB: db, db: database{ex: db, dialect: dialectOf(driver)}}
 }
 
-//ConvertTypes - convert RouteDB to Route.
-func convertTypes(routeDB RouteDB) (domain.Route, error) {
-	var route domain.Route
-	date, err := time.Parse("2006-01-02 15:04:05", routeDB.startTime)
+//ObserveQueries makes DBManager report durations of calls of its methods to observer.
+//It has to be called before DBManager is used.
+func (dbmanager *DBManager) ObserveQueries(observer QueryObserver) {
+	dbmanager.observer = observer
+}
+
+//DB returns database of DBManager, e.g. for getting statistics of its connections.
+func (dbmanager *DBManager) DB() *sql.DB {
+	return dbmanager.sqlDB
+}
+
+//Ping checks that database is available.
+func (dbmanager *DBManager) Ping(ctx context.Context) error {
+	return dbmanager.sqlDB.PingContext(ctx)
+}
+
+//measure reports duration of call of method which started at start to observer.
+func (dbmanager *DBManager) measure(method string, start time.Time) {
+	if dbmanager.observer != nil {
+		dbmanager.observer(method, time.Since(start))
+	}
+}
+
+//txKey - key of transaction of InTransaction in context.
+type txKey struct{}
+
+//conn returns transaction of InTransaction if ctx has it or database otherwise.
+func (dbmanager *DBManager) conn(ctx context.Context) database {
+	if tx, ok := ctx.Value(txKey{}).(*transaction); ok {
+		return tx.database
+	}
+	return dbmanager.db
+}
+
+//begin starts transaction in database. If ctx has transaction of InTransaction,
+//it is continued and is committed or aborted only by InTransaction.
+func (dbmanager *DBManager) begin(ctx context.Context) (*transaction, error) {
+	if tx, ok := ctx.Value(txKey{}).(*transaction); ok {
+		return &transaction{database: tx.database}, nil
+	}
+	tx, err := dbmanager.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{database: database{ex: tx, dialect: dbmanager.db.dialect}, tx: tx}, nil
+}
+
+//InTransaction calls fn with context in which methods of DBManager make changes in one transaction,
+//the transaction is committed if fn succeeds and is aborted otherwise.
+func (dbmanager *DBManager) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := dbmanager.begin(ctx)
 	if err != nil {
-		return route, err
+		return err
 	}
-	route = domain.Route{ID: routeDB.idRoute,
+	defer rollback(tx)
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//ConvertTypes - convert RouteDB to Route.
+func convertTypes(routeDB RouteDB) domain.Route {
+	return domain.Route{ID: routeDB.idRoute,
 		Points: domain.Points{StartPoint: routeDB.startPoint,
 			EndPoint: routeDB.endPoint},
-		Start:     date,
-		Cost:      routeDB.cost,
-		FreeSeats: routeDB.freeSeats,
-		AllSeats:  routeDB.allSeats}
-	return route, nil
+		Start:      routeDB.startTime.Time,
+		Cost:       domain.Money{Amount: routeDB.cost, Currency: routeDB.currency},
+		FreeSeats:  routeDB.freeSeats,
+		AllSeats:   routeDB.allSeats,
+		Duration:   time.Duration(routeDB.duration) * time.Minute,
+		ScheduleID: int(routeDB.idSchedule.Int64),
+		Status:     routeDB.status,
+		Delay:      time.Duration(routeDB.delay) * time.Minute,
+		Version:    routeDB.version}
 }
 
-//Open opens connection with database.
-func Open(cfg *config.Config) (*sql.DB, error) {
+//dataSource returns name of SQL driver and data source name for driver selected in config.
+func dataSource(cfg *config.Config) (string, string, error) {
+	switch cfg.Driver {
+	case config.DriverMySQL:
+		return "mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s",
+			cfg.Login, cfg.Passwd, cfg.Hostname, strconv.Itoa(cfg.Port), cfg.DBName), nil
+	case config.DriverPostgres:
+		dsn := url.URL{Scheme: "postgres", User: url.UserPassword(cfg.Login, cfg.Passwd),
+			Host: net.JoinHostPort(cfg.Hostname, strconv.Itoa(cfg.Port)), Path: "/" + cfg.DBName,
+			RawQuery: url.Values{"sslmode": {cfg.SSLMode}, "timezone": {"UTC"}}.Encode()}
+		return "postgres", dsn.String(), nil
+	case config.DriverSQLite:
+		return "sqlite", "file:" + cfg.DBFile + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(10000)" +
+			"&_pragma=journal_mode(WAL)&_txlock=immediate", nil
+	}
+	return "", "", fmt.Errorf("unknown SQL driver %q", cfg.Driver)
+}
 
-	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s",
-		cfg.Login, cfg.Passwd, cfg.Hostname, strconv.Itoa(cfg.Port), cfg.DBName))
+//Open opens connection with database of driver selected in config.
+func Open(cfg *config.Config) (*sql.DB, error) {
+	driver, dsn, err := dataSource(cfg)
+	if err != nil {
+		return nil, err
+	}
 
+	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, err
 	}
 	err = db.Ping()
 	if err != nil {
-		return nil, errors.New("database hasn't connected")
+		return nil, domain.Unavailable("database hasn't connected")
 	}
 	return db, nil
 }
 
-//GetAllData gets full data from db.
-func (dbmanager *DBManager) GetAllData() ([]domain.Route, error) {
-	rows, err := dbmanager.db.Query(`SELECT r.id_route, r.starttime, r.cost, r.freeseats, r.allseats,
-		p.id_points, p.startpoint, p.endpoint
-		FROM route r JOIN points p ON r.id_points = p.id_points`)
+//queryRoutes selects routes from database by query.
+func (dbmanager *DBManager) queryRoutes(ctx context.Context, query string,
+	args ...interface{}) ([]domain.Route, error) {
+	rows, err := dbmanager.conn(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, errors.New("data hasn't read")
+		return nil, domain.Unavailable("data hasn't read")
 	}
 
 	defer func() {
@@ -86,168 +187,454 @@ func (dbmanager *DBManager) GetAllData() ([]domain.Route, error) {
 	var dbr RouteDB
 	var routes []domain.Route
 	for rows.Next() {
-		err = rows.Scan(&dbr.idRoute, &dbr.startTime, &dbr.cost, &dbr.freeSeats,
-			&dbr.allSeats, &dbr.idPoint, &dbr.startPoint, &dbr.endPoint)
+		err = rows.Scan(&dbr.idRoute, &dbr.startTime, &dbr.cost, &dbr.currency, &dbr.freeSeats,
+			&dbr.allSeats, &dbr.duration, &dbr.idSchedule, &dbr.idPoint, &dbr.startPoint, &dbr.endPoint,
+			&dbr.status, &dbr.delay, &dbr.version)
 		if err != nil {
 			return nil, errors.New("no data")
 		}
-		route, err := convertTypes(dbr)
-		if err != nil {
-			return nil, errors.New("errors with types")
-		}
+		routes = append(routes, convertTypes(dbr))
+	}
 
-		routes = append(routes, route)
+	err = dbmanager.loadStops(ctx, routes)
+	if err != nil {
+		return nil, err
 	}
 	return routes, nil
 }
 
-//RouteByID finds route by id in database.
-func (dbmanager *DBManager) RouteByID(id int) (*domain.Route, error) {
-	rows, err := dbmanager.db.Query(`SELECT r.id_route, r.starttime, r.cost, r.freeseats, r.allseats, 
-	p.id_points, p.startpoint, p.endpoint 
-	FROM route r JOIN points p on r.id_points = p.id_points WHERE r.id_route=?`, id)
-
+//RouteByID finds route which isn't deleted by id in database.
+func (dbmanager *DBManager) RouteByID(ctx context.Context, id int) (*domain.Route, error) {
+	defer dbmanager.measure("RouteByID", time.Now())
+	routes, err := dbmanager.queryRoutes(ctx, selectRoutes+" WHERE r.id_route=? AND r.deleted_at IS NULL", id)
 	if err != nil {
-		return nil, errors.New("data hasn't read")
+		return nil, err
 	}
+	if len(routes) == 0 {
+		return nil, domain.NotFound("no such route")
+	}
+	return &routes[0], nil
+}
 
-	defer func() {
-		err = rows.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
-
-	if !rows.Next() {
-		return nil, errors.New("no such route")
+//versionError returns error of change of route by id with expected version which didn't change any row.
+//Route is looked for among deleted routes if deleted is true.
+func versionError(ctx context.Context, db database, id int, deleted bool) error {
+	query, message := "SELECT version FROM route WHERE id_route=? AND deleted_at IS NULL", "no such route"
+	if deleted {
+		query, message = "SELECT version FROM route WHERE id_route=? AND deleted_at IS NOT NULL", "no such deleted route"
 	}
-	var routeDB RouteDB
-	err = rows.Scan(&routeDB.idRoute, &routeDB.startTime, &routeDB.cost, &routeDB.freeSeats,
-		&routeDB.allSeats, &routeDB.idPoint, &routeDB.startPoint, &routeDB.endPoint)
-	if err != nil {
-		return nil, errors.New("something is wrong")
+	var version int
+	err := db.QueryRowContext(ctx, query, id).Scan(&version)
+	if err == sql.ErrNoRows {
+		return domain.NotFound(message)
 	}
-
-	route, err := convertTypes(routeDB)
 	if err != nil {
-		return nil, errors.New("errors with types")
+		return err
 	}
-	return &route, nil
+	return domain.PreconditionFailed("route was changed")
 }
 
-//DeleteRow deletes row from database by id.
-func (dbmanager *DBManager) DeleteRow(id int) error {
-	stmtIns, err := dbmanager.db.Prepare("DELETE FROM route where id_route=?")
+//DeleteRow marks row with version in database as deleted by id, stops and tickets of the route are kept.
+func (dbmanager *DBManager) DeleteRow(ctx context.Context, id, version int) error {
+	defer dbmanager.measure("DeleteRow", time.Now())
+	rows, err := dbmanager.conn(ctx).ExecContext(ctx, `UPDATE route SET deleted_at=?, version=version+1
+		WHERE id_route=? AND version=? AND deleted_at IS NULL`, time.Now().UTC().Format("2006-01-02 15:04:05"),
+		id, version)
 	if err != nil {
 		return err
 	}
-	rows, err := stmtIns.Exec(id)
+	if n, _ := rows.RowsAffected(); n == 0 {
+		return versionError(ctx, dbmanager.conn(ctx), id, false)
+	}
+	return nil
+}
+
+//RestoreRoute removes mark of deletion from row with version in database by id.
+func (dbmanager *DBManager) RestoreRoute(ctx context.Context, id, version int) error {
+	defer dbmanager.measure("RestoreRoute", time.Now())
+	rows, err := dbmanager.conn(ctx).ExecContext(ctx, `UPDATE route SET deleted_at=NULL, version=version+1
+		WHERE id_route=? AND version=? AND deleted_at IS NOT NULL`, id, version)
 	if err != nil {
 		return err
 	}
 	if n, _ := rows.RowsAffected(); n == 0 {
-		return errors.New("no such route")
+		return versionError(ctx, dbmanager.conn(ctx), id, true)
 	}
 	return nil
 }
 
-//RoutesByEndPoint finds row in database by date and endpoint.
-func (dbmanager *DBManager) RoutesByEndPoint(endpoint string) ([]domain.Route, error) {
-	rows, err := dbmanager.db.Query(`SELECT r.id_route, r.starttime, r.cost, r.freeseats, r.allseats, 
-	p.id_points, p.startpoint, p.endpoint 
-	FROM route r JOIN points p on r.id_points = p.id_points WHERE p.endpoint=?`, endpoint)
-	if err != nil {
-		return nil, errors.New("data hasn't read")
+//sortColumn returns column of database for sort key.
+func sortColumn(key string) (string, bool) {
+	switch key {
+	case domain.SortByStart:
+		return "r.starttime", true
+	case domain.SortByCost:
+		return "r.cost", true
+	case domain.SortByFreeSeats:
+		return "r.freeseats", true
 	}
+	return "", false
+}
 
-	defer func() {
-		err = rows.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
+//clock formats offset from midnight as time of day.
+func clock(d time.Duration) string {
+	return time.Time{}.Add(d).Format("15:04:05")
+}
 
-	var dbr RouteDB
-	var routes []domain.Route
-	for rows.Next() {
-		err = rows.Scan(&dbr.idRoute, &dbr.startTime, &dbr.cost, &dbr.freeSeats,
-			&dbr.allSeats, &dbr.idPoint, &dbr.startPoint, &dbr.endPoint)
-		if err != nil {
-			return nil, errors.New("no data")
+//pointsCondition builds condition for routes which go from start point to end point of query
+//directly or through their stops.
+func pointsCondition(q domain.RouteQuery) (string, []interface{}) {
+	var direct, stops []string
+	var directArgs, stopsArgs []interface{}
+	if q.StartPoint != "" {
+		direct, stops = append(direct, "p.startpoint=?"), append(stops, "a.point=?")
+		directArgs, stopsArgs = append(directArgs, q.StartPoint), append(stopsArgs, q.StartPoint)
+	}
+	if q.EndPoint != "" {
+		direct, stops = append(direct, "p.endpoint=?"), append(stops, "b.point=?")
+		directArgs, stopsArgs = append(directArgs, q.EndPoint), append(stopsArgs, q.EndPoint)
+	}
+	if len(direct) == 0 {
+		return "", nil
+	}
+	return "((" + strings.Join(direct, " AND ") + `) OR EXISTS (SELECT 1 FROM stop a
+		JOIN stop b ON a.id_route = b.id_route AND a.position < b.position
+		WHERE a.id_route = r.id_route AND ` + strings.Join(stops, " AND ") + "))",
+		append(directArgs, stopsArgs...)
+}
+
+//queryConditions builds WHERE clause and its arguments for route query, deleted routes are skipped,
+//timeOfDay is expression for time of day of start of route.
+func queryConditions(q domain.RouteQuery, timeOfDay string) (string, []interface{}) {
+	filters := []struct {
+		apply bool
+		cond  string
+		arg   interface{}
+	}{
+		{!q.From.IsZero(), "r.starttime>=?", q.From.Format("2006-01-02 15:04:05")},
+		{!q.To.IsZero(), "r.starttime<?", q.To.Format("2006-01-02 15:04:05")},
+		{q.DepartAfter > 0, timeOfDay + ">=?", clock(q.DepartAfter)},
+		{q.DepartBefore > 0, timeOfDay + "<=?", clock(q.DepartBefore)},
+		{q.MinFreeSeats > 0, "r.freeseats>=?", q.MinFreeSeats},
+		{q.MaxCost > 0, "r.cost<=?", q.MaxCost},
+	}
+
+	conds := []string{"r.deleted_at IS NULL"}
+	var args []interface{}
+	if cond, pointArgs := pointsCondition(q); cond != "" {
+		conds = append(conds, cond)
+		args = append(args, pointArgs...)
+	}
+	if len(q.Statuses) > 0 {
+		conds = append(conds, "r.status IN (?"+strings.Repeat(", ?", len(q.Statuses)-1)+")")
+		for _, status := range q.Statuses {
+			args = append(args, status)
 		}
-		route, err := convertTypes(dbr)
-		if err != nil {
-			return nil, errors.New("errors with types")
+	}
+	for _, f := range filters {
+		if f.apply {
+			conds = append(conds, f.cond)
+			args = append(args, f.arg)
 		}
-		routes = append(routes, route)
 	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
 
-	if len(routes) == 0 {
-		return nil, errors.New("no such routes by this endpoint")
+//RoutesByQuery finds routes by filters in query and returns requested page of them
+//with total number of matched routes.
+func (dbmanager *DBManager) RoutesByQuery(ctx context.Context, q domain.RouteQuery) ([]domain.Route, int, error) {
+	defer dbmanager.measure("RoutesByQuery", time.Now())
+	where, args := queryConditions(q, dbmanager.db.dialect.timeOfDay)
+
+	var total int
+	err := dbmanager.conn(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM route r JOIN points p ON r.id_points = p.id_points"+
+		where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, domain.Unavailable("data hasn't read")
 	}
-	return routes, nil
+
+	order := "r.id_route"
+	if column, ok := sortColumn(q.SortBy); ok {
+		order = column
+		if q.Desc {
+			order += " DESC"
+		}
+		order += ", r.id_route"
+	}
+	query := selectRoutes + where + " ORDER BY " + order
+	if q.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, q.Limit, q.Offset)
+	}
+
+	routes, err := dbmanager.queryRoutes(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return routes, total, nil
 }
 
-func (dbmanager *DBManager) insertPoint(startpoint, endpoint string) (int64, error) {
-	stmtIn, err := dbmanager.db.Prepare("INSERT INTO points (startpoint, endpoint) VALUES( ?, ? )")
+func insertRoute(ctx context.Context, db database, id, freeseats, allseats int, cost domain.Money, duration, scheduleID int,
+	datetime string) (int64, error) {
+
+	date, err := time.Parse("2006-01-02 15:04:05", datetime)
 	if err != nil {
 		return 0, err
 	}
-	defer func() {
-		err = stmtIn.Close()
+
+	schedule := sql.NullInt64{Int64: int64(scheduleID), Valid: scheduleID != 0}
+	return db.insert(ctx, `INSERT INTO route (id_points, starttime, cost, currency, freeseats, allseats,
+			duration, id_schedule) VALUES( ?, ?, ?, ?, ?, ?, ?, ? )`, "id_route",
+		id, date.Format("2006-01-02 15:04:05"), cost.Amount, cost.Currency, freeseats, allseats, duration, schedule)
+}
+
+//findPoint finds id of points row, sql.ErrNoRows is returned if there is no such points.
+func (dbmanager *DBManager) findPoint(ctx context.Context, startpoint, endpoint string) (int64, error) {
+	var pointID int64
+	err := dbmanager.conn(ctx).QueryRowContext(ctx, "SELECT id_points FROM points WHERE startpoint=? AND endpoint=?",
+		startpoint, endpoint).Scan(&pointID)
+	return pointID, err
+}
+
+//pointID finds id of points row and inserts new row if there is no such points.
+//The row is inserted by upsert, so points inserted concurrently don't fail transaction of the caller.
+func (dbmanager *DBManager) pointID(ctx context.Context, startpoint, endpoint string) (int64, error) {
+	pointID, err := dbmanager.findPoint(ctx, startpoint, endpoint)
+	if err == nil {
+		return pointID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, domain.Unavailable("data hasn't read")
+	}
+
+	db := dbmanager.conn(ctx)
+	if db.dialect.upsertID {
+		pointID, err = db.insert(ctx, `INSERT INTO points (startpoint, endpoint) VALUES( ?, ? )
+			ON DUPLICATE KEY UPDATE id_points=LAST_INSERT_ID(id_points)`, "id_points", startpoint, endpoint)
 		if err != nil {
-			log.Println(err)
+			return 0, domain.Unavailable("points haven't added")
 		}
-	}()
+		return pointID, nil
+	}
+
+	_, err = db.ExecContext(ctx, `INSERT INTO points (startpoint, endpoint) VALUES( ?, ? )
+		ON CONFLICT (startpoint, endpoint) DO NOTHING`, startpoint, endpoint)
+	if err != nil {
+		return 0, domain.Unavailable("points haven't added")
+	}
+	pointID, err = dbmanager.findPoint(ctx, startpoint, endpoint)
+	if err != nil {
+		return 0, domain.Unavailable("data hasn't read")
+	}
+	return pointID, nil
+}
 
-	row, err := stmtIn.Exec(startpoint, endpoint)
+//AddRoute adds route with its stops to database, new route is scheduled without delay.
+//Route of schedule isn't added if the schedule already has route with the same start.
+func (dbmanager *DBManager) AddRoute(ctx context.Context, r *domain.Route) (int, error) {
+	defer dbmanager.measure("AddRoute", time.Now())
+	pointID, err := dbmanager.pointID(ctx, r.Points.StartPoint, r.Points.EndPoint)
 	if err != nil {
 		return 0, err
 	}
 
-	pointID, err := row.LastInsertId()
+	tx, err := dbmanager.begin(ctx)
 	if err != nil {
 		return 0, err
 	}
-	return pointID, nil
+	defer rollback(tx)
+
+	idRoute, err := insertRoute(ctx, tx.database, int(pointID), r.FreeSeats, r.AllSeats,
+		r.Cost, minutes(r.Duration), r.ScheduleID, r.Start.Format("2006-01-02 15:04:05"))
+	if isDuplicate(err) {
+		return 0, domain.Conflict("schedule already has route with such start")
+	}
+	if err != nil {
+		return 0, err
+	}
+	err = insertStops(ctx, tx, idRoute, r.Stops)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return int(idRoute), nil
 }
 
-func (dbmanager *DBManager) insertRoute(id, freeseats, allseats, cost int, datetime string) (int64, error) {
+//routeExists checks if there is route with id which isn't deleted in transaction.
+func routeExists(ctx context.Context, tx *transaction, id int) error {
+	err := tx.QueryRowContext(ctx, "SELECT id_route FROM route WHERE id_route=? AND deleted_at IS NULL", id).Scan(&id)
+	if err == sql.ErrNoRows {
+		return domain.NotFound("no such route")
+	}
+	return err
+}
 
-	date, err := time.Parse("2006-01-02 15:04:05", datetime)
+//UpdateRoute replaces data and stops of route with version in database and links route to new points
+//if they were changed, status and delay of the route are kept.
+func (dbmanager *DBManager) UpdateRoute(ctx context.Context, r *domain.Route) error {
+	defer dbmanager.measure("UpdateRoute", time.Now())
+	pointID, err := dbmanager.pointID(ctx, r.Points.StartPoint, r.Points.EndPoint)
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbmanager.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	res, err := tx.ExecContext(ctx, `UPDATE route SET id_points=?, starttime=?, cost=?, currency=?, freeseats=?,
+		allseats=?, duration=?, version=version+1 WHERE id_route=? AND version=? AND deleted_at IS NULL`, pointID,
+		r.Start.Format("2006-01-02 15:04:05"), r.Cost.Amount, r.Cost.Currency, r.FreeSeats, r.AllSeats,
+		minutes(r.Duration), r.ID, r.Version)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return versionError(ctx, tx.database, r.ID, false)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM stop WHERE id_route=?", r.ID)
+	if err != nil {
+		return err
+	}
+	err = insertStops(ctx, tx, int64(r.ID), r.Stops)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//SetRouteStatus changes status and delay of the route if it still has version.
+func (dbmanager *DBManager) SetRouteStatus(ctx context.Context, id, version int, status string,
+	delay time.Duration) error {
+	defer dbmanager.measure("SetRouteStatus", time.Now())
+	res, err := dbmanager.conn(ctx).ExecContext(ctx, `UPDATE route SET status=?, delay_minutes=?, version=version+1
+		WHERE id_route=? AND version=? AND deleted_at IS NULL`, status, minutes(delay), id, version)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return versionError(ctx, dbmanager.conn(ctx), id, false)
+	}
+	return nil
+}
+
+func rollback(tx *transaction) {
+	err := tx.Rollback()
+	if err != nil && err != sql.ErrTxDone {
+		log.Println(err)
+	}
+}
+
+//bookRoute takes one seat of the existing route without stops.
+func bookRoute(ctx context.Context, tx *transaction, routeID int) error {
+	res, err := tx.ExecContext(ctx, `UPDATE route SET freeseats = freeseats - 1, version = version + 1
+		WHERE id_route=? AND freeseats > 0`, routeID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.Conflict("no free seats")
+	}
+	return nil
+}
+
+//BookSeat takes one free seat of the route between stops of the ticket and adds ticket to database.
+func (dbmanager *DBManager) BookSeat(ctx context.Context, t *domain.Ticket) (int, error) {
+	defer dbmanager.measure("BookSeat", time.Now())
+	tx, err := dbmanager.begin(ctx)
 	if err != nil {
 		return 0, err
 	}
-	stmtIns, err := dbmanager.db.Prepare(`INSERT INTO route (id_points, starttime, cost, freeseats, allseats)
-			VALUES( ?, ?, ?, ?, ? )`)
+	defer rollback(tx)
+
+	err = routeExists(ctx, tx, t.RouteID)
+	if err != nil {
+		return 0, err
+	}
+	from, to, ok, err := stopPositions(ctx, tx, t)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		err = bookSegments(ctx, tx, t.RouteID, from, to)
+	} else {
+		err = bookRoute(ctx, tx, t.RouteID)
+	}
 	if err != nil {
 		return 0, err
 	}
-	defer func() {
-		err = stmtIns.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
 
-	rowRoute, err := stmtIns.Exec(id, date, cost, freeseats, allseats)
+	idTicket, err := tx.insert(ctx, `INSERT INTO ticket (id_route, passenger, fromstop, tostop, cost, currency,
+		booked) VALUES( ?, ?, ?, ?, ?, ?, ? )`, "id_ticket", t.RouteID, t.Passenger, t.From, t.To, t.Cost.Amount,
+		t.Cost.Currency, t.Booked.Format("2006-01-02 15:04:05"))
 	if err != nil {
 		return 0, err
 	}
 
-	idRoute, err := rowRoute.LastInsertId()
+	err = tx.Commit()
 	if err != nil {
 		return 0, err
 	}
-	return idRoute, nil
+	return int(idTicket), nil
 }
 
-//AddRoute adds route to database.
-func (dbmanager *DBManager) AddRoute(r *domain.Route) (int, error) {
-	rows, err := dbmanager.db.Query("SELECT id_points FROM points WHERE startpoint=? AND endpoint=?",
-		r.Points.StartPoint, r.Points.EndPoint)
+//CancelBooking deletes ticket from database by id and frees its seat.
+func (dbmanager *DBManager) CancelBooking(ctx context.Context, id int) error {
+	defer dbmanager.measure("CancelBooking", time.Now())
+	tx, err := dbmanager.begin(ctx)
 	if err != nil {
-		return 0, errors.New("data hasn't read")
+		return err
+	}
+	defer rollback(tx)
+
+	var t domain.Ticket
+	err = tx.QueryRowContext(ctx, "SELECT id_route, fromstop, tostop FROM ticket WHERE id_ticket=?", id).
+		Scan(&t.RouteID, &t.From, &t.To)
+	if err == sql.ErrNoRows {
+		return domain.NotFound("no such ticket")
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM ticket WHERE id_ticket=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.NotFound("no such ticket")
+	}
+
+	from, to, ok, err := stopPositions(ctx, tx, &t)
+	switch {
+	case err != nil:
+		return err
+	case ok:
+		err = releaseSegments(ctx, tx, t.RouteID, from, to)
+	default:
+		_, err = tx.ExecContext(ctx, `UPDATE route SET freeseats = freeseats + 1, version = version + 1
+			WHERE id_route=? AND freeseats < allseats`, t.RouteID)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//RouteTickets finds tickets booked on the route in database ordered by id.
+func (dbmanager *DBManager) RouteTickets(ctx context.Context, routeID int) ([]domain.Ticket, error) {
+	defer dbmanager.measure("RouteTickets", time.Now())
+	rows, err := dbmanager.conn(ctx).QueryContext(ctx, `SELECT id_ticket, id_route, passenger, fromstop, tostop,
+		cost, currency, booked FROM ticket WHERE id_route=? ORDER BY id_ticket`, routeID)
+	if err != nil {
+		return nil, domain.Unavailable("data hasn't read")
 	}
 
 	defer func() {
@@ -257,22 +644,16 @@ func (dbmanager *DBManager) AddRoute(r *domain.Route) (int, error) {
 		}
 	}()
 
-	var pointID int64
-	if !rows.Next() {
-		pointID, err = dbmanager.insertPoint(r.Points.StartPoint, r.Points.EndPoint)
-		if err != nil {
-			return 0, err
-		}
-	} else {
-		err = rows.Scan(&pointID)
+	var tickets []domain.Ticket
+	for rows.Next() {
+		var t domain.Ticket
+		var booked dbTime
+		err = rows.Scan(&t.ID, &t.RouteID, &t.Passenger, &t.From, &t.To, &t.Cost.Amount, &t.Cost.Currency, &booked)
 		if err != nil {
-			return 0, err
+			return nil, errors.New("no data")
 		}
+		t.Booked = booked.Time
+		tickets = append(tickets, t)
 	}
-	idRoute, err := dbmanager.insertRoute(int(pointID), r.FreeSeats, r.AllSeats,
-		r.Cost, r.Start.Format("2006-01-02 15:04:05"))
-	if err != nil {
-		return 0, err
-	}
-	return int(idRoute), nil
+	return tickets, nil
 }
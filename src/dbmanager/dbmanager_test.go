@@ -1,51 +1,185 @@
-//+build testdb
-
 package dbmanager
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/JaneKetko/Buses/src/accessmanager"
 	"github.com/JaneKetko/Buses/src/config"
 	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/migrations"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/JaneKetko/Buses/src/storagetest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-
-	_ "github.com/go-sql-driver/mysql"
 )
 
-func dbOpen() (*sql.DB, error) {
-	config := &config.Config{
-		PortServer: 8000,
-		Login:      "root",
-		Passwd:     "root",
-		Hostname:   "172.17.0.2",
-		Port:       3306,
-		DBName:     "busstationtest",
+//migratedDB opens database by config and applies all migrations to it.
+func migratedDB(cfg *config.Config) (*sql.DB, error) {
+	db, err := Open(cfg)
+	if err != nil {
+		return nil, err
 	}
-
-	db, err := Open(config)
+	migrator, err := migrations.NewMigrator(db, cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+	_, err = migrator.Up(context.Background())
 	if err != nil {
 		return nil, err
 	}
 	return db, nil
 }
 
+//clearDB deletes all data from tables of database of tests.
+func clearDB(dbmanager *DBManager) error {
+	for _, table := range []string{"ticket", "stop", "route", "schedule_exception", "schedule", "points",
+		"ticket_archive", "stop_archive", "route_archive", "audit_log", "identity_role",
+		"idempotency_key"} {
+		_, err := dbmanager.db.ExecContext(context.Background(), "DELETE FROM "+table)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) routemanager.RouteStorage {
+		db, err := dbOpen(t)
+		require.NoError(t, err)
+		dbmanager := NewDBManager(db, testDriver)
+		require.NoError(t, clearDB(dbmanager))
+		return dbmanager
+	})
+}
+
+func TestIdentityConformance(t *testing.T) {
+	storagetest.RunIdentities(t, func(t *testing.T) accessmanager.IdentityStorage {
+		db, err := dbOpen(t)
+		require.NoError(t, err)
+		dbmanager := NewDBManager(db, testDriver)
+		require.NoError(t, clearDB(dbmanager))
+		return dbmanager
+	})
+}
+
+func TestIdempotencyConformance(t *testing.T) {
+	storagetest.RunIdempotency(t, func(t *testing.T) routemanager.IdempotencyStorage {
+		db, err := dbOpen(t)
+		require.NoError(t, err)
+		dbmanager := NewDBManager(db, testDriver)
+		require.NoError(t, clearDB(dbmanager))
+		return dbmanager
+	})
+}
+
+func TestObserveQueries(t *testing.T) {
+	db, err := dbOpen(t)
+	require.NoError(t, err)
+	dbmanager := NewDBManager(db, testDriver)
+	require.NoError(t, clearDB(dbmanager))
+	assert.Equal(t, db, dbmanager.DB())
+
+	var methods []string
+	dbmanager.ObserveQueries(func(method string, d time.Duration) {
+		assert.True(t, d >= 0)
+		methods = append(methods, method)
+	})
+	_, _, err = dbmanager.RoutesByQuery(context.Background(), domain.RouteQuery{})
+	require.NoError(t, err)
+	_, err = dbmanager.RouteByID(context.Background(), 1)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"RoutesByQuery", "RouteByID"}, methods)
+}
+
+func TestPing(t *testing.T) {
+	db, err := dbOpen(t)
+	require.NoError(t, err)
+	dbmanager := NewDBManager(db, testDriver)
+	require.NoError(t, dbmanager.Ping(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, dbmanager.Ping(ctx))
+}
+
+func TestInTransaction(t *testing.T) {
+	db, err := dbOpen(t)
+	require.NoError(t, err)
+	dbmanager := NewDBManager(db, testDriver)
+	require.NoError(t, clearDB(dbmanager))
+
+	route := domain.Route{
+		Points: domain.Points{
+			StartPoint: "Minsk",
+			EndPoint:   "Vitebsk",
+		},
+		Start:     time.Date(2019, 02, 12, 10, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+	}
+	var id int
+	failed := errors.New("audit entry hasn't added")
+	err = dbmanager.InTransaction(context.Background(), func(ctx context.Context) error {
+		id, err = dbmanager.AddRoute(ctx, &route)
+		require.NoError(t, err)
+		_, err = dbmanager.RouteByID(ctx, id)
+		require.NoError(t, err)
+		return failed
+	})
+	assert.Equal(t, failed, err)
+	_, err = dbmanager.RouteByID(context.Background(), id)
+	assert.EqualError(t, err, "no such route")
+
+	err = dbmanager.InTransaction(context.Background(), func(ctx context.Context) error {
+		id, err = dbmanager.AddRoute(ctx, &route)
+		if err != nil {
+			return err
+		}
+		_, err = dbmanager.AddAuditEntry(ctx, &domain.AuditEntry{RouteID: id, Action: domain.ActionCreate,
+			Actor: "admin", Time: route.Start})
+		return err
+	})
+	require.NoError(t, err)
+	_, err = dbmanager.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	entries, err := dbmanager.AuditEntries(context.Background(), domain.AuditQuery{RouteID: id})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	require.NoError(t, clearDB(dbmanager))
+}
+
 func TestRouteID(t *testing.T) {
 
-	db, err := dbOpen()
+	db, err := dbOpen(t)
+	require.NoError(t, err)
+	dbmanager := NewDBManager(db, testDriver)
+	ctx := context.Background()
+	pointID, err := dbmanager.pointID(ctx, "Minsk", "Vitebsk")
 	require.NoError(t, err)
-	dbmanager := NewDBManager(db)
-	id1, err := dbmanager.insertRoute(7, 32, 44, 1500, "2019-02-24 08:30:00")
+	err = dbmanager.InTransaction(ctx, func(ctx context.Context) error {
+		id, err := dbmanager.pointID(ctx, "Minsk", "Vitebsk")
+		assert.Equal(t, pointID, id)
+		return err
+	})
 	require.NoError(t, err)
-	_, err = dbmanager.insertRoute(7, 32, 44, 1520, "02-24 08:30:00")
+	id1, err := insertRoute(ctx, dbmanager.db, int(pointID), 32, 44, domain.Money{Amount: 1500, Currency: "BYN"}, 120, 0,
+		"2019-02-24 08:30:00")
+	require.NoError(t, err)
+	_, err = insertRoute(ctx, dbmanager.db, int(pointID), 32, 44, domain.Money{Amount: 1520, Currency: "BYN"}, 120, 0,
+		"02-24 08:30:00")
 	require.Error(t, err, "invalid format of date")
 
-	_, err = dbmanager.RouteByID(int(id1))
+	_, err = dbmanager.RouteByID(ctx, int(id1))
 	assert.NoError(t, err)
 
-	_, err = db.Exec("DELETE FROM route where id_route=?", id1)
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM route where id_route=?", id1)
 	assert.NoError(t, err)
 }
 
@@ -58,7 +192,7 @@ func TestAddRoute(t *testing.T) {
 				EndPoint:   "Vitebsk",
 			},
 			Start:     time.Date(2019, 02, 12, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
@@ -68,34 +202,34 @@ func TestAddRoute(t *testing.T) {
 				EndPoint:   "Lida",
 			},
 			Start:     time.Date(2019, 04, 10, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
 	}
-	db, err := dbOpen()
+	db, err := dbOpen(t)
 	require.NoError(t, err)
-	dbmanager := NewDBManager(db)
+	dbmanager := NewDBManager(db, testDriver)
 
-	id, err := dbmanager.AddRoute(&routes[0])
+	id, err := dbmanager.AddRoute(context.Background(), &routes[0])
 	require.NoError(t, err)
 
-	_, err = db.Exec("DELETE FROM route where id_route=?", id)
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM route where id_route=?", id)
 	assert.NoError(t, err)
 
-	id, err = dbmanager.AddRoute(&routes[1])
+	id, err = dbmanager.AddRoute(context.Background(), &routes[1])
 	require.NoError(t, err)
-	_, err = db.Exec("DELETE FROM points where startpoint=? && endpoint=?", "Minsk", "Lida")
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM points where startpoint=? AND endpoint=?", "Minsk", "Lida")
 	assert.NoError(t, err)
 
-	_, err = db.Exec("DELETE FROM route where id_route=?", id)
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM route where id_route=?", id)
 	assert.NoError(t, err)
 }
 
-func TestGetAllData(t *testing.T) {
-	db, err := dbOpen()
+func TestAllRoutes(t *testing.T) {
+	db, err := dbOpen(t)
 	require.NoError(t, err)
-	dbmanager := NewDBManager(db)
+	dbmanager := NewDBManager(db, testDriver)
 
 	route := domain.Route{
 		Points: domain.Points{
@@ -103,30 +237,30 @@ func TestGetAllData(t *testing.T) {
 			EndPoint:   "Vitebsk",
 		},
 		Start:     time.Date(2019, 02, 12, 10, 0, 0, 0, time.UTC),
-		Cost:      1000,
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 		FreeSeats: 12,
 		AllSeats:  13,
 	}
-	id1, err := dbmanager.AddRoute(&route)
+	id1, err := dbmanager.AddRoute(context.Background(), &route)
 	require.NoError(t, err)
-	id2, err := dbmanager.AddRoute(&route)
+	id2, err := dbmanager.AddRoute(context.Background(), &route)
 	require.NoError(t, err)
-	id3, err := dbmanager.AddRoute(&route)
+	id3, err := dbmanager.AddRoute(context.Background(), &route)
 	require.NoError(t, err)
 
-	routes, err := dbmanager.GetAllData()
+	routes, _, err := dbmanager.RoutesByQuery(context.Background(), domain.RouteQuery{})
 	assert.NoError(t, err)
 
 	assert.Equal(t, 3, len(routes))
 
-	_, err = db.Exec("DELETE FROM route where id_route in (?, ?, ?)", id1, id2, id3)
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM route where id_route in (?, ?, ?)", id1, id2, id3)
 	assert.NoError(t, err)
 }
 
 func TestDeleteRoute(t *testing.T) {
-	db, err := dbOpen()
+	db, err := dbOpen(t)
 	require.NoError(t, err)
-	dbmanager := NewDBManager(db)
+	dbmanager := NewDBManager(db, testDriver)
 
 	route := domain.Route{
 		Points: domain.Points{
@@ -134,24 +268,87 @@ func TestDeleteRoute(t *testing.T) {
 			EndPoint:   "Vitebsk",
 		},
 		Start:     time.Date(2019, 02, 12, 10, 0, 0, 0, time.UTC),
-		Cost:      1000,
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 		FreeSeats: 12,
 		AllSeats:  13,
 	}
-	id, err := dbmanager.AddRoute(&route)
+	id, err := dbmanager.AddRoute(context.Background(), &route)
 	require.NoError(t, err)
-	err = dbmanager.DeleteRow(id)
+	err = dbmanager.DeleteRow(context.Background(), id, 1)
 	require.NoError(t, err)
 
-	_, err = dbmanager.RouteByID(id)
+	_, err = dbmanager.RouteByID(context.Background(), id)
 
 	assert.EqualError(t, err, "no such route")
+
+	var deletedAt sql.NullString
+	err = dbmanager.db.QueryRowContext(context.Background(), "SELECT deleted_at FROM route WHERE id_route=?", id).
+		Scan(&deletedAt)
+	require.NoError(t, err)
+	assert.True(t, deletedAt.Valid)
+}
+
+func TestArchiveRoutes(t *testing.T) {
+	db, err := dbOpen(t)
+	require.NoError(t, err)
+	dbmanager := NewDBManager(db, testDriver)
+	require.NoError(t, clearDB(dbmanager))
+	ctx := context.Background()
+
+	route := domain.Route{
+		Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Grodno"},
+		Start:     time.Date(2019, 02, 12, 10, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 2500, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  12,
+		Stops: []domain.Stop{
+			{Point: "Minsk", FreeSeats: 12},
+			{Point: "Lida", Arrival: time.Hour, Departure: time.Hour, Fare: 1500, FreeSeats: 12},
+			{Point: "Grodno", Arrival: 2 * time.Hour, Departure: 2 * time.Hour, Fare: 1000, FreeSeats: 12},
+		},
+	}
+	id, err := dbmanager.AddRoute(ctx, &route)
+	require.NoError(t, err)
+	ticket, err := dbmanager.BookSeat(ctx, &domain.Ticket{RouteID: id, Passenger: "Ivanov", From: "Minsk",
+		To: "Lida", Cost: domain.Money{Amount: 1500, Currency: "BYN"}, Booked: route.Start.Add(-time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, dbmanager.DeleteRow(ctx, id, 2))
+
+	archived, err := dbmanager.ArchiveRoutes(ctx, route.Start.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived)
+
+	var start dbTime
+	var status string
+	var deletedAt sql.NullString
+	err = dbmanager.db.QueryRowContext(ctx, "SELECT starttime, status, deleted_at FROM route_archive WHERE id_route=?",
+		id).Scan(&start, &status, &deletedAt)
+	require.NoError(t, err)
+	assert.Equal(t, route.Start, start.Time)
+	assert.Equal(t, domain.StatusScheduled, status)
+	assert.True(t, deletedAt.Valid)
+
+	var stops, rows int
+	err = dbmanager.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stop_archive WHERE id_route=?", id).Scan(&stops)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stops)
+	var passenger string
+	err = dbmanager.db.QueryRowContext(ctx, "SELECT passenger FROM ticket_archive WHERE id_ticket=?", ticket).
+		Scan(&passenger)
+	require.NoError(t, err)
+	assert.Equal(t, "Ivanov", passenger)
+
+	for _, table := range []string{"route", "stop", "ticket"} {
+		err = dbmanager.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&rows)
+		require.NoError(t, err)
+		assert.Equal(t, 0, rows, table)
+	}
 }
 
 func TestFindRoute(t *testing.T) {
-	db, err := dbOpen()
+	db, err := dbOpen(t)
 	require.NoError(t, err)
-	dbmanager := NewDBManager(db)
+	dbmanager := NewDBManager(db, testDriver)
 
 	routes := []domain.Route{
 		{
@@ -161,7 +358,7 @@ func TestFindRoute(t *testing.T) {
 				EndPoint:   "Vitebsk",
 			},
 			Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
@@ -171,7 +368,7 @@ func TestFindRoute(t *testing.T) {
 				EndPoint:   "Vitebsk",
 			},
 			Start:     time.Date(2019, 02, 12, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
@@ -181,22 +378,293 @@ func TestFindRoute(t *testing.T) {
 				EndPoint:   "Lida",
 			},
 			Start:     time.Date(2019, 04, 10, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
 	}
-	id1, err := dbmanager.AddRoute(&routes[0])
+	id1, err := dbmanager.AddRoute(context.Background(), &routes[0])
 	require.NoError(t, err)
-	id2, err := dbmanager.AddRoute(&routes[1])
+	id2, err := dbmanager.AddRoute(context.Background(), &routes[1])
 	require.NoError(t, err)
-	id3, err := dbmanager.AddRoute(&routes[2])
+	id3, err := dbmanager.AddRoute(context.Background(), &routes[2])
 	require.NoError(t, err)
 
-	rts, err := dbmanager.RoutesByEndPoint("Vitebsk")
+	rts, _, err := dbmanager.RoutesByQuery(context.Background(), domain.RouteQuery{EndPoint: "Vitebsk"})
 	assert.NoError(t, err)
 
 	assert.Equal(t, 2, len(rts))
-	_, err = db.Exec("DELETE FROM route where id_route in (?, ?, ?)", id1, id2, id3)
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM route where id_route in (?, ?, ?)", id1, id2, id3)
+	assert.NoError(t, err)
+}
+
+func TestBookSeat(t *testing.T) {
+	db, err := dbOpen(t)
+	require.NoError(t, err)
+	dbmanager := NewDBManager(db, testDriver)
+
+	route := domain.Route{
+		Points: domain.Points{
+			StartPoint: "Minsk",
+			EndPoint:   "Vitebsk",
+		},
+		Start:     time.Date(2019, 02, 12, 10, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 3,
+		AllSeats:  3,
+	}
+	id, err := dbmanager.AddRoute(context.Background(), &route)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var tickets []int
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticketID, err := dbmanager.BookSeat(context.Background(), &domain.Ticket{RouteID: id, Passenger: "Ivanov",
+				Booked: time.Date(2019, 02, 10, 10, 0, 0, 0, time.UTC)})
+			if err != nil {
+				assert.EqualError(t, err, "no free seats")
+				return
+			}
+			mu.Lock()
+			tickets = append(tickets, ticketID)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 3, len(tickets))
+
+	rt, err := dbmanager.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rt.FreeSeats)
+
+	err = dbmanager.CancelBooking(context.Background(), tickets[0])
+	require.NoError(t, err)
+	err = dbmanager.CancelBooking(context.Background(), tickets[0])
+	assert.EqualError(t, err, "no such ticket")
+
+	rt, err = dbmanager.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rt.FreeSeats)
+
+	_, err = dbmanager.BookSeat(context.Background(), &domain.Ticket{RouteID: -1, Passenger: "Ivanov"})
+	assert.EqualError(t, err, "no such route")
+
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM ticket where id_route=?", id)
+	assert.NoError(t, err)
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM route where id_route=?", id)
+	assert.NoError(t, err)
+}
+
+func TestUpdateRoute(t *testing.T) {
+	db, err := dbOpen(t)
+	require.NoError(t, err)
+	dbmanager := NewDBManager(db, testDriver)
+
+	route := domain.Route{
+		Points: domain.Points{
+			StartPoint: "Minsk",
+			EndPoint:   "Vitebsk",
+		},
+		Start:     time.Date(2019, 02, 12, 10, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+		Status:    domain.StatusScheduled,
+	}
+	id, err := dbmanager.AddRoute(context.Background(), &route)
+	require.NoError(t, err)
+
+	route.ID, route.Version = id, 1
+	route.Points.EndPoint = "Lida"
+	route.Cost.Amount = 1500
+	route.Duration = 2*time.Hour + 30*time.Minute
+	err = dbmanager.UpdateRoute(context.Background(), &route)
+	require.NoError(t, err)
+	err = dbmanager.UpdateRoute(context.Background(), &route)
+	assert.EqualError(t, err, "route was changed")
+	route.Version = 2
+	err = dbmanager.UpdateRoute(context.Background(), &route)
+	require.NoError(t, err)
+
+	rt, err := dbmanager.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	route.Version = 3
+	assert.Equal(t, route, *rt)
+
+	route.ID = -1
+	err = dbmanager.UpdateRoute(context.Background(), &route)
+	assert.EqualError(t, err, "no such route")
+
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM route where id_route=?", id)
+	assert.NoError(t, err)
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM points where startpoint=? AND endpoint=?", "Minsk", "Lida")
+	assert.NoError(t, err)
+}
+
+func TestRoutesByQuery(t *testing.T) {
+	db, err := dbOpen(t)
+	require.NoError(t, err)
+	dbmanager := NewDBManager(db, testDriver)
+
+	routes := []domain.Route{
+		{
+			Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+			Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1500, Currency: "BYN"},
+			FreeSeats: 2,
+		},
+		{
+			Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+			Start:     time.Date(2019, 04, 10, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+		},
+		{
+			Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+			Start:     time.Date(2019, 05, 1, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 2000, Currency: "BYN"},
+			FreeSeats: 0,
+		},
+	}
+	var ids []int
+	for i := range routes {
+		id, err := dbmanager.AddRoute(context.Background(), &routes[i])
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	rts, total, err := dbmanager.RoutesByQuery(context.Background(), domain.RouteQuery{
+		StartPoint:   "Minsk",
+		EndPoint:     "Vitebsk",
+		From:         time.Date(2019, 04, 1, 0, 0, 0, 0, time.UTC),
+		To:           time.Date(2019, 05, 1, 0, 0, 0, 0, time.UTC),
+		DepartAfter:  9 * time.Hour,
+		DepartBefore: 11 * time.Hour,
+		MinFreeSeats: 1,
+		MaxCost:      1500,
+		SortBy:       domain.SortByCost,
+		Desc:         true,
+		Limit:        1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Equal(t, 1, len(rts))
+	assert.Equal(t, ids[0], rts[0].ID)
+
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM route where id_route in (?, ?, ?)", ids[0], ids[1], ids[2])
 	assert.NoError(t, err)
 }
+
+func TestStops(t *testing.T) {
+	db, err := dbOpen(t)
+	require.NoError(t, err)
+	dbmanager := NewDBManager(db, testDriver)
+
+	route := domain.Route{
+		Points: domain.Points{
+			StartPoint: "Brest",
+			EndPoint:   "Vitebsk",
+		},
+		Start:     time.Date(2019, 04, 12, 8, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 3000, Currency: "BYN"},
+		FreeSeats: 1,
+		AllSeats:  1,
+		Duration:  8 * time.Hour,
+		Stops: []domain.Stop{
+			{Point: "Brest", FreeSeats: 1},
+			{Point: "Minsk", Arrival: 4 * time.Hour, Departure: 4*time.Hour + 20*time.Minute, Fare: 2000,
+				FreeSeats: 1},
+			{Point: "Vitebsk", Arrival: 8 * time.Hour, Departure: 8 * time.Hour, Fare: 1000, FreeSeats: 1},
+		},
+	}
+	id, err := dbmanager.AddRoute(context.Background(), &route)
+	require.NoError(t, err)
+
+	rt, err := dbmanager.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, route.Stops, rt.Stops)
+
+	rts, _, err := dbmanager.RoutesByQuery(context.Background(), domain.RouteQuery{StartPoint: "Minsk", EndPoint: "Vitebsk"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(rts))
+	rts, _, err = dbmanager.RoutesByQuery(context.Background(), domain.RouteQuery{StartPoint: "Minsk", EndPoint: "Brest"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(rts))
+
+	first, err := dbmanager.BookSeat(context.Background(), &domain.Ticket{RouteID: id, Passenger: "Ivanov", From: "Brest", To: "Minsk"})
+	require.NoError(t, err)
+	_, err = dbmanager.BookSeat(context.Background(), &domain.Ticket{RouteID: id, Passenger: "Petrov", From: "Minsk", To: "Vitebsk"})
+	require.NoError(t, err)
+	_, err = dbmanager.BookSeat(context.Background(), &domain.Ticket{RouteID: id, Passenger: "Sidorov", From: "Brest", To: "Vitebsk"})
+	assert.EqualError(t, err, "no free seats")
+
+	err = dbmanager.CancelBooking(context.Background(), first)
+	require.NoError(t, err)
+	rt, err = dbmanager.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rt.FreeSeats)
+	assert.Equal(t, 1, rt.Stops[0].FreeSeats)
+
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM ticket where id_route=?", id)
+	assert.NoError(t, err)
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM stop where id_route=?", id)
+	assert.NoError(t, err)
+	_, err = dbmanager.db.ExecContext(context.Background(), "DELETE FROM route where id_route=?", id)
+	assert.NoError(t, err)
+}
+
+func TestSchedules(t *testing.T) {
+	db, err := dbOpen(t)
+	require.NoError(t, err)
+	dbmanager := NewDBManager(db, testDriver)
+
+	schedule := domain.Schedule{
+		Points:     domain.Points{StartPoint: "Minsk", EndPoint: "Grodno"},
+		Departure:  9 * time.Hour,
+		Weekdays:   []time.Weekday{time.Monday, time.Friday},
+		ValidFrom:  time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:    time.Date(2019, 5, 31, 0, 0, 0, 0, time.UTC),
+		Exceptions: []time.Time{time.Date(2019, 5, 13, 0, 0, 0, 0, time.UTC)},
+		Cost:       domain.Money{Amount: 1500, Currency: "BYN"},
+		AllSeats:   40,
+		Duration:   4 * time.Hour,
+	}
+	id, err := dbmanager.AddSchedule(context.Background(), &schedule)
+	require.NoError(t, err)
+
+	s, err := dbmanager.ScheduleByID(context.Background(), id)
+	require.NoError(t, err)
+	schedule.ID = id
+	assert.Equal(t, schedule, *s)
+
+	s.Exceptions = nil
+	s.Cost.Amount = 2000
+	require.NoError(t, dbmanager.UpdateSchedule(context.Background(), s))
+	s, err = dbmanager.ScheduleByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 2000, s.Cost.Amount)
+	assert.Empty(t, s.Exceptions)
+
+	trip := s.Trip(time.Date(2019, 5, 10, 0, 0, 0, 0, time.UTC))
+	routeID, err := dbmanager.AddRoute(context.Background(), &trip)
+	require.NoError(t, err)
+	rt, err := dbmanager.RouteByID(context.Background(), routeID)
+	require.NoError(t, err)
+	assert.Equal(t, id, rt.ScheduleID)
+
+	starts, err := dbmanager.ScheduledStarts(context.Background(), id, time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{trip.Start}, starts)
+
+	require.NoError(t, dbmanager.DeleteSchedule(context.Background(), id))
+	_, err = dbmanager.ScheduleByID(context.Background(), id)
+	assert.EqualError(t, err, "no such schedule")
+	rt, err = dbmanager.RouteByID(context.Background(), routeID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rt.ScheduleID)
+}
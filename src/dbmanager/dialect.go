@@ -0,0 +1,176 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/JaneKetko/Buses/src/config"
+)
+
+//dialect - struct for describing differences of SQL between supported databases.
+//Queries in DBManager are written with ? placeholders and rewritten for dialect before execution.
+type dialect struct {
+	//numbered is true if placeholders are $1, $2, ... instead of ?.
+	numbered bool
+	//returning is true if ids of inserted rows are got by RETURNING clause instead of LastInsertId.
+	returning bool
+	//upsertID is true if INSERT of duplicate row returns id of existing row by ON DUPLICATE KEY UPDATE,
+	//otherwise the duplicate is skipped by ON CONFLICT DO NOTHING and the row has to be selected.
+	upsertID bool
+	//timeOfDay - expression for time of day of start of route.
+	timeOfDay string
+}
+
+//dialectOf returns dialect of SQL for driver from config.
+func dialectOf(driver string) dialect {
+	if driver == config.DriverPostgres {
+		return dialect{numbered: true, returning: true, timeOfDay: "CAST(r.starttime AS TIME)"}
+	}
+	if driver == config.DriverMySQL {
+		return dialect{upsertID: true, timeOfDay: "TIME(r.starttime)"}
+	}
+	return dialect{timeOfDay: "TIME(r.starttime)"}
+}
+
+//isDuplicate checks if error of any supported driver is violation of unique key.
+func isDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	var pqErr *pq.Error
+	var sqliteErr *sqlite.Error
+	switch {
+	case errors.As(err, &mysqlErr):
+		return mysqlErr.Number == 1062
+	case errors.As(err, &pqErr):
+		return pqErr.Code == "23505"
+	case errors.As(err, &sqliteErr):
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+	return false
+}
+
+//rebind replaces ? placeholders in query with placeholders of dialect.
+func (d dialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c != '?' {
+			b.WriteRune(c)
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+//execer - interface for executing queries in database or in transaction.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+//database - struct for executing queries with ? placeholders in database or in transaction
+//of given dialect.
+type database struct {
+	ex      execer
+	dialect dialect
+}
+
+//ExecContext executes query without returning rows.
+func (d database) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return d.ex.ExecContext(ctx, d.dialect.rebind(query), args...)
+}
+
+//QueryContext executes query which returns rows.
+func (d database) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.ex.QueryContext(ctx, d.dialect.rebind(query), args...)
+}
+
+//QueryRowContext executes query which returns at most one row.
+func (d database) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return d.ex.QueryRowContext(ctx, d.dialect.rebind(query), args...)
+}
+
+//insert executes INSERT query and returns value of id column of inserted row.
+func (d database) insert(ctx context.Context, query, idColumn string, args ...interface{}) (int64, error) {
+	var id int64
+	if d.dialect.returning {
+		err := d.QueryRowContext(ctx, query+" RETURNING "+idColumn, args...).Scan(&id)
+		return id, err
+	}
+
+	res, err := d.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+//transaction - struct for executing queries of dialect in transaction.
+//Transaction without tx continues outer transaction and doesn't commit or abort it.
+type transaction struct {
+	database
+	tx *sql.Tx
+}
+
+//Commit commits the transaction.
+func (t *transaction) Commit() error {
+	if t.tx == nil {
+		return nil
+	}
+	return t.tx.Commit()
+}
+
+//Rollback aborts the transaction.
+func (t *transaction) Rollback() error {
+	if t.tx == nil {
+		return nil
+	}
+	return t.tx.Rollback()
+}
+
+//dbTime - struct for reading time from DATETIME, DATE or TIMESTAMPTZ column which can be
+//returned by driver as time.Time or as text.
+type dbTime struct {
+	time.Time
+}
+
+//Scan implements sql.Scanner.
+func (t *dbTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		t.Time = v.UTC()
+		return nil
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	}
+	return fmt.Errorf("unsupported type %T of time", value)
+}
+
+//parse parses time in format of datetime or date.
+func (t *dbTime) parse(value string) error {
+	var err error
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+		t.Time, err = time.Parse(layout, value)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//nullBody returns body of response or NULL if there is no body.
+func nullBody(body []byte) sql.NullString {
+	return sql.NullString{String: string(body), Valid: len(body) > 0}
+}
+
+//AddIdempotencyRecord removes expired records and adds record of request which is handled.
+func (dbmanager *DBManager) AddIdempotencyRecord(ctx context.Context, rec *domain.IdempotencyRecord) error {
+	defer dbmanager.measure("AddIdempotencyRecord", time.Now())
+	_, err := dbmanager.conn(ctx).ExecContext(ctx, "DELETE FROM idempotency_key WHERE expires<=?",
+		time.Now().UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return domain.Unavailable("idempotency key hasn't added")
+	}
+
+	_, err = dbmanager.conn(ctx).ExecContext(ctx, `INSERT INTO idempotency_key (idem_key, request_hash, status,
+		content_type, etag, body, expires) VALUES(?, ?, ?, ?, ?, ?, ?)`, rec.Key, rec.Hash, rec.Status,
+		rec.ContentType, rec.ETag, nullBody(rec.Body), rec.Expires.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		_, findErr := dbmanager.IdempotencyRecordByKey(ctx, rec.Key)
+		if findErr == nil {
+			return domain.Conflict("idempotency key is used")
+		}
+		return domain.Unavailable("idempotency key hasn't added")
+	}
+	return nil
+}
+
+//IdempotencyRecordByKey gets idempotency record by key.
+func (dbmanager *DBManager) IdempotencyRecordByKey(ctx context.Context, key string) (*domain.IdempotencyRecord,
+	error) {
+	defer dbmanager.measure("IdempotencyRecordByKey", time.Now())
+	var rec domain.IdempotencyRecord
+	var body sql.NullString
+	var expires dbTime
+	err := dbmanager.conn(ctx).QueryRowContext(ctx, `SELECT idem_key, request_hash, status, content_type, etag, body,
+		expires FROM idempotency_key WHERE idem_key=?`, key).Scan(&rec.Key, &rec.Hash, &rec.Status,
+		&rec.ContentType, &rec.ETag, &body, &expires)
+	if err == sql.ErrNoRows {
+		return nil, domain.NotFound("no such idempotency key")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if body.Valid {
+		rec.Body = []byte(body.String)
+	}
+	rec.Expires = expires.Time
+	return &rec, nil
+}
+
+//CompleteIdempotencyRecord saves response to request of idempotency record.
+func (dbmanager *DBManager) CompleteIdempotencyRecord(ctx context.Context, rec *domain.IdempotencyRecord) error {
+	defer dbmanager.measure("CompleteIdempotencyRecord", time.Now())
+	res, err := dbmanager.conn(ctx).ExecContext(ctx, `UPDATE idempotency_key SET status=?, content_type=?, etag=?,
+		body=? WHERE idem_key=?`, rec.Status, rec.ContentType, rec.ETag, nullBody(rec.Body), rec.Key)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		_, err = dbmanager.IdempotencyRecordByKey(ctx, rec.Key)
+	}
+	return err
+}
+
+//DeleteIdempotencyRecord removes idempotency record by key.
+func (dbmanager *DBManager) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	defer dbmanager.measure("DeleteIdempotencyRecord", time.Now())
+	res, err := dbmanager.conn(ctx).ExecContext(ctx, "DELETE FROM idempotency_key WHERE idem_key=?", key)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.NotFound("no such idempotency key")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package dbmanager
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//IdentityByName gets identity of client with roles ordered by name of role.
+func (dbmanager *DBManager) IdentityByName(ctx context.Context, name string) (*domain.Identity, error) {
+	defer dbmanager.measure("IdentityByName", time.Now())
+	identities, err := dbmanager.identities(ctx, " WHERE name=?", name)
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		return nil, domain.NotFound("no such identity")
+	}
+	return &identities[0], nil
+}
+
+//GetAllIdentities gets all identities ordered by name.
+func (dbmanager *DBManager) GetAllIdentities(ctx context.Context) ([]domain.Identity, error) {
+	defer dbmanager.measure("GetAllIdentities", time.Now())
+	return dbmanager.identities(ctx, "")
+}
+
+//identities reads roles by condition and groups them by name of client.
+func (dbmanager *DBManager) identities(ctx context.Context, where string, args ...interface{}) (
+	[]domain.Identity, error) {
+	rows, err := dbmanager.conn(ctx).QueryContext(ctx, "SELECT name, role FROM identity_role"+where+
+		" ORDER BY name, role", args...)
+	if err != nil {
+		return nil, domain.Unavailable("data hasn't read")
+	}
+
+	defer func() {
+		err = rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	var identities []domain.Identity
+	for rows.Next() {
+		var name, role string
+		err = rows.Scan(&name, &role)
+		if err != nil {
+			return nil, errors.New("no data")
+		}
+		if len(identities) == 0 || identities[len(identities)-1].Name != name {
+			identities = append(identities, domain.Identity{Name: name})
+		}
+		last := &identities[len(identities)-1]
+		last.Roles = append(last.Roles, role)
+	}
+	return identities, nil
+}
+
+//SaveIdentity replaces roles of client by roles of identity.
+func (dbmanager *DBManager) SaveIdentity(ctx context.Context, i *domain.Identity) error {
+	defer dbmanager.measure("SaveIdentity", time.Now())
+	tx, err := dbmanager.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM identity_role WHERE name=?", i.Name)
+	if err != nil {
+		return err
+	}
+	for _, role := range i.Roles {
+		_, err = tx.ExecContext(ctx, "INSERT INTO identity_role (name, role) VALUES(?, ?)", i.Name, role)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//DeleteIdentity removes all roles of client.
+func (dbmanager *DBManager) DeleteIdentity(ctx context.Context, name string) error {
+	defer dbmanager.measure("DeleteIdentity", time.Now())
+	res, err := dbmanager.conn(ctx).ExecContext(ctx, "DELETE FROM identity_role WHERE name=?", name)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.NotFound("no such identity")
+	}
+	return nil
+}
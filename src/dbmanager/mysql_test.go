@@ -0,0 +1,27 @@
+//+build testdb
+
+package dbmanager
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/JaneKetko/Buses/src/config"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+//testDriver - driver of database of tests.
+const testDriver = config.DriverMySQL
+
+func dbOpen(t *testing.T) (*sql.DB, error) {
+	return migratedDB(&config.Config{
+		PortServer: 8000,
+		Driver:     testDriver,
+		Login:      "root",
+		Passwd:     "root",
+		Hostname:   "172.17.0.2",
+		Port:       3306,
+		DBName:     "busstationtest",
+	})
+}
@@ -0,0 +1,28 @@
+//+build testpg
+
+package dbmanager
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/JaneKetko/Buses/src/config"
+
+	_ "github.com/lib/pq"
+)
+
+//testDriver - driver of database of tests.
+const testDriver = config.DriverPostgres
+
+func dbOpen(t *testing.T) (*sql.DB, error) {
+	return migratedDB(&config.Config{
+		PortServer: 8000,
+		Driver:     testDriver,
+		Login:      "postgres",
+		Passwd:     "postgres",
+		Hostname:   "172.17.0.2",
+		Port:       5432,
+		DBName:     "busstationtest",
+		SSLMode:    "disable",
+	})
+}
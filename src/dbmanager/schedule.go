@@ -0,0 +1,287 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//ScheduleDB - struct for reading schedule from database.
+type ScheduleDB struct {
+	idSchedule int
+	departure  int
+	weekdays   int
+	validFrom  dbTime
+	validTo    dbTime
+	cost       int
+	currency   string
+	allSeats   int
+	duration   int
+	startPoint string
+	endPoint   string
+}
+
+//selectSchedules - beginning of query for selecting schedules with their points.
+const selectSchedules = `SELECT s.id_schedule, s.departure, s.weekdays, s.validfrom, s.validto, s.cost,
+	s.currency, s.allseats, s.duration, p.startpoint, p.endpoint
+	FROM schedule s JOIN points p ON s.id_points = p.id_points`
+
+//weekdayMask packs weekdays into bitmask for storing in database.
+func weekdayMask(weekdays []time.Weekday) int {
+	var mask int
+	for _, weekday := range weekdays {
+		mask |= 1 << uint(weekday)
+	}
+	return mask
+}
+
+//maskWeekdays unpacks weekdays from bitmask.
+func maskWeekdays(mask int) []time.Weekday {
+	var weekdays []time.Weekday
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if mask&(1<<uint(weekday)) != 0 {
+			weekdays = append(weekdays, weekday)
+		}
+	}
+	return weekdays
+}
+
+//convertSchedule - convert ScheduleDB to Schedule.
+func convertSchedule(s ScheduleDB) domain.Schedule {
+	return domain.Schedule{ID: s.idSchedule,
+		Points:    domain.Points{StartPoint: s.startPoint, EndPoint: s.endPoint},
+		Departure: time.Duration(s.departure) * time.Minute,
+		Weekdays:  maskWeekdays(s.weekdays),
+		ValidFrom: s.validFrom.Time,
+		ValidTo:   s.validTo.Time,
+		Cost:      domain.Money{Amount: s.cost, Currency: s.currency},
+		AllSeats:  s.allSeats,
+		Duration:  time.Duration(s.duration) * time.Minute}
+}
+
+//querySchedules selects schedules from database by query.
+func (dbmanager *DBManager) querySchedules(ctx context.Context, query string,
+	args ...interface{}) ([]domain.Schedule, error) {
+	rows, err := dbmanager.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, domain.Unavailable("data hasn't read")
+	}
+
+	defer func() {
+		err = rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	var dbs ScheduleDB
+	var schedules []domain.Schedule
+	for rows.Next() {
+		err = rows.Scan(&dbs.idSchedule, &dbs.departure, &dbs.weekdays, &dbs.validFrom, &dbs.validTo,
+			&dbs.cost, &dbs.currency, &dbs.allSeats, &dbs.duration, &dbs.startPoint, &dbs.endPoint)
+		if err != nil {
+			return nil, errors.New("no data")
+		}
+		schedules = append(schedules, convertSchedule(dbs))
+	}
+
+	err = dbmanager.loadExceptions(ctx, schedules)
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+//loadExceptions fills exception days of schedules from database.
+func (dbmanager *DBManager) loadExceptions(ctx context.Context, schedules []domain.Schedule) error {
+	for i := range schedules {
+		rows, err := dbmanager.conn(ctx).QueryContext(ctx, `SELECT day FROM schedule_exception
+			WHERE id_schedule=? ORDER BY day`, schedules[i].ID)
+		if err != nil {
+			return domain.Unavailable("data hasn't read")
+		}
+		for rows.Next() {
+			var day dbTime
+			err = rows.Scan(&day)
+			if err != nil {
+				log.Println(rows.Close())
+				return errors.New("no data")
+			}
+			schedules[i].Exceptions = append(schedules[i].Exceptions, day.Time)
+		}
+		err = rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	return nil
+}
+
+//insertExceptions adds exception days of the schedule to database.
+func insertExceptions(ctx context.Context, ex execer, scheduleID int64, days []time.Time) error {
+	for _, day := range days {
+		_, err := ex.ExecContext(ctx, "INSERT INTO schedule_exception (id_schedule, day) VALUES( ?, ? )",
+			scheduleID, day.Format("2006-01-02"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//AddSchedule adds schedule to database.
+func (dbmanager *DBManager) AddSchedule(ctx context.Context, s *domain.Schedule) (int, error) {
+	defer dbmanager.measure("AddSchedule", time.Now())
+	pointID, err := dbmanager.pointID(ctx, s.Points.StartPoint, s.Points.EndPoint)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := dbmanager.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer rollback(tx)
+
+	id, err := tx.insert(ctx, `INSERT INTO schedule (id_points, departure, weekdays, validfrom, validto, cost,
+		currency, allseats, duration) VALUES( ?, ?, ?, ?, ?, ?, ?, ?, ? )`, "id_schedule", pointID,
+		minutes(s.Departure), weekdayMask(s.Weekdays), s.ValidFrom.Format("2006-01-02"),
+		s.ValidTo.Format("2006-01-02"), s.Cost.Amount, s.Cost.Currency, s.AllSeats, minutes(s.Duration))
+	if err != nil {
+		return 0, err
+	}
+	err = insertExceptions(ctx, tx, id, s.Exceptions)
+	if err != nil {
+		return 0, err
+	}
+	return int(id), tx.Commit()
+}
+
+//ScheduleByID finds schedule by id.
+func (dbmanager *DBManager) ScheduleByID(ctx context.Context, id int) (*domain.Schedule, error) {
+	defer dbmanager.measure("ScheduleByID", time.Now())
+	schedules, err := dbmanager.querySchedules(ctx, selectSchedules+" WHERE s.id_schedule=?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, domain.NotFound("no such schedule")
+	}
+	return &schedules[0], nil
+}
+
+//GetAllSchedules gets all schedules ordered by id.
+func (dbmanager *DBManager) GetAllSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	defer dbmanager.measure("GetAllSchedules", time.Now())
+	return dbmanager.querySchedules(ctx, selectSchedules+" ORDER BY s.id_schedule")
+}
+
+//scheduleExists checks that schedule with id is in database.
+func scheduleExists(ctx context.Context, tx *transaction, id int) error {
+	err := tx.QueryRowContext(ctx, "SELECT id_schedule FROM schedule WHERE id_schedule=?", id).Scan(&id)
+	if err == sql.ErrNoRows {
+		return domain.NotFound("no such schedule")
+	}
+	return err
+}
+
+//UpdateSchedule replaces data of existing schedule.
+func (dbmanager *DBManager) UpdateSchedule(ctx context.Context, s *domain.Schedule) error {
+	defer dbmanager.measure("UpdateSchedule", time.Now())
+	pointID, err := dbmanager.pointID(ctx, s.Points.StartPoint, s.Points.EndPoint)
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbmanager.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	res, err := tx.ExecContext(ctx, `UPDATE schedule SET id_points=?, departure=?, weekdays=?, validfrom=?, validto=?,
+		cost=?, currency=?, allseats=?, duration=? WHERE id_schedule=?`, pointID, minutes(s.Departure),
+		weekdayMask(s.Weekdays), s.ValidFrom.Format("2006-01-02"), s.ValidTo.Format("2006-01-02"),
+		s.Cost.Amount, s.Cost.Currency, s.AllSeats, minutes(s.Duration), s.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		err = scheduleExists(ctx, tx, s.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM schedule_exception WHERE id_schedule=?", s.ID)
+	if err != nil {
+		return err
+	}
+	err = insertExceptions(ctx, tx, int64(s.ID), s.Exceptions)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//DeleteSchedule deletes schedule by id, generated routes are kept.
+func (dbmanager *DBManager) DeleteSchedule(ctx context.Context, id int) error {
+	defer dbmanager.measure("DeleteSchedule", time.Now())
+	tx, err := dbmanager.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM schedule_exception WHERE id_schedule=?", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE route SET id_schedule=NULL WHERE id_schedule=?", id)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM schedule WHERE id_schedule=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.NotFound("no such schedule")
+	}
+	return tx.Commit()
+}
+
+//ScheduledStarts finds start times of routes generated by schedule which start in period.
+//Deleted routes are included, so they aren't generated again.
+func (dbmanager *DBManager) ScheduledStarts(ctx context.Context, scheduleID int,
+	from, to time.Time) ([]time.Time, error) {
+	defer dbmanager.measure("ScheduledStarts", time.Now())
+	rows, err := dbmanager.conn(ctx).QueryContext(ctx, `SELECT starttime FROM route WHERE id_schedule=? AND starttime >= ?
+		AND starttime < ? ORDER BY starttime`, scheduleID, from.Format("2006-01-02 15:04:05"),
+		to.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, domain.Unavailable("data hasn't read")
+	}
+
+	defer func() {
+		err = rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	var starts []time.Time
+	for rows.Next() {
+		var start dbTime
+		err = rows.Scan(&start)
+		if err != nil {
+			return nil, errors.New("no data")
+		}
+		starts = append(starts, start.Time)
+	}
+	return starts, nil
+}
@@ -0,0 +1,31 @@
+//+build !testdb,!testpg
+
+package dbmanager
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/JaneKetko/Buses/src/config"
+
+	_ "modernc.org/sqlite"
+)
+
+//testDriver - driver of database of tests.
+const testDriver = config.DriverSQLite
+
+//dbOpen creates new SQLite database in temporary directory of the test.
+func dbOpen(t *testing.T) (*sql.DB, error) {
+	db, err := migratedDB(&config.Config{
+		Driver: testDriver,
+		DBFile: filepath.Join(t.TempDir(), "busstation.db"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db, nil
+}
@@ -0,0 +1,24 @@
+package dbmanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//SeatStats counts active routes which aren't deleted and start at or after time and their seats.
+func (dbmanager *DBManager) SeatStats(ctx context.Context, from time.Time) (domain.SeatStats, error) {
+	defer dbmanager.measure("SeatStats", time.Now())
+	where, args := queryConditions(domain.RouteQuery{From: from.UTC(), Statuses: domain.ActiveStatuses()},
+		dbmanager.db.dialect.timeOfDay)
+
+	var stats domain.SeatStats
+	err := dbmanager.conn(ctx).QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(r.freeseats), 0),
+		COALESCE(SUM(r.allseats), 0) FROM route r JOIN points p ON r.id_points = p.id_points`+where, args...).
+		Scan(&stats.Routes, &stats.FreeSeats, &stats.AllSeats)
+	if err != nil {
+		return domain.SeatStats{}, domain.Unavailable("data hasn't read")
+	}
+	return stats, nil
+}
@@ -0,0 +1,141 @@
+package dbmanager
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//minutes converts offset to number of minutes for storing in database.
+func minutes(d time.Duration) int {
+	return int(d / time.Minute)
+}
+
+//insertStops adds stops of the route to database.
+func insertStops(ctx context.Context, ex execer, routeID int64, stops []domain.Stop) error {
+	for i, stop := range stops {
+		_, err := ex.ExecContext(ctx, `INSERT INTO stop (id_route, position, point, arrival, departure, fare, freeseats)
+			VALUES( ?, ?, ?, ?, ?, ?, ? )`, routeID, i, stop.Point, minutes(stop.Arrival),
+			minutes(stop.Departure), stop.Fare, stop.FreeSeats)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//loadStops fills stops of routes from database.
+func (dbmanager *DBManager) loadStops(ctx context.Context, routes []domain.Route) error {
+	if len(routes) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(routes))
+	args := make([]interface{}, 0, len(routes))
+	for i, route := range routes {
+		index[route.ID] = i
+		args = append(args, route.ID)
+	}
+	rows, err := dbmanager.conn(ctx).QueryContext(ctx, `SELECT id_route, point, arrival, departure, fare, freeseats FROM stop
+		WHERE id_route IN (?`+strings.Repeat(", ?", len(args)-1)+`) ORDER BY id_route, position`, args...)
+	if err != nil {
+		return domain.Unavailable("data hasn't read")
+	}
+
+	defer func() {
+		err = rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	for rows.Next() {
+		var routeID, arrival, departure int
+		var stop domain.Stop
+		err = rows.Scan(&routeID, &stop.Point, &arrival, &departure, &stop.Fare, &stop.FreeSeats)
+		if err != nil {
+			return errors.New("no data")
+		}
+		stop.Arrival = time.Duration(arrival) * time.Minute
+		stop.Departure = time.Duration(departure) * time.Minute
+		i := index[routeID]
+		routes[i].Stops = append(routes[i].Stops, stop)
+	}
+	return nil
+}
+
+//stopPositions finds positions of stops of the ticket, ok is false if the route has no stops.
+func stopPositions(ctx context.Context, tx *transaction, t *domain.Ticket) (from, to int, ok bool, err error) {
+	rows, err := tx.QueryContext(ctx, "SELECT position, point FROM stop WHERE id_route=? ORDER BY position", t.RouteID)
+	if err != nil {
+		return 0, 0, false, domain.Unavailable("data hasn't read")
+	}
+
+	defer func() {
+		errClose := rows.Close()
+		if errClose != nil {
+			log.Println(errClose)
+		}
+	}()
+
+	from, to = -1, -1
+	for rows.Next() {
+		var position int
+		var point string
+		err = rows.Scan(&position, &point)
+		if err != nil {
+			return 0, 0, false, errors.New("no data")
+		}
+		ok = true
+		switch point {
+		case t.From:
+			from = position
+		case t.To:
+			to = position
+		}
+	}
+	switch {
+	case !ok:
+		return 0, 0, false, nil
+	case from < 0 || to < 0:
+		return 0, 0, true, domain.Invalid("no such stop")
+	case from >= to:
+		return 0, 0, true, domain.Invalid("stops order is invalid")
+	}
+	return from, to, true, nil
+}
+
+//updateRouteSeats sets free seats of the route to number of seats which are free on all segments.
+func updateRouteSeats(ctx context.Context, ex execer, routeID int) error {
+	_, err := ex.ExecContext(ctx, `UPDATE route SET freeseats = (SELECT MIN(s.freeseats) FROM stop s
+		WHERE s.id_route=? AND s.position < (SELECT MAX(l.position) FROM stop l WHERE l.id_route=?)),
+		version = version + 1 WHERE id_route=?`, routeID, routeID, routeID)
+	return err
+}
+
+//bookSegments takes one seat on every segment between stops.
+func bookSegments(ctx context.Context, tx *transaction, routeID, from, to int) error {
+	res, err := tx.ExecContext(ctx, `UPDATE stop SET freeseats = freeseats - 1
+		WHERE id_route=? AND position>=? AND position<? AND freeseats > 0`, routeID, from, to)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n != int64(to-from) {
+		return domain.Conflict("no free seats")
+	}
+	return updateRouteSeats(ctx, tx, routeID)
+}
+
+//releaseSegments frees one seat on every segment between stops.
+func releaseSegments(ctx context.Context, tx *transaction, routeID, from, to int) error {
+	_, err := tx.ExecContext(ctx, `UPDATE stop SET freeseats = freeseats + 1
+		WHERE id_route=? AND position>=? AND position<?`, routeID, from, to)
+	if err != nil {
+		return err
+	}
+	return updateRouteSeats(ctx, tx, routeID)
+}
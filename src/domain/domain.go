@@ -1,17 +1,80 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 )
 
 //Route - struct for describing route of any bus.
+//Delay shifts expected departure of the route from its Start.
+//Version is incremented by every change of the route, including booking of its seats.
 type Route struct {
-	ID        int
-	Points    Points
-	Start     time.Time
-	Cost      int
+	ID         int
+	Points     Points
+	Start      time.Time
+	Cost       Money
+	FreeSeats  int
+	AllSeats   int
+	Duration   time.Duration
+	Stops      []Stop
+	ScheduleID int
+	Status     string
+	Delay      time.Duration
+	Version    int
+}
+
+//Statuses of route. New routes are scheduled, departed and cancelled routes are final.
+const (
+	StatusScheduled = "scheduled"
+	StatusBoarding  = "boarding"
+	StatusDelayed   = "delayed"
+	StatusDeparted  = "departed"
+	StatusCancelled = "cancelled"
+)
+
+//ActiveStatuses returns statuses of routes which haven't departed and weren't cancelled.
+func ActiveStatuses() []string {
+	return []string{StatusScheduled, StatusBoarding, StatusDelayed}
+}
+
+//Arrival returns arrival time of the route.
+func (r Route) Arrival() time.Time {
+	return r.Start.Add(r.Duration)
+}
+
+//ExpectedStart returns departure time of the route shifted by delay.
+func (r Route) ExpectedStart() time.Time {
+	return r.Start.Add(r.Delay)
+}
+
+//StopIndex returns position of stop with point in the route or -1 if there is no such stop.
+func (r Route) StopIndex(point string) int {
+	for i, stop := range r.Stops {
+		if stop.Point == point {
+			return i
+		}
+	}
+	return -1
+}
+
+//SegmentCost returns cost of travel between stops with positions from and to.
+func (r Route) SegmentCost(from, to int) Money {
+	cost := Money{Currency: r.Cost.Currency}
+	for _, stop := range r.Stops[from+1 : to+1] {
+		cost.Amount += stop.Fare
+	}
+	return cost
+}
+
+//Stop - struct for describing stop of the route. Arrival and Departure are offsets from start
+//of the route, Fare is cost of segment from previous stop in minor units of route currency
+//and FreeSeats is number of free seats on segment to next stop.
+type Stop struct {
+	Point     string
+	Arrival   time.Duration
+	Departure time.Duration
+	Fare      int
 	FreeSeats int
-	AllSeats  int
 }
 
 //Points - struct for showing points of route.
@@ -19,3 +82,260 @@ type Points struct {
 	StartPoint string
 	EndPoint   string
 }
+
+//Ticket - struct for describing booked seat on the route.
+//From and To are stops of the route between which the seat is booked.
+type Ticket struct {
+	ID        int
+	RouteID   int
+	Passenger string
+	From      string
+	To        string
+	Cost      Money
+	Booked    time.Time
+}
+
+//Sort keys for routes.
+const (
+	SortByStart     = "start_time"
+	SortByCost      = "cost"
+	SortByFreeSeats = "freeseats"
+)
+
+//RouteQuery - struct for filtering, sorting and paginating routes.
+//Zero values of fields mean that filter isn't applied.
+//DepartAfter and DepartBefore bound departure time of day as offsets from midnight,
+//MaxCost is in minor units, Statuses selects routes with one of the statuses.
+type RouteQuery struct {
+	StartPoint   string
+	EndPoint     string
+	From         time.Time
+	To           time.Time
+	DepartAfter  time.Duration
+	DepartBefore time.Duration
+	MinFreeSeats int
+	MaxCost      int
+	Statuses     []string
+	SortBy       string
+	Desc         bool
+	Limit        int
+	Offset       int
+}
+
+//TimeOfDay returns offset of time from midnight.
+func TimeOfDay(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+}
+
+//Rank keys for journeys.
+const (
+	RankByArrival   = "arrival"
+	RankByCost      = "cost"
+	RankByTransfers = "transfers"
+)
+
+//JourneyQuery - struct for describing request for journey planning.
+type JourneyQuery struct {
+	From          string
+	To            string
+	Date          time.Time
+	MaxLegs       int
+	MinConnection time.Duration
+	RankBy        string
+}
+
+//Leg - struct for describing part of journey which passenger travels by the route from stop From
+//to stop To. Departure and Arrival are times of the route at these stops by timetable,
+//Cost is cost of travel between the stops.
+type Leg struct {
+	Route     Route
+	From      string
+	To        string
+	Departure time.Time
+	Arrival   time.Time
+	Cost      Money
+}
+
+//ExpectedDeparture returns departure time of the leg shifted by delay of its route.
+func (l Leg) ExpectedDeparture() time.Time {
+	return l.Departure.Add(l.Route.Delay)
+}
+
+//ExpectedArrival returns arrival time of the leg shifted by delay of its route.
+func (l Leg) ExpectedArrival() time.Time {
+	return l.Arrival.Add(l.Route.Delay)
+}
+
+//Journey - struct for describing itinerary from one or several legs.
+type Journey struct {
+	Legs []Leg
+}
+
+//Departure returns departure time of the first leg.
+func (j Journey) Departure() time.Time {
+	return j.Legs[0].Departure
+}
+
+//Arrival returns arrival time of the last leg.
+func (j Journey) Arrival() time.Time {
+	return j.Legs[len(j.Legs)-1].Arrival
+}
+
+//Duration returns total duration of journey including connections.
+func (j Journey) Duration() time.Duration {
+	return j.Arrival().Sub(j.Departure())
+}
+
+//Cost returns total cost of all legs, legs of journey have the same currency.
+func (j Journey) Cost() Money {
+	cost := Money{Currency: j.Legs[0].Cost.Currency}
+	for _, leg := range j.Legs {
+		cost.Amount += leg.Cost.Amount
+	}
+	return cost
+}
+
+//Transfers returns number of changes between routes.
+func (j Journey) Transfers() int {
+	return len(j.Legs) - 1
+}
+
+//Schedule - struct for describing template of regular trips. Departure is time of day
+//as offset from midnight, trips run on Weekdays from ValidFrom to ValidTo days inclusive
+//except days from Exceptions.
+type Schedule struct {
+	ID         int
+	Points     Points
+	Departure  time.Duration
+	Weekdays   []time.Weekday
+	ValidFrom  time.Time
+	ValidTo    time.Time
+	Exceptions []time.Time
+	Cost       Money
+	AllSeats   int
+	Duration   time.Duration
+}
+
+//RunsOn checks if there is trip by schedule on the day.
+func (s Schedule) RunsOn(day time.Time) bool {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if day.Before(s.ValidFrom) || day.After(s.ValidTo) {
+		return false
+	}
+	for _, exception := range s.Exceptions {
+		if exception.Equal(day) {
+			return false
+		}
+	}
+	for _, weekday := range s.Weekdays {
+		if weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+//Trip returns route of the schedule which starts on the day.
+func (s Schedule) Trip(day time.Time) Route {
+	return Route{
+		Points:     s.Points,
+		Start:      time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC).Add(s.Departure),
+		Cost:       s.Cost,
+		FreeSeats:  s.AllSeats,
+		AllSeats:   s.AllSeats,
+		Duration:   s.Duration,
+		ScheduleID: s.ID,
+		Status:     StatusScheduled,
+	}
+}
+
+//Actions of route which are recorded in audit log.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionStatus  = "status"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+//AuditEntry - struct for describing change of route made by actor.
+//Before and After are JSON snapshots of the route, Before is nil if route was created or restored,
+//After is nil if route was deleted.
+type AuditEntry struct {
+	ID      int
+	RouteID int
+	Action  string
+	Actor   string
+	Time    time.Time
+	Before  json.RawMessage
+	After   json.RawMessage
+}
+
+//AuditQuery - struct for filtering and paginating entries of audit log.
+//Zero values of fields mean that filter isn't applied, entries are in range [From, To).
+type AuditQuery struct {
+	RouteID int
+	Actor   string
+	From    time.Time
+	To      time.Time
+	Limit   int
+	Offset  int
+}
+
+//Roles of clients of API. Admin is allowed everything, other roles are allowed
+//only endpoints which they need for their work.
+const (
+	RoleAdmin      = "admin"
+	RoleDispatcher = "dispatcher"
+	RoleCashier    = "cashier"
+	RolePassenger  = "passenger"
+)
+
+//ValidRole checks if role is one of known roles.
+func ValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleDispatcher, RoleCashier, RolePassenger:
+		return true
+	}
+	return false
+}
+
+//Identity - struct for describing client of API with its roles. Name is name of API key
+//or subject of token of the client.
+type Identity struct {
+	Name  string
+	Roles []string
+}
+
+//HasRole checks if identity has one of roles.
+func (i Identity) HasRole(roles ...string) bool {
+	for _, have := range i.Roles {
+		for _, role := range roles {
+			if have == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//IdempotencyRecord - struct for describing response to request with idempotency key.
+//Hash identifies method, path and body of request, Status is zero while request is handled.
+//Record is kept until Expires.
+type IdempotencyRecord struct {
+	Key         string
+	Hash        string
+	Status      int
+	ContentType string
+	ETag        string
+	Body        []byte
+	Expires     time.Time
+}
+
+//SeatStats - struct for describing number of upcoming routes and their free and all seats.
+type SeatStats struct {
+	Routes    int
+	FreeSeats int
+	AllSeats  int
+}
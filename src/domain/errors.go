@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+)
+
+//Kinds of errors which are returned by storages and route manager.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrValidation   = errors.New("validation failed")
+	ErrConflict     = errors.New("conflict")
+	ErrPrecondition = errors.New("precondition failed")
+	ErrUnavailable  = errors.New("storage unavailable")
+)
+
+//Error - struct for describing error of known kind with message for client
+//and optional details.
+type Error struct {
+	Kind    error
+	Message string
+	Details string
+}
+
+//Error returns message of the error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+//ErrorKind returns kind of the error or nil if kind of the error is unknown.
+func ErrorKind(err error) error {
+	if e, ok := err.(*Error); ok {
+		return e.Kind
+	}
+	return nil
+}
+
+//NotFound creates error about missing data.
+func NotFound(message string) error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+//Invalid creates error about invalid data in request.
+func Invalid(message string) error {
+	return &Error{Kind: ErrValidation, Message: message}
+}
+
+//Conflict creates error about request which conflicts with current state of data.
+func Conflict(message string) error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+//PreconditionFailed creates error about request which expects other version of data.
+func PreconditionFailed(message string) error {
+	return &Error{Kind: ErrPrecondition, Message: message}
+}
+
+//Unavailable creates error about storage which can't be reached.
+func Unavailable(message string) error {
+	return &Error{Kind: ErrUnavailable, Message: message}
+}
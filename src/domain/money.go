@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//DefaultCurrency - currency of costs which are set without currency.
+const DefaultCurrency = "BYN"
+
+//Money - struct for describing exact amount of money in minor units (cents)
+//with ISO 4217 currency code.
+type Money struct {
+	Amount   int
+	Currency string
+}
+
+//String returns amount as decimal with currency code.
+func (m Money) String() string {
+	return FormatAmount(m.Amount) + " " + m.Currency
+}
+
+//ValidCurrency checks that code consists of three uppercase latin letters.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+//isDigits checks that s is not empty and consists of decimal digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+//ParseAmount converts decimal string like "10.29" to minor units without rounding.
+//Amount can have no more than two fractional digits.
+func ParseAmount(s string) (int, error) {
+	negative := strings.HasPrefix(s, "-")
+	parts := strings.SplitN(strings.TrimPrefix(s, "-"), ".", 2)
+	if !isDigits(parts[0]) || (len(parts) == 2 && !isDigits(parts[1])) {
+		return 0, Invalid(fmt.Sprintf("invalid amount %q", s))
+	}
+	var fraction string
+	if len(parts) == 2 {
+		fraction = parts[1]
+	}
+	if len(fraction) > 2 {
+		return 0, Invalid("amount has more than two fractional digits")
+	}
+
+	units, err := strconv.Atoi(parts[0])
+	if err != nil || units*100/100 != units {
+		return 0, Invalid(fmt.Sprintf("invalid amount %q", s))
+	}
+	cents, err := strconv.Atoi((fraction + "00")[:2])
+	if err != nil {
+		return 0, Invalid(fmt.Sprintf("invalid amount %q", s))
+	}
+	amount := units*100 + cents
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+//FormatAmount converts minor units to decimal string with two fractional digits.
+func FormatAmount(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
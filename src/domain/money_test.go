@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	testCases := []struct {
+		name          string
+		value         string
+		expected      int
+		expectedError error
+	}{
+		{name: "integer", value: "10", expected: 1000},
+		{name: "one fractional digit", value: "10.5", expected: 1050},
+		{name: "two fractional digits", value: "10.29", expected: 1029},
+		{name: "leading zero of cents", value: "0.05", expected: 5},
+		{name: "negative", value: "-1.25", expected: -125},
+		{
+			name:          "three fractional digits",
+			value:         "10.299",
+			expectedError: Invalid("amount has more than two fractional digits"),
+		},
+		{name: "empty fraction", value: "10.", expectedError: Invalid(`invalid amount "10."`)},
+		{name: "exponent", value: "1e3", expectedError: Invalid(`invalid amount "1e3"`)},
+		{name: "empty", value: "", expectedError: Invalid(`invalid amount ""`)},
+		{
+			name:          "overflow",
+			value:         "99999999999999999999",
+			expectedError: Invalid(`invalid amount "99999999999999999999"`),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, err := ParseAmount(tc.value)
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expected, amount)
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	assert.Equal(t, "10.29", FormatAmount(1029))
+	assert.Equal(t, "0.05", FormatAmount(5))
+	assert.Equal(t, "-1.50", FormatAmount(-150))
+	assert.Equal(t, "10.29 BYN", Money{Amount: 1029, Currency: "BYN"}.String())
+}
+
+func TestValidCurrency(t *testing.T) {
+	assert.True(t, ValidCurrency("BYN"))
+	assert.False(t, ValidCurrency("byn"))
+	assert.False(t, ValidCurrency("EURO"))
+	assert.False(t, ValidCurrency(""))
+}
@@ -0,0 +1,71 @@
+package memstorage
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//copySnapshot returns copy of route snapshot or nil if there is no snapshot.
+func copySnapshot(data json.RawMessage) json.RawMessage {
+	if data == nil {
+		return nil
+	}
+	return append(json.RawMessage{}, data...)
+}
+
+//copyEntry returns copy of audit entry which doesn't share snapshots with original.
+func copyEntry(e domain.AuditEntry) domain.AuditEntry {
+	e.Before, e.After = copySnapshot(e.Before), copySnapshot(e.After)
+	return e
+}
+
+//matchEntry checks if audit entry satisfies filters of query.
+func matchEntry(e domain.AuditEntry, q domain.AuditQuery) bool {
+	switch {
+	case q.RouteID != 0 && e.RouteID != q.RouteID:
+		return false
+	case q.Actor != "" && e.Actor != q.Actor:
+		return false
+	case !q.From.IsZero() && e.Time.Before(truncate(q.From.UTC())):
+		return false
+	case !q.To.IsZero() && !e.Time.Before(truncate(q.To.UTC())):
+		return false
+	}
+	return true
+}
+
+//AddAuditEntry adds entry to audit log in memory and returns its id.
+func (m *MemStorage) AddAuditEntry(ctx context.Context, e *domain.AuditEntry) (int, error) {
+	defer m.lock(ctx)()
+
+	entry := copyEntry(*e)
+	entry.ID = len(m.audit) + 1
+	entry.Time = truncate(e.Time.UTC())
+	m.audit = append(m.audit, entry)
+	return entry.ID, nil
+}
+
+//AuditEntries finds entries of audit log by filters in query ordered by time of change.
+func (m *MemStorage) AuditEntries(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	defer m.rlock(ctx)()
+
+	var entries []domain.AuditEntry
+	for _, e := range m.audit {
+		if matchEntry(e, q) {
+			entries = append(entries, copyEntry(e))
+		}
+	}
+	if q.Limit > 0 {
+		if q.Offset >= len(entries) {
+			return nil, nil
+		}
+		end := q.Offset + q.Limit
+		if end > len(entries) {
+			end = len(entries)
+		}
+		entries = entries[q.Offset:end]
+	}
+	return entries, nil
+}
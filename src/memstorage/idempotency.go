@@ -0,0 +1,66 @@
+package memstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//copyRecord returns copy of idempotency record which doesn't share body with original.
+func copyRecord(rec domain.IdempotencyRecord) domain.IdempotencyRecord {
+	rec.Body = append([]byte(nil), rec.Body...)
+	rec.Expires = truncate(rec.Expires)
+	return rec
+}
+
+//AddIdempotencyRecord removes expired records and adds record of request which is handled.
+func (m *MemStorage) AddIdempotencyRecord(ctx context.Context, rec *domain.IdempotencyRecord) error {
+	defer m.lock(ctx)()
+
+	now := time.Now()
+	for key, stored := range m.idempotency {
+		if !stored.Expires.After(now) {
+			delete(m.idempotency, key)
+		}
+	}
+	if _, ok := m.idempotency[rec.Key]; ok {
+		return domain.Conflict("idempotency key is used")
+	}
+	m.idempotency[rec.Key] = copyRecord(*rec)
+	return nil
+}
+
+//IdempotencyRecordByKey gets idempotency record by key.
+func (m *MemStorage) IdempotencyRecordByKey(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	defer m.rlock(ctx)()
+
+	rec, ok := m.idempotency[key]
+	if !ok {
+		return nil, domain.NotFound("no such idempotency key")
+	}
+	rec = copyRecord(rec)
+	return &rec, nil
+}
+
+//CompleteIdempotencyRecord saves response to request of idempotency record.
+func (m *MemStorage) CompleteIdempotencyRecord(ctx context.Context, rec *domain.IdempotencyRecord) error {
+	defer m.lock(ctx)()
+
+	if _, ok := m.idempotency[rec.Key]; !ok {
+		return domain.NotFound("no such idempotency key")
+	}
+	m.idempotency[rec.Key] = copyRecord(*rec)
+	return nil
+}
+
+//DeleteIdempotencyRecord removes idempotency record by key.
+func (m *MemStorage) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	defer m.lock(ctx)()
+
+	if _, ok := m.idempotency[key]; !ok {
+		return domain.NotFound("no such idempotency key")
+	}
+	delete(m.idempotency, key)
+	return nil
+}
@@ -0,0 +1,54 @@
+package memstorage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//IdentityByName gets identity of client by name.
+func (m *MemStorage) IdentityByName(ctx context.Context, name string) (*domain.Identity, error) {
+	defer m.rlock(ctx)()
+
+	roles, ok := m.identities[name]
+	if !ok {
+		return nil, domain.NotFound("no such identity")
+	}
+	return &domain.Identity{Name: name, Roles: append([]string(nil), roles...)}, nil
+}
+
+//GetAllIdentities gets all identities ordered by name.
+func (m *MemStorage) GetAllIdentities(ctx context.Context) ([]domain.Identity, error) {
+	defer m.rlock(ctx)()
+
+	identities := make([]domain.Identity, 0, len(m.identities))
+	for name, roles := range m.identities {
+		identities = append(identities, domain.Identity{Name: name, Roles: append([]string(nil), roles...)})
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		return identities[i].Name < identities[j].Name
+	})
+	return identities, nil
+}
+
+//SaveIdentity replaces roles of client by roles of identity ordered by name of role.
+func (m *MemStorage) SaveIdentity(ctx context.Context, i *domain.Identity) error {
+	defer m.lock(ctx)()
+
+	roles := append([]string(nil), i.Roles...)
+	sort.Strings(roles)
+	m.identities[i.Name] = roles
+	return nil
+}
+
+//DeleteIdentity removes identity of client by name.
+func (m *MemStorage) DeleteIdentity(ctx context.Context, name string) error {
+	defer m.lock(ctx)()
+
+	if _, ok := m.identities[name]; !ok {
+		return domain.NotFound("no such identity")
+	}
+	delete(m.identities, name)
+	return nil
+}
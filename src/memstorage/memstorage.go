@@ -0,0 +1,509 @@
+package memstorage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+const layout = "2006-01-02 15:04:05"
+
+//MemStorage - struct for storing routes in memory.
+//Deleted routes are kept apart from routes until they are restored or archived.
+type MemStorage struct {
+	mu              sync.RWMutex
+	routes          map[int]domain.Route
+	deleted         map[int]domain.Route
+	tickets         map[int]domain.Ticket
+	schedules       map[int]domain.Schedule
+	archive         map[int]domain.Route
+	archivedTickets map[int]domain.Ticket
+	audit           []domain.AuditEntry
+	identities      map[string][]string
+	idempotency     map[string]domain.IdempotencyRecord
+	lastRouteID     int
+	lastTicket      int
+	lastSchedule    int
+}
+
+//NewMemStorage - constructor for MemStorage.
+func NewMemStorage() *MemStorage {
+	return &MemStorage{
+		routes:          make(map[int]domain.Route),
+		deleted:         make(map[int]domain.Route),
+		tickets:         make(map[int]domain.Ticket),
+		schedules:       make(map[int]domain.Schedule),
+		archive:         make(map[int]domain.Route),
+		archivedTickets: make(map[int]domain.Ticket),
+		identities:      make(map[string][]string),
+		idempotency:     make(map[string]domain.IdempotencyRecord),
+	}
+}
+
+//truncate drops time zone and fractional seconds the same way as database does.
+func truncate(t time.Time) time.Time {
+	date, err := time.Parse(layout, t.Format(layout))
+	if err != nil {
+		return t
+	}
+	return date
+}
+
+//copyRoute returns copy of route which doesn't share stops with original.
+func copyRoute(r domain.Route) domain.Route {
+	if r.Stops != nil {
+		r.Stops = append([]domain.Stop(nil), r.Stops...)
+	}
+	return r
+}
+
+//normalize drops precision of route data which isn't kept by database.
+func normalize(r domain.Route) domain.Route {
+	r = copyRoute(r)
+	r.Start = truncate(r.Start)
+	r.Duration = r.Duration.Truncate(time.Minute)
+	for i := range r.Stops {
+		r.Stops[i].Arrival = r.Stops[i].Arrival.Truncate(time.Minute)
+		r.Stops[i].Departure = r.Stops[i].Departure.Truncate(time.Minute)
+	}
+	return r
+}
+
+//sortedRoutes returns copies of routes which satisfy filter ordered by id.
+func (m *MemStorage) sortedRoutes(filter func(domain.Route) bool) []domain.Route {
+	var routes []domain.Route
+	for _, route := range m.routes {
+		if filter(route) {
+			routes = append(routes, copyRoute(route))
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].ID < routes[j].ID
+	})
+	return routes
+}
+
+//RouteByID finds route by id.
+func (m *MemStorage) RouteByID(ctx context.Context, id int) (*domain.Route, error) {
+	defer m.rlock(ctx)()
+
+	route, ok := m.routes[id]
+	if !ok {
+		return nil, domain.NotFound("no such route")
+	}
+	route = copyRoute(route)
+	return &route, nil
+}
+
+//changedRoute finds route by id in routes and checks that it has version.
+func changedRoute(routes map[int]domain.Route, id, version int, message string) (domain.Route, error) {
+	route, ok := routes[id]
+	if !ok {
+		return route, domain.NotFound(message)
+	}
+	if route.Version != version {
+		return route, domain.PreconditionFailed("route was changed")
+	}
+	route.Version++
+	return route, nil
+}
+
+//txKey - key of storage whose transaction is run in context.
+type txKey struct{}
+
+//lock locks m for changes and returns function which unlocks it.
+//Storage isn't locked again if ctx belongs to its transaction which holds the lock.
+func (m *MemStorage) lock(ctx context.Context) func() {
+	if ctx.Value(txKey{}) == m {
+		return func() {}
+	}
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+//rlock locks m for reading and returns function which unlocks it.
+func (m *MemStorage) rlock(ctx context.Context) func() {
+	if ctx.Value(txKey{}) == m {
+		return func() {}
+	}
+	m.mu.RLock()
+	return m.mu.RUnlock
+}
+
+//copyRoutes returns copy of routes map which doesn't share stops with original.
+func copyRoutes(routes map[int]domain.Route) map[int]domain.Route {
+	copied := make(map[int]domain.Route, len(routes))
+	for id, route := range routes {
+		copied[id] = copyRoute(route)
+	}
+	return copied
+}
+
+//copyMap returns shallow copy of map.
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	copied := make(map[K]V, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+//snapshot returns storage with copy of data of m which is restored if transaction fails.
+func (m *MemStorage) snapshot() *MemStorage {
+	return &MemStorage{
+		routes:          copyRoutes(m.routes),
+		deleted:         copyRoutes(m.deleted),
+		tickets:         copyMap(m.tickets),
+		schedules:       copyMap(m.schedules),
+		archive:         copyRoutes(m.archive),
+		archivedTickets: copyMap(m.archivedTickets),
+		audit:           m.audit[:len(m.audit):len(m.audit)],
+		identities:      copyMap(m.identities),
+		idempotency:     copyMap(m.idempotency),
+		lastRouteID:     m.lastRouteID,
+		lastTicket:      m.lastTicket,
+		lastSchedule:    m.lastSchedule,
+	}
+}
+
+//restore replaces data of m with data of snapshot s.
+func (m *MemStorage) restore(s *MemStorage) {
+	m.routes, m.deleted, m.archive = s.routes, s.deleted, s.archive
+	m.tickets, m.archivedTickets = s.tickets, s.archivedTickets
+	m.schedules, m.audit = s.schedules, s.audit
+	m.identities, m.idempotency = s.identities, s.idempotency
+	m.lastRouteID, m.lastTicket, m.lastSchedule = s.lastRouteID, s.lastTicket, s.lastSchedule
+}
+
+//InTransaction calls fn with context in which storage is locked for other callers,
+//data changed by fn is restored if fn fails. Nested transaction continues outer one.
+func (m *MemStorage) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) == m {
+		return fn(ctx)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := m.snapshot()
+	err := fn(context.WithValue(ctx, txKey{}, m))
+	if err != nil {
+		m.restore(saved)
+	}
+	return err
+}
+
+//DeleteRow marks route with version as deleted by id, tickets of the route are kept.
+func (m *MemStorage) DeleteRow(ctx context.Context, id, version int) error {
+	defer m.lock(ctx)()
+
+	route, err := changedRoute(m.routes, id, version, "no such route")
+	if err != nil {
+		return err
+	}
+	delete(m.routes, id)
+	m.deleted[id] = route
+	return nil
+}
+
+//RestoreRoute removes mark of deletion from route with version by id.
+func (m *MemStorage) RestoreRoute(ctx context.Context, id, version int) error {
+	defer m.lock(ctx)()
+
+	route, err := changedRoute(m.deleted, id, version, "no such deleted route")
+	if err != nil {
+		return err
+	}
+	delete(m.deleted, id)
+	m.routes[id] = route
+	return nil
+}
+
+//ArchiveRoutes moves routes which start before time with their tickets to archive
+//and returns number of archived routes.
+func (m *MemStorage) ArchiveRoutes(ctx context.Context, before time.Time) (int, error) {
+	defer m.lock(ctx)()
+
+	before = truncate(before)
+	var archived int
+	for _, routes := range []map[int]domain.Route{m.routes, m.deleted} {
+		for id, route := range routes {
+			if route.Start.Before(before) {
+				delete(routes, id)
+				m.archive[id] = route
+				archived++
+			}
+		}
+	}
+	for id, ticket := range m.tickets {
+		if _, ok := m.archive[ticket.RouteID]; ok {
+			delete(m.tickets, id)
+			m.archivedTickets[id] = ticket
+		}
+	}
+	return archived, nil
+}
+
+//matchPoints checks if route goes from start point to end point of query directly
+//or through its stops.
+func matchPoints(r domain.Route, q domain.RouteQuery) bool {
+	if (q.StartPoint == "" || r.Points.StartPoint == q.StartPoint) &&
+		(q.EndPoint == "" || r.Points.EndPoint == q.EndPoint) {
+		return true
+	}
+
+	from, to := 0, len(r.Stops)-1
+	if q.StartPoint != "" {
+		from = r.StopIndex(q.StartPoint)
+	}
+	if q.EndPoint != "" {
+		to = r.StopIndex(q.EndPoint)
+	}
+	return from >= 0 && to >= 0 && from < to
+}
+
+//matchStart checks if route satisfies departure filters of query.
+func matchStart(r domain.Route, q domain.RouteQuery) bool {
+	if !q.From.IsZero() && r.Start.Before(truncate(q.From)) {
+		return false
+	}
+	if !q.To.IsZero() && !r.Start.Before(truncate(q.To)) {
+		return false
+	}
+	day := domain.TimeOfDay(r.Start)
+	return (q.DepartAfter <= 0 || day >= q.DepartAfter) &&
+		(q.DepartBefore <= 0 || day <= q.DepartBefore)
+}
+
+//matchStatus checks if route has one of statuses of query.
+func matchStatus(r domain.Route, q domain.RouteQuery) bool {
+	if len(q.Statuses) == 0 {
+		return true
+	}
+	for _, status := range q.Statuses {
+		if r.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+//matchQuery checks if route satisfies filters of query.
+func matchQuery(r domain.Route, q domain.RouteQuery) bool {
+	return matchPoints(r, q) && matchStart(r, q) && matchStatus(r, q) &&
+		(q.MinFreeSeats <= 0 || r.FreeSeats >= q.MinFreeSeats) &&
+		(q.MaxCost <= 0 || r.Cost.Amount <= q.MaxCost)
+}
+
+//lessByKey compares routes by sort key.
+func lessByKey(a, b domain.Route, key string, desc bool) bool {
+	var less, equal bool
+	switch key {
+	case domain.SortByStart:
+		less, equal = a.Start.Before(b.Start), a.Start.Equal(b.Start)
+	case domain.SortByCost:
+		less, equal = a.Cost.Amount < b.Cost.Amount, a.Cost.Amount == b.Cost.Amount
+	case domain.SortByFreeSeats:
+		less, equal = a.FreeSeats < b.FreeSeats, a.FreeSeats == b.FreeSeats
+	default:
+		return a.ID < b.ID
+	}
+	if equal {
+		return a.ID < b.ID
+	}
+	return less != desc
+}
+
+//RoutesByQuery finds routes by filters in query and returns requested page of them
+//with total number of matched routes.
+func (m *MemStorage) RoutesByQuery(ctx context.Context, q domain.RouteQuery) ([]domain.Route, int, error) {
+	defer m.rlock(ctx)()
+
+	routes := m.sortedRoutes(func(r domain.Route) bool {
+		return matchQuery(r, q)
+	})
+	sort.SliceStable(routes, func(i, j int) bool {
+		return lessByKey(routes[i], routes[j], q.SortBy, q.Desc)
+	})
+
+	total := len(routes)
+	if q.Limit > 0 {
+		if q.Offset >= total {
+			return nil, total, nil
+		}
+		end := q.Offset + q.Limit
+		if end > total {
+			end = total
+		}
+		routes = routes[q.Offset:end]
+	}
+	return routes, total, nil
+}
+
+//AddRoute adds route to memory, new route is scheduled without delay.
+//Route of schedule isn't added if the schedule already has route with the same start.
+func (m *MemStorage) AddRoute(ctx context.Context, r *domain.Route) (int, error) {
+	defer m.lock(ctx)()
+
+	route := normalize(*r)
+	if route.ScheduleID != 0 {
+		for _, routes := range []map[int]domain.Route{m.routes, m.deleted} {
+			for _, other := range routes {
+				if other.ScheduleID == route.ScheduleID && other.Start.Equal(route.Start) {
+					return 0, domain.Conflict("schedule already has route with such start")
+				}
+			}
+		}
+	}
+	m.lastRouteID++
+	route.ID = m.lastRouteID
+	route.Status, route.Delay, route.Version = domain.StatusScheduled, 0, 1
+	m.routes[route.ID] = route
+	return route.ID, nil
+}
+
+//UpdateRoute replaces data of route with version in memory, link to schedule, status and delay are kept.
+func (m *MemStorage) UpdateRoute(ctx context.Context, r *domain.Route) error {
+	defer m.lock(ctx)()
+
+	old, err := changedRoute(m.routes, r.ID, r.Version, "no such route")
+	if err != nil {
+		return err
+	}
+	route := normalize(*r)
+	route.ScheduleID, route.Status, route.Delay, route.Version = old.ScheduleID, old.Status, old.Delay, old.Version
+	m.routes[r.ID] = route
+	return nil
+}
+
+//SetRouteStatus changes status and delay of the route if it still has version.
+func (m *MemStorage) SetRouteStatus(ctx context.Context, id, version int, status string, delay time.Duration) error {
+	defer m.lock(ctx)()
+
+	route, err := changedRoute(m.routes, id, version, "no such route")
+	if err != nil {
+		return err
+	}
+	route.Status, route.Delay = status, delay.Truncate(time.Minute)
+	m.routes[id] = route
+	return nil
+}
+
+//stopPositions finds positions of stops of the ticket, ok is false if the route has no stops.
+func stopPositions(r domain.Route, t domain.Ticket) (from, to int, ok bool, err error) {
+	if len(r.Stops) == 0 {
+		return 0, 0, false, nil
+	}
+	from, to = r.StopIndex(t.From), r.StopIndex(t.To)
+	if from < 0 || to < 0 {
+		return 0, 0, true, domain.Invalid("no such stop")
+	}
+	if from >= to {
+		return 0, 0, true, domain.Invalid("stops order is invalid")
+	}
+	return from, to, true, nil
+}
+
+//updateSeats changes free seats on segments between stops by delta and sets free seats of the route
+//to number of seats which are free on all segments.
+func updateSeats(r *domain.Route, from, to, delta int) {
+	for i := from; i < to; i++ {
+		r.Stops[i].FreeSeats += delta
+	}
+	segments := r.Stops[:len(r.Stops)-1]
+	r.FreeSeats = segments[0].FreeSeats
+	for _, stop := range segments {
+		if stop.FreeSeats < r.FreeSeats {
+			r.FreeSeats = stop.FreeSeats
+		}
+	}
+}
+
+//BookSeat takes one free seat of the route between stops of the ticket and saves ticket.
+func (m *MemStorage) BookSeat(ctx context.Context, t *domain.Ticket) (int, error) {
+	defer m.lock(ctx)()
+
+	route, ok := m.routes[t.RouteID]
+	if !ok {
+		return 0, domain.NotFound("no such route")
+	}
+	from, to, ok, err := stopPositions(route, *t)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		for _, stop := range route.Stops[from:to] {
+			if stop.FreeSeats <= 0 {
+				return 0, domain.Conflict("no free seats")
+			}
+		}
+		updateSeats(&route, from, to, -1)
+	} else {
+		if route.FreeSeats <= 0 {
+			return 0, domain.Conflict("no free seats")
+		}
+		route.FreeSeats--
+	}
+	route.Version++
+	m.routes[route.ID] = route
+
+	m.lastTicket++
+	ticket := *t
+	ticket.ID = m.lastTicket
+	ticket.Booked = truncate(t.Booked)
+	m.tickets[ticket.ID] = ticket
+	return ticket.ID, nil
+}
+
+//CancelBooking deletes ticket by id and frees its seat.
+func (m *MemStorage) CancelBooking(ctx context.Context, id int) error {
+	defer m.lock(ctx)()
+
+	ticket, ok := m.tickets[id]
+	if !ok {
+		return domain.NotFound("no such ticket")
+	}
+
+	routes := m.routes
+	if _, ok := m.deleted[ticket.RouteID]; ok {
+		routes = m.deleted
+	}
+	route, ok := routes[ticket.RouteID]
+	if !ok {
+		delete(m.tickets, id)
+		return nil
+	}
+	from, to, ok, err := stopPositions(route, ticket)
+	if err != nil {
+		return err
+	}
+	delete(m.tickets, id)
+	switch {
+	case ok:
+		updateSeats(&route, from, to, 1)
+		route.Version++
+	case route.FreeSeats < route.AllSeats:
+		route.FreeSeats++
+		route.Version++
+	}
+	routes[route.ID] = route
+	return nil
+}
+
+//RouteTickets finds tickets booked on the route ordered by id.
+func (m *MemStorage) RouteTickets(ctx context.Context, routeID int) ([]domain.Ticket, error) {
+	defer m.rlock(ctx)()
+
+	var tickets []domain.Ticket
+	for _, ticket := range m.tickets {
+		if ticket.RouteID == routeID {
+			tickets = append(tickets, ticket)
+		}
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].ID < tickets[j].ID
+	})
+	return tickets, nil
+}
@@ -0,0 +1,512 @@
+package memstorage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/accessmanager"
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/JaneKetko/Buses/src/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRoute(t *testing.T) {
+	storage := NewMemStorage()
+
+	route := domain.Route{
+		Points: domain.Points{
+			StartPoint: "Minsk",
+			EndPoint:   "Vitebsk",
+		},
+		Start:     time.Date(2019, 02, 12, 10, 0, 0, 500, time.FixedZone("MSK", 3*60*60)),
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+	}
+
+	id1, err := storage.AddRoute(context.Background(), &route)
+	require.NoError(t, err)
+	id2, err := storage.AddRoute(context.Background(), &route)
+	require.NoError(t, err)
+	assert.NotEqual(t, id1, id2)
+	assert.Equal(t, 0, route.ID)
+
+	rt, err := storage.RouteByID(context.Background(), id1)
+	require.NoError(t, err)
+	assert.Equal(t, id1, rt.ID)
+	assert.Equal(t, time.Date(2019, 02, 12, 10, 0, 0, 0, time.UTC), rt.Start)
+}
+
+func TestRouteByID(t *testing.T) {
+	storage := NewMemStorage()
+
+	_, err := storage.RouteByID(context.Background(), 1)
+	assert.EqualError(t, err, "no such route")
+
+	id, err := storage.AddRoute(context.Background(), &domain.Route{Cost: domain.Money{Amount: 1000, Currency: "BYN"}})
+	require.NoError(t, err)
+
+	rt, err := storage.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	rt.Cost.Amount = 2000
+
+	rt, err = storage.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, rt.Cost.Amount)
+}
+
+func TestAllRoutes(t *testing.T) {
+	storage := NewMemStorage()
+
+	routes, _, err := storage.RoutesByQuery(context.Background(), domain.RouteQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, routes)
+
+	route := domain.Route{
+		Points: domain.Points{
+			StartPoint: "Minsk",
+			EndPoint:   "Vitebsk",
+		},
+		Start:     time.Date(2019, 02, 12, 10, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+	}
+	for i := 0; i < 3; i++ {
+		_, err = storage.AddRoute(context.Background(), &route)
+		require.NoError(t, err)
+	}
+
+	routes, _, err = storage.RoutesByQuery(context.Background(), domain.RouteQuery{})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(routes))
+	for i, rt := range routes {
+		assert.Equal(t, i+1, rt.ID)
+	}
+}
+
+func TestDeleteRoute(t *testing.T) {
+	storage := NewMemStorage()
+
+	id, err := storage.AddRoute(context.Background(), &domain.Route{})
+	require.NoError(t, err)
+	err = storage.DeleteRow(context.Background(), id, 1)
+	require.NoError(t, err)
+
+	_, err = storage.RouteByID(context.Background(), id)
+	assert.EqualError(t, err, "no such route")
+	err = storage.DeleteRow(context.Background(), id, 2)
+	assert.EqualError(t, err, "no such route")
+}
+
+func TestFindRoute(t *testing.T) {
+	storage := NewMemStorage()
+
+	routes := []domain.Route{
+		{
+			Points: domain.Points{
+				StartPoint: "Minsk",
+				EndPoint:   "Vitebsk",
+			},
+			Start: time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			Points: domain.Points{
+				StartPoint: "Minsk",
+				EndPoint:   "Lida",
+			},
+			Start: time.Date(2019, 04, 10, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			Points: domain.Points{
+				StartPoint: "Grodno",
+				EndPoint:   "Vitebsk",
+			},
+			Start: time.Date(2019, 02, 12, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	for i := range routes {
+		_, err := storage.AddRoute(context.Background(), &routes[i])
+		require.NoError(t, err)
+	}
+
+	rts, _, err := storage.RoutesByQuery(context.Background(), domain.RouteQuery{EndPoint: "Vitebsk"})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(rts))
+	assert.Equal(t, 1, rts[0].ID)
+	assert.Equal(t, 3, rts[1].ID)
+
+	rts, _, err = storage.RoutesByQuery(context.Background(), domain.RouteQuery{EndPoint: "Mir"})
+	require.NoError(t, err)
+	assert.Empty(t, rts)
+}
+
+func TestBookSeat(t *testing.T) {
+	storage := NewMemStorage()
+
+	id, err := storage.AddRoute(context.Background(), &domain.Route{FreeSeats: 3, AllSeats: 3})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var tickets []int
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticketID, err := storage.BookSeat(context.Background(), &domain.Ticket{RouteID: id, Passenger: "Ivanov"})
+			if err != nil {
+				assert.EqualError(t, err, "no free seats")
+				return
+			}
+			mu.Lock()
+			tickets = append(tickets, ticketID)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 3, len(tickets))
+
+	rt, err := storage.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rt.FreeSeats)
+
+	err = storage.CancelBooking(context.Background(), tickets[0])
+	require.NoError(t, err)
+	err = storage.CancelBooking(context.Background(), tickets[0])
+	assert.EqualError(t, err, "no such ticket")
+
+	rt, err = storage.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rt.FreeSeats)
+
+	_, err = storage.BookSeat(context.Background(), &domain.Ticket{RouteID: id + 1, Passenger: "Ivanov"})
+	assert.EqualError(t, err, "no such route")
+}
+
+func TestUpdateRoute(t *testing.T) {
+	storage := NewMemStorage()
+
+	id, err := storage.AddRoute(context.Background(), &domain.Route{
+		Points: domain.Points{
+			StartPoint: "Minsk",
+			EndPoint:   "Vitebsk",
+		},
+		Cost: domain.Money{Amount: 1000, Currency: "BYN"},
+	})
+	require.NoError(t, err)
+
+	route := domain.Route{
+		ID: id,
+		Points: domain.Points{
+			StartPoint: "Minsk",
+			EndPoint:   "Lida",
+		},
+		Start:    time.Date(2019, 04, 10, 10, 0, 0, 0, time.UTC),
+		Cost:     domain.Money{Amount: 1500, Currency: "BYN"},
+		Duration: 2*time.Hour + 30*time.Minute,
+		Status:   domain.StatusScheduled,
+		Version:  1,
+	}
+	err = storage.UpdateRoute(context.Background(), &route)
+	require.NoError(t, err)
+
+	rt, err := storage.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	route.Version = 2
+	assert.Equal(t, route, *rt)
+
+	err = storage.UpdateRoute(context.Background(), &domain.Route{ID: id, Version: 1})
+	assert.EqualError(t, err, "route was changed")
+
+	route.ID = id + 1
+	err = storage.UpdateRoute(context.Background(), &route)
+	assert.EqualError(t, err, "no such route")
+}
+
+func TestUpdateRouteKeepsSoldSeats(t *testing.T) {
+	routeman := routemanager.NewRouteManager(NewMemStorage())
+	route := domain.Route{
+		Points: domain.Points{
+			StartPoint: "Minsk",
+			EndPoint:   "Vitebsk",
+		},
+		Start:     time.Date(time.Now().Year()+1, 04, 10, 10, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1500, Currency: "BYN"},
+		FreeSeats: 2,
+		AllSeats:  2,
+	}
+	require.NoError(t, routeman.CreateNewRoute(context.Background(), &route))
+	for i := 0; i < 2; i++ {
+		err := routeman.BookSeat(context.Background(), &domain.Ticket{RouteID: route.ID, Passenger: "Ivanov"})
+		require.NoError(t, err)
+	}
+
+	stored, err := routeman.GetRouteByID(context.Background(), route.ID)
+	require.NoError(t, err)
+	update := *stored
+	update.FreeSeats = 50
+	err = routeman.UpdateRoute(context.Background(), &update)
+	require.NoError(t, err)
+	assert.Equal(t, 0, update.FreeSeats)
+
+	rt, err := routeman.GetRouteByID(context.Background(), route.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rt.FreeSeats)
+	err = routeman.BookSeat(context.Background(), &domain.Ticket{RouteID: route.ID, Passenger: "Petrov"})
+	assert.EqualError(t, err, "no free seats")
+
+	update = *rt
+	update.AllSeats = 1
+	err = routeman.UpdateRoute(context.Background(), &update)
+	assert.Equal(t, domain.Conflict("all seats are fewer than sold seats"), err)
+
+	update.AllSeats = 5
+	err = routeman.UpdateRoute(context.Background(), &update)
+	require.NoError(t, err)
+	assert.Equal(t, 3, update.FreeSeats)
+}
+
+func TestRoutesByQuery(t *testing.T) {
+	storage := NewMemStorage()
+
+	routes := []domain.Route{
+		{
+			Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+			Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1500, Currency: "BYN"},
+			FreeSeats: 2,
+		},
+		{
+			Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Lida"},
+			Start:     time.Date(2019, 04, 10, 7, 30, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+		},
+		{
+			Points:    domain.Points{StartPoint: "Grodno", EndPoint: "Vitebsk"},
+			Start:     time.Date(2019, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 5,
+		},
+		{
+			Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+			Start:     time.Date(2019, 05, 1, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 2000, Currency: "BYN"},
+			FreeSeats: 0,
+		},
+	}
+	for i := range routes {
+		_, err := storage.AddRoute(context.Background(), &routes[i])
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		name          string
+		query         domain.RouteQuery
+		expectedIDs   []int
+		expectedTotal int
+	}{
+		{
+			name:          "all routes",
+			query:         domain.RouteQuery{},
+			expectedIDs:   []int{1, 2, 3, 4},
+			expectedTotal: 4,
+		},
+		{
+			name:          "by points",
+			query:         domain.RouteQuery{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+			expectedIDs:   []int{1, 4},
+			expectedTotal: 2,
+		},
+		{
+			name: "by date range",
+			query: domain.RouteQuery{
+				From: time.Date(2019, 04, 12, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2019, 05, 1, 10, 0, 0, 0, time.UTC),
+			},
+			expectedIDs:   []int{1, 3},
+			expectedTotal: 2,
+		},
+		{
+			name:          "by time of day",
+			query:         domain.RouteQuery{DepartAfter: 7 * time.Hour, DepartBefore: 8 * time.Hour},
+			expectedIDs:   []int{2},
+			expectedTotal: 1,
+		},
+		{
+			name:          "by start point and earliest time",
+			query:         domain.RouteQuery{StartPoint: "Minsk", DepartAfter: 10 * time.Hour},
+			expectedIDs:   []int{1, 4},
+			expectedTotal: 2,
+		},
+		{
+			name:          "by seats and cost",
+			query:         domain.RouteQuery{MinFreeSeats: 2, MaxCost: 1000},
+			expectedIDs:   []int{2, 3},
+			expectedTotal: 2,
+		},
+		{
+			name:          "sort by cost desc",
+			query:         domain.RouteQuery{SortBy: domain.SortByCost, Desc: true},
+			expectedIDs:   []int{4, 1, 2, 3},
+			expectedTotal: 4,
+		},
+		{
+			name:          "sort by start with page",
+			query:         domain.RouteQuery{SortBy: domain.SortByStart, Limit: 2, Offset: 1},
+			expectedIDs:   []int{3, 1},
+			expectedTotal: 4,
+		},
+		{
+			name:          "page out of range",
+			query:         domain.RouteQuery{SortBy: domain.SortByFreeSeats, Limit: 2, Offset: 4},
+			expectedIDs:   nil,
+			expectedTotal: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rts, total, err := storage.RoutesByQuery(context.Background(), tc.query)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedTotal, total)
+			var ids []int
+			for _, rt := range rts {
+				ids = append(ids, rt.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
+
+func TestStops(t *testing.T) {
+	storage := NewMemStorage()
+
+	route := domain.Route{
+		Points: domain.Points{
+			StartPoint: "Brest",
+			EndPoint:   "Vitebsk",
+		},
+		Start:     time.Date(2019, 04, 12, 8, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 3000, Currency: "BYN"},
+		FreeSeats: 1,
+		AllSeats:  1,
+		Duration:  8 * time.Hour,
+		Stops: []domain.Stop{
+			{Point: "Brest", FreeSeats: 1},
+			{Point: "Minsk", Arrival: 4 * time.Hour, Departure: 4*time.Hour + 20*time.Minute, Fare: 2000,
+				FreeSeats: 1},
+			{Point: "Vitebsk", Arrival: 8 * time.Hour, Departure: 8 * time.Hour, Fare: 1000, FreeSeats: 1},
+		},
+	}
+	id, err := storage.AddRoute(context.Background(), &route)
+	require.NoError(t, err)
+
+	rt, err := storage.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, route.Stops, rt.Stops)
+	rt.Stops[0].Point = "Pinsk"
+
+	rts, _, err := storage.RoutesByQuery(context.Background(), domain.RouteQuery{StartPoint: "Minsk", EndPoint: "Vitebsk"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(rts))
+	rts, _, err = storage.RoutesByQuery(context.Background(), domain.RouteQuery{StartPoint: "Brest", EndPoint: "Minsk"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(rts))
+	rts, _, err = storage.RoutesByQuery(context.Background(), domain.RouteQuery{StartPoint: "Vitebsk"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(rts))
+	rts, _, err = storage.RoutesByQuery(context.Background(), domain.RouteQuery{StartPoint: "Minsk", EndPoint: "Brest"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(rts))
+
+	first, err := storage.BookSeat(context.Background(), &domain.Ticket{RouteID: id, Passenger: "Ivanov", From: "Brest", To: "Minsk"})
+	require.NoError(t, err)
+	_, err = storage.BookSeat(context.Background(), &domain.Ticket{RouteID: id, Passenger: "Petrov", From: "Minsk", To: "Vitebsk"})
+	require.NoError(t, err)
+	_, err = storage.BookSeat(context.Background(), &domain.Ticket{RouteID: id, Passenger: "Sidorov", From: "Brest", To: "Vitebsk"})
+	assert.EqualError(t, err, "no free seats")
+	_, err = storage.BookSeat(context.Background(), &domain.Ticket{RouteID: id, Passenger: "Sidorov", From: "Brest", To: "Orsha"})
+	assert.EqualError(t, err, "no such stop")
+
+	rt, err = storage.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rt.FreeSeats)
+
+	err = storage.CancelBooking(context.Background(), first)
+	require.NoError(t, err)
+	rt, err = storage.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rt.FreeSeats)
+	assert.Equal(t, 1, rt.Stops[0].FreeSeats)
+	assert.Equal(t, 0, rt.Stops[1].FreeSeats)
+}
+
+func TestSchedules(t *testing.T) {
+	storage := NewMemStorage()
+
+	schedule := domain.Schedule{
+		Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Grodno"},
+		Departure: 9 * time.Hour,
+		Weekdays:  []time.Weekday{time.Monday},
+		ValidFrom: time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:   time.Date(2019, 5, 31, 0, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1500, Currency: "BYN"},
+		AllSeats:  40,
+	}
+	id, err := storage.AddSchedule(context.Background(), &schedule)
+	require.NoError(t, err)
+
+	s, err := storage.ScheduleByID(context.Background(), id)
+	require.NoError(t, err)
+	s.Weekdays[0] = time.Friday
+	s.Cost.Amount = 2000
+	require.NoError(t, storage.UpdateSchedule(context.Background(), s))
+
+	schedules, err := storage.GetAllSchedules(context.Background())
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	assert.Equal(t, []time.Weekday{time.Friday}, schedules[0].Weekdays)
+	assert.Equal(t, []time.Weekday{time.Monday}, schedule.Weekdays)
+
+	trip := s.Trip(time.Date(2019, 5, 10, 0, 0, 0, 0, time.UTC))
+	_, err = storage.AddRoute(context.Background(), &trip)
+	require.NoError(t, err)
+	_, err = storage.AddRoute(context.Background(), &domain.Route{Start: trip.Start})
+	require.NoError(t, err)
+
+	starts, err := storage.ScheduledStarts(context.Background(), id, time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{time.Date(2019, 5, 10, 9, 0, 0, 0, time.UTC)}, starts)
+
+	require.NoError(t, storage.DeleteSchedule(context.Background(), id))
+	_, err = storage.ScheduleByID(context.Background(), id)
+	assert.EqualError(t, err, "no such schedule")
+	assert.EqualError(t, storage.DeleteSchedule(context.Background(), id), "no such schedule")
+	assert.EqualError(t, storage.UpdateSchedule(context.Background(), s), "no such schedule")
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) routemanager.RouteStorage {
+		return NewMemStorage()
+	})
+}
+
+func TestIdentityConformance(t *testing.T) {
+	storagetest.RunIdentities(t, func(t *testing.T) accessmanager.IdentityStorage {
+		return NewMemStorage()
+	})
+}
+
+func TestIdempotencyConformance(t *testing.T) {
+	storagetest.RunIdempotency(t, func(t *testing.T) routemanager.IdempotencyStorage {
+		return NewMemStorage()
+	})
+}
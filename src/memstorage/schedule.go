@@ -0,0 +1,112 @@
+package memstorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//copySchedule returns copy of schedule which doesn't share slices with original.
+func copySchedule(s domain.Schedule) domain.Schedule {
+	s.Weekdays = append([]time.Weekday(nil), s.Weekdays...)
+	s.Exceptions = append([]time.Time(nil), s.Exceptions...)
+	return s
+}
+
+//normalizeSchedule returns copy of schedule with exceptions ordered by day the same way as database does.
+func normalizeSchedule(s domain.Schedule) domain.Schedule {
+	s = copySchedule(s)
+	sort.Slice(s.Exceptions, func(i, j int) bool {
+		return s.Exceptions[i].Before(s.Exceptions[j])
+	})
+	return s
+}
+
+//AddSchedule adds schedule to memory.
+func (m *MemStorage) AddSchedule(ctx context.Context, s *domain.Schedule) (int, error) {
+	defer m.lock(ctx)()
+
+	m.lastSchedule++
+	schedule := normalizeSchedule(*s)
+	schedule.ID = m.lastSchedule
+	m.schedules[schedule.ID] = schedule
+	return schedule.ID, nil
+}
+
+//ScheduleByID finds schedule by id.
+func (m *MemStorage) ScheduleByID(ctx context.Context, id int) (*domain.Schedule, error) {
+	defer m.rlock(ctx)()
+
+	schedule, ok := m.schedules[id]
+	if !ok {
+		return nil, domain.NotFound("no such schedule")
+	}
+	schedule = copySchedule(schedule)
+	return &schedule, nil
+}
+
+//GetAllSchedules gets all schedules ordered by id.
+func (m *MemStorage) GetAllSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	defer m.rlock(ctx)()
+
+	var schedules []domain.Schedule
+	for _, schedule := range m.schedules {
+		schedules = append(schedules, copySchedule(schedule))
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules, nil
+}
+
+//UpdateSchedule replaces schedule data in memory.
+func (m *MemStorage) UpdateSchedule(ctx context.Context, s *domain.Schedule) error {
+	defer m.lock(ctx)()
+
+	if _, ok := m.schedules[s.ID]; !ok {
+		return domain.NotFound("no such schedule")
+	}
+	m.schedules[s.ID] = normalizeSchedule(*s)
+	return nil
+}
+
+//DeleteSchedule deletes schedule by id, generated routes are kept without link to the schedule.
+func (m *MemStorage) DeleteSchedule(ctx context.Context, id int) error {
+	defer m.lock(ctx)()
+
+	if _, ok := m.schedules[id]; !ok {
+		return domain.NotFound("no such schedule")
+	}
+	delete(m.schedules, id)
+	for _, routes := range []map[int]domain.Route{m.routes, m.deleted} {
+		for routeID, route := range routes {
+			if route.ScheduleID == id {
+				route.ScheduleID = 0
+				routes[routeID] = route
+			}
+		}
+	}
+	return nil
+}
+
+//ScheduledStarts finds start times of routes generated by schedule which start in period
+//ordered by time. Deleted routes are included, so they aren't generated again.
+func (m *MemStorage) ScheduledStarts(ctx context.Context, scheduleID int, from, to time.Time) ([]time.Time, error) {
+	defer m.rlock(ctx)()
+
+	var starts []time.Time
+	for _, routes := range []map[int]domain.Route{m.routes, m.deleted} {
+		for _, route := range routes {
+			if route.ScheduleID == scheduleID && !route.Start.Before(truncate(from)) &&
+				route.Start.Before(truncate(to)) {
+				starts = append(starts, route.Start)
+			}
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+	return starts, nil
+}
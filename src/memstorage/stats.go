@@ -0,0 +1,25 @@
+package memstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//SeatStats counts active routes which aren't deleted and start at or after time and their seats.
+func (m *MemStorage) SeatStats(ctx context.Context, from time.Time) (domain.SeatStats, error) {
+	defer m.rlock(ctx)()
+
+	q := domain.RouteQuery{From: from, Statuses: domain.ActiveStatuses()}
+	var stats domain.SeatStats
+	for _, route := range m.routes {
+		if !matchQuery(route, q) {
+			continue
+		}
+		stats.Routes++
+		stats.FreeSeats += route.FreeSeats
+		stats.AllSeats += route.AllSeats
+	}
+	return stats, nil
+}
@@ -0,0 +1,262 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// files - SQL scripts of migrations, each dialect has its own directory.
+// Name of script is <version>_<name>.up.sql or <version>_<name>.down.sql.
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS //nolint:gochecknoglobals
+
+//fileName - pattern of name of migration script.
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`) //nolint:gochecknoglobals
+
+//statementEnd - pattern of semicolon which ends statement in SQL script.
+var statementEnd = regexp.MustCompile(`;\s*(\n|$)`) //nolint:gochecknoglobals
+
+//Migration - struct for describing one version of database schema.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+//Load reads embedded migrations of SQL dialect ordered by version.
+func Load(dialect string) ([]Migration, error) {
+	entries, err := files.ReadDir(dialect)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %q", dialect)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid name of migration %q", entry.Name())
+		}
+		script, err := files.ReadFile(path.Join(dialect, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrations %q and %q have the same version", m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(script)
+		} else {
+			m.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have up and down scripts", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+//statements splits SQL script into statements separated by semicolons at the end of line.
+func statements(script string) []string {
+	var stmts []string
+	for _, stmt := range statementEnd.Split(script, -1) {
+		stmt = strings.TrimSpace(stmt)
+		if stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+//Migrator - struct for applying migrations to database.
+//Applied versions are stored in schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	//numbered is true if dialect uses $1, $2, ... placeholders instead of ?.
+	numbered bool
+}
+
+//NewMigrator - constructor for Migrator with migrations of SQL dialect.
+func NewMigrator(db *sql.DB, dialect string) (*Migrator, error) {
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, numbered: dialect == "postgres"}, nil
+}
+
+//placeholder returns placeholder of n-th argument of query.
+func (m *Migrator) placeholder(n int) string {
+	if m.numbered {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+//init creates table for tracking of applied migrations if it doesn't exist.
+func (m *Migrator) init(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL)`)
+	return err
+}
+
+//trackApplied returns query which records migration as applied.
+func (m *Migrator) trackApplied() string {
+	return fmt.Sprintf("INSERT INTO schema_migrations (version, name, applied_at) VALUES( %s, %s, %s )",
+		m.placeholder(1), m.placeholder(2), m.placeholder(3))
+}
+
+//Version returns version of the last applied migration, zero is returned for empty database.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	err := m.init(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	err = m.db.QueryRowContext(ctx, "SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1").
+		Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+//run executes script of migration and changes tracking table in one transaction.
+//Note that MySQL commits DDL statements implicitly, so failed migration can be applied partially.
+func (m *Migrator) run(ctx context.Context, script, track string, args ...interface{}) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, stmt := range statements(script) {
+		_, err = tx.ExecContext(ctx, stmt)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, track, args...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//Up applies all migrations which are newer than current version and returns number of applied migrations.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	current, err := m.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var applied int
+	for _, migration := range m.migrations {
+		if migration.Version <= current {
+			continue
+		}
+		err = m.run(ctx, migration.Up, m.trackApplied(), migration.Version, migration.Name,
+			time.Now().UTC().Format("2006-01-02 15:04:05"))
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+//Down reverts given number of the last applied migrations and returns number of reverted migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	current, err := m.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var reverted int
+	for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current {
+			continue
+		}
+		err = m.run(ctx, migration.Down, "DELETE FROM schema_migrations WHERE version="+m.placeholder(1),
+			migration.Version)
+		if err != nil {
+			return reverted, fmt.Errorf("migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+//Force sets version of database without running scripts of migrations: migrations up to version are marked
+//as applied and newer ones as not applied. It is used for adopting database whose schema was created
+//before migrations were tracked or for recovering after failed migration which was fixed by hand.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	var last int
+	if len(m.migrations) > 0 {
+		last = m.migrations[len(m.migrations)-1].Version
+	}
+	if version < 0 || version > last {
+		return fmt.Errorf("unknown version %d", version)
+	}
+	err := m.init(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+	applied := time.Now().UTC().Format("2006-01-02 15:04:05")
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			break
+		}
+		_, err = tx.ExecContext(ctx, m.trackApplied(), migration.Version, migration.Name, applied)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
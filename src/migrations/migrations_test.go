@@ -0,0 +1,123 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestLoad(t *testing.T) {
+	mysql, err := Load("mysql")
+	require.NoError(t, err)
+	require.NotEmpty(t, mysql)
+
+	for i, m := range mysql {
+		assert.Equal(t, i+1, m.Version)
+		assert.NotEmpty(t, m.Name)
+		assert.NotEmpty(t, statements(m.Up))
+		assert.NotEmpty(t, statements(m.Down))
+	}
+
+	for _, dialect := range []string{"postgres", "sqlite"} {
+		other, err := Load(dialect)
+		require.NoError(t, err)
+		require.Equal(t, len(mysql), len(other), dialect)
+		for i := range other {
+			assert.Equal(t, mysql[i].Version, other[i].Version, dialect)
+			assert.Equal(t, mysql[i].Name, other[i].Name, dialect)
+		}
+	}
+
+	_, err = Load("oracle")
+	assert.EqualError(t, err, `no migrations for "oracle"`)
+}
+
+func TestPlaceholder(t *testing.T) {
+	mysql := Migrator{}
+	assert.Equal(t, "?", mysql.placeholder(2))
+	postgres := Migrator{numbered: true}
+	assert.Equal(t, "$2", postgres.placeholder(2))
+}
+
+func TestStatements(t *testing.T) {
+	testCases := []struct {
+		name     string
+		script   string
+		expected []string
+	}{
+		{
+			name:     "one statement",
+			script:   "DROP TABLE route;\n",
+			expected: []string{"DROP TABLE route"},
+		},
+		{
+			name:     "statement without semicolon",
+			script:   "DROP TABLE route",
+			expected: []string{"DROP TABLE route"},
+		},
+		{
+			name: "several statements",
+			script: `CREATE TABLE stop (
+	point VARCHAR(100) NOT NULL DEFAULT ';'
+);
+
+ALTER TABLE ticket
+	DROP COLUMN cost;  
+`,
+			expected: []string{"CREATE TABLE stop (\n\tpoint VARCHAR(100) NOT NULL DEFAULT ';'\n)",
+				"ALTER TABLE ticket\n\tDROP COLUMN cost"},
+		},
+		{
+			name:     "empty script",
+			script:   "\n\n",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, statements(tc.script))
+		})
+	}
+}
+
+func TestForce(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "busstation.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	migrator, err := NewMigrator(db, "sqlite")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	//schema of the first version was created before migrations were tracked
+	for _, stmt := range statements(migrator.migrations[0].Up) {
+		_, err = db.ExecContext(ctx, stmt)
+		require.NoError(t, err)
+	}
+	_, err = migrator.Up(ctx)
+	require.Error(t, err)
+
+	require.NoError(t, migrator.Force(ctx, 1))
+	version, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrator.migrations)-1, applied)
+
+	require.NoError(t, migrator.Force(ctx, 2))
+	version, err = migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	assert.EqualError(t, migrator.Force(ctx, len(migrator.migrations)+1),
+		fmt.Sprintf("unknown version %d", len(migrator.migrations)+1))
+	assert.EqualError(t, migrator.Force(ctx, -1), "unknown version -1")
+}
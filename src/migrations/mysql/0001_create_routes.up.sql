@@ -0,0 +1,21 @@
+CREATE TABLE points (
+	id_points INT NOT NULL AUTO_INCREMENT,
+	startpoint VARCHAR(100) NOT NULL,
+	endpoint VARCHAR(100) NOT NULL,
+	PRIMARY KEY (id_points),
+	UNIQUE KEY points_startpoint_endpoint (startpoint, endpoint)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE route (
+	id_route INT NOT NULL AUTO_INCREMENT,
+	id_points INT NOT NULL,
+	starttime DATETIME NOT NULL,
+	cost INT NOT NULL,
+	currency CHAR(3) NOT NULL DEFAULT 'BYN',
+	freeseats INT NOT NULL,
+	allseats INT NOT NULL,
+	duration INT NOT NULL DEFAULT 0,
+	PRIMARY KEY (id_route),
+	KEY route_points (id_points),
+	KEY route_starttime (starttime)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,8 @@
+CREATE TABLE ticket (
+	id_ticket INT NOT NULL AUTO_INCREMENT,
+	id_route INT NOT NULL,
+	passenger VARCHAR(100) NOT NULL,
+	booked DATETIME NOT NULL,
+	PRIMARY KEY (id_ticket),
+	CONSTRAINT ticket_route FOREIGN KEY (id_route) REFERENCES route (id_route) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,18 @@
+CREATE TABLE stop (
+	id_route INT NOT NULL,
+	position INT NOT NULL,
+	point VARCHAR(100) NOT NULL,
+	arrival INT NOT NULL,
+	departure INT NOT NULL,
+	fare INT NOT NULL,
+	freeseats INT NOT NULL,
+	PRIMARY KEY (id_route, position),
+	KEY stop_point (point),
+	CONSTRAINT stop_route FOREIGN KEY (id_route) REFERENCES route (id_route) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE ticket
+	ADD COLUMN fromstop VARCHAR(100) NOT NULL DEFAULT '' AFTER passenger,
+	ADD COLUMN tostop VARCHAR(100) NOT NULL DEFAULT '' AFTER fromstop,
+	ADD COLUMN cost INT NOT NULL DEFAULT 0 AFTER tostop,
+	ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'BYN' AFTER cost;
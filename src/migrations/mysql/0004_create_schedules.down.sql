@@ -0,0 +1,7 @@
+ALTER TABLE route
+	DROP FOREIGN KEY route_schedule,
+	DROP KEY route_schedule_starttime,
+	DROP COLUMN id_schedule;
+
+DROP TABLE schedule_exception;
+DROP TABLE schedule;
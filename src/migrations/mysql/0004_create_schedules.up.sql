@@ -0,0 +1,26 @@
+CREATE TABLE schedule (
+	id_schedule INT NOT NULL AUTO_INCREMENT,
+	id_points INT NOT NULL,
+	departure INT NOT NULL,
+	weekdays INT NOT NULL,
+	validfrom DATE NOT NULL,
+	validto DATE NOT NULL,
+	cost INT NOT NULL,
+	currency CHAR(3) NOT NULL DEFAULT 'BYN',
+	allseats INT NOT NULL,
+	duration INT NOT NULL DEFAULT 0,
+	PRIMARY KEY (id_schedule),
+	KEY schedule_points (id_points)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE schedule_exception (
+	id_schedule INT NOT NULL,
+	day DATE NOT NULL,
+	PRIMARY KEY (id_schedule, day),
+	CONSTRAINT exception_schedule FOREIGN KEY (id_schedule) REFERENCES schedule (id_schedule) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE route
+	ADD COLUMN id_schedule INT NULL,
+	ADD KEY route_schedule_starttime (id_schedule, starttime),
+	ADD CONSTRAINT route_schedule FOREIGN KEY (id_schedule) REFERENCES schedule (id_schedule) ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE route
+	DROP KEY route_status_starttime,
+	DROP COLUMN delay_minutes,
+	DROP COLUMN status;
@@ -0,0 +1,4 @@
+ALTER TABLE route
+	ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'scheduled',
+	ADD COLUMN delay_minutes INT NOT NULL DEFAULT 0,
+	ADD KEY route_status_starttime (status, starttime);
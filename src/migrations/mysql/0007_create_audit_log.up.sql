@@ -0,0 +1,12 @@
+CREATE TABLE audit_log (
+	id_audit INT NOT NULL AUTO_INCREMENT,
+	id_route INT NOT NULL,
+	action VARCHAR(16) NOT NULL,
+	actor VARCHAR(100) NOT NULL,
+	changed DATETIME NOT NULL,
+	snapshot_before TEXT NULL,
+	snapshot_after TEXT NULL,
+	PRIMARY KEY (id_audit),
+	KEY audit_log_route (id_route, id_audit),
+	KEY audit_log_changed (changed)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
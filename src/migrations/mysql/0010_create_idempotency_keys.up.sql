@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_key (
+	idem_key CHAR(64) NOT NULL,
+	request_hash CHAR(64) NOT NULL,
+	status INT NOT NULL DEFAULT 0,
+	content_type VARCHAR(100) NOT NULL DEFAULT '',
+	etag VARCHAR(100) NOT NULL DEFAULT '',
+	body MEDIUMTEXT NULL,
+	expires DATETIME NOT NULL,
+	PRIMARY KEY (idem_key),
+	KEY idempotency_key_expires (expires)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+ALTER TABLE route ADD KEY route_schedule_starttime (id_schedule, starttime);
+
+ALTER TABLE route DROP KEY route_schedule_start_unique;
@@ -0,0 +1,20 @@
+CREATE TABLE points (
+	id_points SERIAL PRIMARY KEY,
+	startpoint VARCHAR(100) NOT NULL,
+	endpoint VARCHAR(100) NOT NULL,
+	CONSTRAINT points_startpoint_endpoint UNIQUE (startpoint, endpoint)
+);
+
+CREATE TABLE route (
+	id_route SERIAL PRIMARY KEY,
+	id_points INT NOT NULL,
+	starttime TIMESTAMPTZ NOT NULL,
+	cost INT NOT NULL,
+	currency CHAR(3) NOT NULL DEFAULT 'BYN',
+	freeseats INT NOT NULL,
+	allseats INT NOT NULL,
+	duration INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX route_points ON route (id_points);
+CREATE INDEX route_starttime ON route (starttime);
@@ -0,0 +1,7 @@
+CREATE TABLE ticket (
+	id_ticket SERIAL PRIMARY KEY,
+	id_route INT NOT NULL,
+	passenger VARCHAR(100) NOT NULL,
+	booked TIMESTAMPTZ NOT NULL,
+	CONSTRAINT ticket_route FOREIGN KEY (id_route) REFERENCES route (id_route) ON DELETE CASCADE
+);
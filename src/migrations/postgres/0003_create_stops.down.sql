@@ -0,0 +1,7 @@
+ALTER TABLE ticket
+	DROP COLUMN currency,
+	DROP COLUMN cost,
+	DROP COLUMN tostop,
+	DROP COLUMN fromstop;
+
+DROP TABLE stop;
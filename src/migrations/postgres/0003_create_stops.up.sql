@@ -0,0 +1,19 @@
+CREATE TABLE stop (
+	id_route INT NOT NULL,
+	position INT NOT NULL,
+	point VARCHAR(100) NOT NULL,
+	arrival INT NOT NULL,
+	departure INT NOT NULL,
+	fare INT NOT NULL,
+	freeseats INT NOT NULL,
+	PRIMARY KEY (id_route, position),
+	CONSTRAINT stop_route FOREIGN KEY (id_route) REFERENCES route (id_route) ON DELETE CASCADE
+);
+
+CREATE INDEX stop_point ON stop (point);
+
+ALTER TABLE ticket
+	ADD COLUMN fromstop VARCHAR(100) NOT NULL DEFAULT '',
+	ADD COLUMN tostop VARCHAR(100) NOT NULL DEFAULT '',
+	ADD COLUMN cost INT NOT NULL DEFAULT 0,
+	ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'BYN';
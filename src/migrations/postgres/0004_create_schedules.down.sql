@@ -0,0 +1,4 @@
+ALTER TABLE route DROP COLUMN id_schedule;
+
+DROP TABLE schedule_exception;
+DROP TABLE schedule;
@@ -0,0 +1,27 @@
+CREATE TABLE schedule (
+	id_schedule SERIAL PRIMARY KEY,
+	id_points INT NOT NULL,
+	departure INT NOT NULL,
+	weekdays INT NOT NULL,
+	validfrom DATE NOT NULL,
+	validto DATE NOT NULL,
+	cost INT NOT NULL,
+	currency CHAR(3) NOT NULL DEFAULT 'BYN',
+	allseats INT NOT NULL,
+	duration INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX schedule_points ON schedule (id_points);
+
+CREATE TABLE schedule_exception (
+	id_schedule INT NOT NULL,
+	day DATE NOT NULL,
+	PRIMARY KEY (id_schedule, day),
+	CONSTRAINT exception_schedule FOREIGN KEY (id_schedule) REFERENCES schedule (id_schedule) ON DELETE CASCADE
+);
+
+ALTER TABLE route
+	ADD COLUMN id_schedule INT NULL,
+	ADD CONSTRAINT route_schedule FOREIGN KEY (id_schedule) REFERENCES schedule (id_schedule) ON DELETE SET NULL;
+
+CREATE INDEX route_schedule_starttime ON route (id_schedule, starttime);
@@ -0,0 +1,5 @@
+DROP INDEX route_status_starttime;
+
+ALTER TABLE route
+	DROP COLUMN delay_minutes,
+	DROP COLUMN status;
@@ -0,0 +1,5 @@
+ALTER TABLE route
+	ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'scheduled',
+	ADD COLUMN delay_minutes INT NOT NULL DEFAULT 0;
+
+CREATE INDEX route_status_starttime ON route (status, starttime);
@@ -0,0 +1,42 @@
+ALTER TABLE route ADD COLUMN deleted_at TIMESTAMPTZ NULL;
+
+CREATE TABLE route_archive (
+	id_route INT PRIMARY KEY,
+	id_points INT NOT NULL,
+	starttime TIMESTAMPTZ NOT NULL,
+	cost INT NOT NULL,
+	currency CHAR(3) NOT NULL,
+	freeseats INT NOT NULL,
+	allseats INT NOT NULL,
+	duration INT NOT NULL,
+	id_schedule INT NULL,
+	status VARCHAR(16) NOT NULL,
+	delay_minutes INT NOT NULL,
+	deleted_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX route_archive_starttime ON route_archive (starttime);
+
+CREATE TABLE stop_archive (
+	id_route INT NOT NULL,
+	position INT NOT NULL,
+	point VARCHAR(100) NOT NULL,
+	arrival INT NOT NULL,
+	departure INT NOT NULL,
+	fare INT NOT NULL,
+	freeseats INT NOT NULL,
+	PRIMARY KEY (id_route, position)
+);
+
+CREATE TABLE ticket_archive (
+	id_ticket INT PRIMARY KEY,
+	id_route INT NOT NULL,
+	passenger VARCHAR(100) NOT NULL,
+	fromstop VARCHAR(100) NOT NULL,
+	tostop VARCHAR(100) NOT NULL,
+	cost INT NOT NULL,
+	currency CHAR(3) NOT NULL,
+	booked TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX ticket_archive_route ON ticket_archive (id_route);
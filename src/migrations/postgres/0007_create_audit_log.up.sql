@@ -0,0 +1,12 @@
+CREATE TABLE audit_log (
+	id_audit SERIAL PRIMARY KEY,
+	id_route INT NOT NULL,
+	action VARCHAR(16) NOT NULL,
+	actor VARCHAR(100) NOT NULL,
+	changed TIMESTAMPTZ NOT NULL,
+	snapshot_before TEXT NULL,
+	snapshot_after TEXT NULL
+);
+
+CREATE INDEX audit_log_route ON audit_log (id_route, id_audit);
+CREATE INDEX audit_log_changed ON audit_log (changed);
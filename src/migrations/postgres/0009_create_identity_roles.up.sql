@@ -0,0 +1,5 @@
+CREATE TABLE identity_role (
+	name VARCHAR(100) NOT NULL,
+	role VARCHAR(16) NOT NULL,
+	PRIMARY KEY (name, role)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_key (
+	idem_key CHAR(64) PRIMARY KEY,
+	request_hash CHAR(64) NOT NULL,
+	status INT NOT NULL DEFAULT 0,
+	content_type VARCHAR(100) NOT NULL DEFAULT '',
+	etag VARCHAR(100) NOT NULL DEFAULT '',
+	body TEXT NULL,
+	expires TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idempotency_key_expires ON idempotency_key (expires);
@@ -0,0 +1,2 @@
+DROP TABLE route;
+DROP TABLE points;
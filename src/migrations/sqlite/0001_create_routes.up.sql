@@ -0,0 +1,20 @@
+CREATE TABLE points (
+	id_points INTEGER PRIMARY KEY AUTOINCREMENT,
+	startpoint TEXT NOT NULL,
+	endpoint TEXT NOT NULL,
+	UNIQUE (startpoint, endpoint)
+);
+
+CREATE TABLE route (
+	id_route INTEGER PRIMARY KEY AUTOINCREMENT,
+	id_points INTEGER NOT NULL,
+	starttime TEXT NOT NULL,
+	cost INTEGER NOT NULL,
+	currency TEXT NOT NULL DEFAULT 'BYN',
+	freeseats INTEGER NOT NULL,
+	allseats INTEGER NOT NULL,
+	duration INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX route_points ON route (id_points);
+CREATE INDEX route_starttime ON route (starttime);
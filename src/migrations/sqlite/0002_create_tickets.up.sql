@@ -0,0 +1,6 @@
+CREATE TABLE ticket (
+	id_ticket INTEGER PRIMARY KEY AUTOINCREMENT,
+	id_route INTEGER NOT NULL REFERENCES route (id_route) ON DELETE CASCADE,
+	passenger TEXT NOT NULL,
+	booked TEXT NOT NULL
+);
@@ -0,0 +1,6 @@
+ALTER TABLE ticket DROP COLUMN currency;
+ALTER TABLE ticket DROP COLUMN cost;
+ALTER TABLE ticket DROP COLUMN tostop;
+ALTER TABLE ticket DROP COLUMN fromstop;
+
+DROP TABLE stop;
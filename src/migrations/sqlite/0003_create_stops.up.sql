@@ -0,0 +1,17 @@
+CREATE TABLE stop (
+	id_route INTEGER NOT NULL REFERENCES route (id_route) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	point TEXT NOT NULL,
+	arrival INTEGER NOT NULL,
+	departure INTEGER NOT NULL,
+	fare INTEGER NOT NULL,
+	freeseats INTEGER NOT NULL,
+	PRIMARY KEY (id_route, position)
+);
+
+CREATE INDEX stop_point ON stop (point);
+
+ALTER TABLE ticket ADD COLUMN fromstop TEXT NOT NULL DEFAULT '';
+ALTER TABLE ticket ADD COLUMN tostop TEXT NOT NULL DEFAULT '';
+ALTER TABLE ticket ADD COLUMN cost INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE ticket ADD COLUMN currency TEXT NOT NULL DEFAULT 'BYN';
@@ -0,0 +1,5 @@
+DROP INDEX route_schedule_starttime;
+ALTER TABLE route DROP COLUMN id_schedule;
+
+DROP TABLE schedule_exception;
+DROP TABLE schedule;
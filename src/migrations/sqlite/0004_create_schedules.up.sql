@@ -0,0 +1,24 @@
+CREATE TABLE schedule (
+	id_schedule INTEGER PRIMARY KEY AUTOINCREMENT,
+	id_points INTEGER NOT NULL,
+	departure INTEGER NOT NULL,
+	weekdays INTEGER NOT NULL,
+	validfrom TEXT NOT NULL,
+	validto TEXT NOT NULL,
+	cost INTEGER NOT NULL,
+	currency TEXT NOT NULL DEFAULT 'BYN',
+	allseats INTEGER NOT NULL,
+	duration INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX schedule_points ON schedule (id_points);
+
+CREATE TABLE schedule_exception (
+	id_schedule INTEGER NOT NULL REFERENCES schedule (id_schedule) ON DELETE CASCADE,
+	day TEXT NOT NULL,
+	PRIMARY KEY (id_schedule, day)
+);
+
+ALTER TABLE route ADD COLUMN id_schedule INTEGER NULL;
+
+CREATE INDEX route_schedule_starttime ON route (id_schedule, starttime);
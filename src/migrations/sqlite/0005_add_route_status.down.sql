@@ -0,0 +1,3 @@
+DROP INDEX route_status_starttime;
+ALTER TABLE route DROP COLUMN delay_minutes;
+ALTER TABLE route DROP COLUMN status;
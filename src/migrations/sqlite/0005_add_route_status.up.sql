@@ -0,0 +1,4 @@
+ALTER TABLE route ADD COLUMN status TEXT NOT NULL DEFAULT 'scheduled';
+ALTER TABLE route ADD COLUMN delay_minutes INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX route_status_starttime ON route (status, starttime);
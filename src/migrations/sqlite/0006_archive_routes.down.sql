@@ -0,0 +1,5 @@
+DROP TABLE ticket_archive;
+DROP TABLE stop_archive;
+DROP TABLE route_archive;
+
+ALTER TABLE route DROP COLUMN deleted_at;
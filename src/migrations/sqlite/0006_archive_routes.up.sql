@@ -0,0 +1,42 @@
+ALTER TABLE route ADD COLUMN deleted_at TEXT NULL;
+
+CREATE TABLE route_archive (
+	id_route INTEGER PRIMARY KEY,
+	id_points INTEGER NOT NULL,
+	starttime TEXT NOT NULL,
+	cost INTEGER NOT NULL,
+	currency TEXT NOT NULL,
+	freeseats INTEGER NOT NULL,
+	allseats INTEGER NOT NULL,
+	duration INTEGER NOT NULL,
+	id_schedule INTEGER NULL,
+	status TEXT NOT NULL,
+	delay_minutes INTEGER NOT NULL,
+	deleted_at TEXT NULL
+);
+
+CREATE INDEX route_archive_starttime ON route_archive (starttime);
+
+CREATE TABLE stop_archive (
+	id_route INTEGER NOT NULL,
+	position INTEGER NOT NULL,
+	point TEXT NOT NULL,
+	arrival INTEGER NOT NULL,
+	departure INTEGER NOT NULL,
+	fare INTEGER NOT NULL,
+	freeseats INTEGER NOT NULL,
+	PRIMARY KEY (id_route, position)
+);
+
+CREATE TABLE ticket_archive (
+	id_ticket INTEGER PRIMARY KEY,
+	id_route INTEGER NOT NULL,
+	passenger TEXT NOT NULL,
+	fromstop TEXT NOT NULL,
+	tostop TEXT NOT NULL,
+	cost INTEGER NOT NULL,
+	currency TEXT NOT NULL,
+	booked TEXT NOT NULL
+);
+
+CREATE INDEX ticket_archive_route ON ticket_archive (id_route);
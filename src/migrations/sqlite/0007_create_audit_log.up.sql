@@ -0,0 +1,12 @@
+CREATE TABLE audit_log (
+	id_audit INTEGER PRIMARY KEY AUTOINCREMENT,
+	id_route INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	changed TEXT NOT NULL,
+	snapshot_before TEXT NULL,
+	snapshot_after TEXT NULL
+);
+
+CREATE INDEX audit_log_route ON audit_log (id_route, id_audit);
+CREATE INDEX audit_log_changed ON audit_log (changed);
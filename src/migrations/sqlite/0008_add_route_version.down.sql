@@ -0,0 +1,2 @@
+ALTER TABLE route_archive DROP COLUMN version;
+ALTER TABLE route DROP COLUMN version;
@@ -0,0 +1,2 @@
+ALTER TABLE route ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE route_archive ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
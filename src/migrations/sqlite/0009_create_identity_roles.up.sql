@@ -0,0 +1,5 @@
+CREATE TABLE identity_role (
+	name TEXT NOT NULL,
+	role TEXT NOT NULL,
+	PRIMARY KEY (name, role)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_key (
+	idem_key TEXT PRIMARY KEY,
+	request_hash TEXT NOT NULL,
+	status INTEGER NOT NULL DEFAULT 0,
+	content_type TEXT NOT NULL DEFAULT '',
+	etag TEXT NOT NULL DEFAULT '',
+	body TEXT NULL,
+	expires TEXT NOT NULL
+);
+
+CREATE INDEX idempotency_key_expires ON idempotency_key (expires);
@@ -0,0 +1,3 @@
+CREATE INDEX route_schedule_starttime ON route (id_schedule, starttime);
+
+DROP INDEX route_schedule_start_unique;
@@ -0,0 +1,3 @@
+CREATE UNIQUE INDEX route_schedule_start_unique ON route (id_schedule, starttime);
+
+DROP INDEX route_schedule_starttime;
@@ -0,0 +1,42 @@
+package routemanager
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//ArchiveRoutes moves routes which started earlier than age before now to archive
+//with their stops and tickets, deleted routes are archived too.
+func (r *RouteManager) ArchiveRoutes(ctx context.Context, now time.Time, age time.Duration) (int, error) {
+	if age <= 0 {
+		return 0, domain.Invalid("age is invalid")
+	}
+	n, err := r.storage.ArchiveRoutes(ctx, now.UTC().Truncate(time.Second).Add(-age))
+	return n, markCommitted(ctx, err)
+}
+
+//StartArchiver archives routes older than age every interval until ctx is done.
+//Every archiving has to finish during interval.
+func (r *RouteManager) StartArchiver(ctx context.Context, age, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, interval)
+		archived, err := r.ArchiveRoutes(runCtx, time.Now(), age)
+		cancel()
+		if err != nil {
+			log.Println(err)
+		}
+		if archived > 0 {
+			log.Printf("%d routes were archived\n", archived)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package routemanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveRoutes(t *testing.T) {
+	now := time.Date(2019, 04, 12, 10, 30, 15, 500, time.FixedZone("MSK", 3*60*60))
+
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+	routestrg.On("ArchiveRoutes", mock.Anything, time.Date(2019, 01, 12, 7, 30, 15, 0, time.UTC)).Return(3, nil)
+	routestrg.On("ArchiveRoutes", mock.Anything, time.Date(2019, 04, 11, 7, 30, 15, 0, time.UTC)).
+		Return(0, domain.Unavailable("data hasn't read"))
+
+	testCases := []struct {
+		name             string
+		age              time.Duration
+		expectedArchived int
+		expectedError    error
+	}{
+		{
+			name:             "successful test",
+			age:              90 * 24 * time.Hour,
+			expectedArchived: 3,
+		},
+		{
+			name:          "storage error",
+			age:           24 * time.Hour,
+			expectedError: domain.Unavailable("data hasn't read"),
+		},
+		{
+			name:          "invalid age",
+			age:           0,
+			expectedError: domain.Invalid("age is invalid"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			archived, err := routeman.ArchiveRoutes(context.Background(), now, tc.age)
+			require.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedArchived, archived)
+		})
+	}
+}
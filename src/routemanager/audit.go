@@ -0,0 +1,122 @@
+package routemanager
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//actorKey - key of actor of request in context.
+type actorKey struct{}
+
+//Actors of changes which aren't made on behalf of known clients.
+const (
+	//Anonymous - actor of changes which were made without known actor.
+	Anonymous = "anonymous"
+	//Generator - actor of trips which are generated by schedules in background.
+	Generator = "generator"
+)
+
+//WithActor returns context of request made by actor, changes of routes made with this context
+//are recorded in audit log on behalf of the actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+//ActorFrom returns actor of request from context or Anonymous if context has no actor.
+func ActorFrom(ctx context.Context) string {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	if !ok || actor == "" {
+		return Anonymous
+	}
+	return actor
+}
+
+//snapshot returns copy of route which doesn't share stops with original, nil route isn't copied.
+func snapshot(route *domain.Route) *domain.Route {
+	if route == nil {
+		return nil
+	}
+	copied := *route
+	copied.Stops = append([]domain.Stop(nil), route.Stops...)
+	return &copied
+}
+
+//SnapshotEncoder - function which encodes snapshot of route for audit log as JSON.
+type SnapshotEncoder func(route *domain.Route) ([]byte, error)
+
+//encodeRoute encodes snapshot of route as JSON of domain.Route.
+func encodeRoute(route *domain.Route) ([]byte, error) {
+	return json.Marshal(route)
+}
+
+//SetSnapshotEncoder sets encoder of snapshots of routes, e.g. for keeping them
+//in representation of API instead of domain.Route.
+func (r *RouteManager) SetSnapshotEncoder(encode SnapshotEncoder) {
+	r.encode = encode
+}
+
+//encodeSnapshot returns encoded snapshot of route or nil if there is no route.
+func (r *RouteManager) encodeSnapshot(route *domain.Route) (json.RawMessage, error) {
+	if route == nil {
+		return nil, nil
+	}
+	return r.encode(route)
+}
+
+//record adds entry about change of the route made by actor of ctx to audit log.
+//It has to be called in transaction of the change, so change isn't kept without entry.
+func (r *RouteManager) record(ctx context.Context, action string, id int, before, after *domain.Route) error {
+	e := domain.AuditEntry{
+		RouteID: id,
+		Action:  action,
+		Actor:   ActorFrom(ctx),
+		Time:    time.Now().UTC().Truncate(time.Second),
+	}
+	var err error
+	e.Before, err = r.encodeSnapshot(before)
+	if err != nil {
+		return err
+	}
+	e.After, err = r.encodeSnapshot(after)
+	if err != nil {
+		return err
+	}
+	_, err = r.storage.AddAuditEntry(ctx, &e)
+	return err
+}
+
+//validateAuditQuery checks filters and pagination of audit query.
+func validateAuditQuery(q domain.AuditQuery) error {
+	if q.Limit < 0 || q.Offset < 0 {
+		return domain.Invalid("invalid pagination")
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.To.After(q.From) {
+		return domain.Invalid("time range is invalid")
+	}
+	return nil
+}
+
+//AuditLog finds entries of audit log by filters of query ordered by time of change.
+func (r RouteManager) AuditLog(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	err := validateAuditQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := r.storage.AuditEntries(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []domain.AuditEntry{}
+	}
+	return entries, nil
+}
+
+//RouteHistory returns all changes of the route ordered by time of change.
+//History is kept for deleted and archived routes too.
+func (r RouteManager) RouteHistory(ctx context.Context, id int) ([]domain.AuditEntry, error) {
+	return r.AuditLog(ctx, domain.AuditQuery{RouteID: id})
+}
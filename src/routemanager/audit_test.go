@@ -0,0 +1,149 @@
+package routemanager
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActorFrom(t *testing.T) {
+	assert.Equal(t, Anonymous, ActorFrom(context.Background()))
+	assert.Equal(t, Anonymous, ActorFrom(WithActor(context.Background(), "")))
+	assert.Equal(t, "admin", ActorFrom(WithActor(context.Background(), "admin")))
+}
+
+func TestRecordChanges(t *testing.T) {
+	var entries []domain.AuditEntry
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil).Run(func(args mock.Arguments) {
+		entries = append(entries, *args.Get(1).(*domain.AuditEntry))
+	})
+
+	created := stopsRoute()
+	routestrg.On("AddRoute", mock.Anything, &created).Return(5, nil)
+	stored := stopsRoute()
+	stored.ID, stored.Status, stored.Version = 5, domain.StatusScheduled, 1
+	routestrg.On("RouteByID", mock.Anything, 5).Return(&stored, nil)
+	routestrg.On("SetRouteStatus", mock.Anything, 5, 1, domain.StatusDelayed, 10*time.Minute).Return(nil)
+	routestrg.On("DeleteRow", mock.Anything, 5, 2).Return(nil)
+
+	ctx := WithActor(context.Background(), "dispatcher")
+	require.NoError(t, routeman.CreateNewRoute(ctx, &created))
+	delayed, err := routeman.ChangeRouteStatus(ctx, 5, 1, domain.StatusDelayed, 10*time.Minute)
+	require.NoError(t, err)
+	_, err = routeman.DeleteRouteByID(context.Background(), 5, 2)
+	require.NoError(t, err)
+
+	require.Len(t, entries, 3)
+	for _, e := range entries {
+		assert.Equal(t, 5, e.RouteID)
+		assert.WithinDuration(t, time.Now(), e.Time, 2*time.Second)
+	}
+
+	decode := func(data json.RawMessage) *domain.Route {
+		if data == nil {
+			return nil
+		}
+		var route domain.Route
+		require.NoError(t, json.Unmarshal(data, &route))
+		return &route
+	}
+
+	assert.Equal(t, domain.ActionCreate, entries[0].Action)
+	assert.Equal(t, "dispatcher", entries[0].Actor)
+	assert.Nil(t, entries[0].Before)
+	assert.Equal(t, &created, decode(entries[0].After))
+
+	assert.Equal(t, domain.ActionStatus, entries[1].Action)
+	assert.Equal(t, domain.StatusScheduled, decode(entries[1].Before).Status)
+	assert.Equal(t, delayed, decode(entries[1].After))
+
+	assert.Equal(t, domain.ActionDelete, entries[2].Action)
+	assert.Equal(t, Anonymous, entries[2].Actor)
+	assert.Equal(t, delayed, decode(entries[2].Before))
+	assert.Nil(t, entries[2].After)
+}
+
+func TestRecordInTransaction(t *testing.T) {
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+	var inTransaction bool
+	routestrg.On("InTransaction", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, fn func(context.Context) error) error {
+			inTransaction = true
+			defer func() { inTransaction = false }()
+			return fn(ctx)
+		})
+	routestrg.On("AddRoute", mock.Anything, mock.Anything).Return(5, nil).Run(func(mock.Arguments) {
+		assert.True(t, inTransaction)
+	})
+	unavailable := domain.Unavailable("audit entry hasn't added")
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(0, unavailable).Run(func(args mock.Arguments) {
+		assert.True(t, inTransaction)
+		assert.Equal(t, json.RawMessage(`{"id":5}`), args.Get(1).(*domain.AuditEntry).After)
+	})
+	routeman.SetSnapshotEncoder(func(route *domain.Route) ([]byte, error) {
+		return []byte(`{"id":5}`), nil
+	})
+
+	route := stopsRoute()
+	err := routeman.CreateNewRoute(context.Background(), &route)
+	assert.Equal(t, unavailable, err)
+	routestrg.AssertExpectations(t)
+}
+
+func TestAuditLog(t *testing.T) {
+	from := time.Date(2019, 04, 12, 0, 0, 0, 0, time.UTC)
+	entries := []domain.AuditEntry{{ID: 1, RouteID: 2, Action: domain.ActionCreate, Actor: "admin", Time: from}}
+
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+	routestrg.On("AuditEntries", mock.Anything, domain.AuditQuery{Actor: "admin", From: from}).Return(entries, nil)
+	routestrg.On("AuditEntries", mock.Anything, domain.AuditQuery{Actor: "cashier"}).Return(nil, nil)
+
+	testCases := []struct {
+		name            string
+		query           domain.AuditQuery
+		expectedEntries []domain.AuditEntry
+		expectedError   error
+	}{
+		{
+			name:            "successful test",
+			query:           domain.AuditQuery{Actor: "admin", From: from},
+			expectedEntries: entries,
+		},
+		{
+			name:            "no entries",
+			query:           domain.AuditQuery{Actor: "cashier"},
+			expectedEntries: []domain.AuditEntry{},
+		},
+		{
+			name:          "invalid pagination",
+			query:         domain.AuditQuery{Limit: -1},
+			expectedError: domain.Invalid("invalid pagination"),
+		},
+		{
+			name:          "invalid time range",
+			query:         domain.AuditQuery{From: from, To: from},
+			expectedError: domain.Invalid("time range is invalid"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := routeman.AuditLog(context.Background(), tc.query)
+			require.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedEntries, entries)
+		})
+	}
+}
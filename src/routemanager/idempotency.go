@@ -0,0 +1,46 @@
+package routemanager
+
+import (
+	"context"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//IdempotencyStorage - interface for storing responses to requests with idempotency keys.
+//AddIdempotencyRecord removes expired records and fails with conflict error if key is used.
+type IdempotencyStorage interface {
+	AddIdempotencyRecord(ctx context.Context, rec *domain.IdempotencyRecord) error
+	IdempotencyRecordByKey(ctx context.Context, key string) (*domain.IdempotencyRecord, error)
+	CompleteIdempotencyRecord(ctx context.Context, rec *domain.IdempotencyRecord) error
+	DeleteIdempotencyRecord(ctx context.Context, key string) error
+}
+
+//commitKey - key of flag of committed changes in context.
+type commitKey struct{}
+
+//WithCommitTracking returns context which records whether changes made with it were committed
+//to storage.
+func WithCommitTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, commitKey{}, new(bool))
+}
+
+//Committed reports whether changes made with context returned by WithCommitTracking
+//were committed to storage.
+func Committed(ctx context.Context) bool {
+	committed, ok := ctx.Value(commitKey{}).(*bool)
+	return ok && *committed
+}
+
+//markCommitted records in context that changes were committed if err is nil, err is returned as is.
+func markCommitted(ctx context.Context, err error) error {
+	committed, ok := ctx.Value(commitKey{}).(*bool)
+	if ok && err == nil {
+		*committed = true
+	}
+	return err
+}
+
+//inTransaction runs fn in transaction of storage and records in context whether it was committed.
+func (r *RouteManager) inTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return markCommitted(ctx, r.storage.InTransaction(ctx, fn))
+}
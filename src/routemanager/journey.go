@@ -0,0 +1,229 @@
+package routemanager
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//Limits of journey planning.
+const (
+	maxJourneyLegs = 4
+	maxJourneys    = 20
+)
+
+//journeyPlanner - struct for searching journeys in graph of routes.
+//Journeys holds at most maxJourneys best journeys found so far ordered by rank key.
+type journeyPlanner struct {
+	graph    map[string][]domain.Leg
+	query    domain.JourneyQuery
+	now      time.Time
+	journeys []domain.Journey
+}
+
+//routeStops returns stops of the route, route without stops has only its start and end points.
+func routeStops(route domain.Route) []domain.Stop {
+	if len(route.Stops) > 0 {
+		return route.Stops
+	}
+	return []domain.Stop{
+		{Point: route.Points.StartPoint, FreeSeats: route.FreeSeats},
+		{Point: route.Points.EndPoint, Arrival: route.Duration, Departure: route.Duration, Fare: route.Cost.Amount},
+	}
+}
+
+//routeLegs returns legs of the route between every pair of its stops which have free seat
+//on all segments between them.
+func routeLegs(route domain.Route) []domain.Leg {
+	stops := routeStops(route)
+	var legs []domain.Leg
+	for from := range stops {
+		cost := domain.Money{Currency: route.Cost.Currency}
+		for to := from + 1; to < len(stops) && stops[to-1].FreeSeats > 0; to++ {
+			cost.Amount += stops[to].Fare
+			legs = append(legs, domain.Leg{
+				Route:     route,
+				From:      stops[from].Point,
+				To:        stops[to].Point,
+				Departure: route.Start.Add(stops[from].Departure),
+				Arrival:   route.Start.Add(stops[to].Arrival),
+				Cost:      cost,
+			})
+		}
+	}
+	return legs
+}
+
+//newJourneyPlanner builds graph where points are vertices and legs between stops of routes are edges,
+//so passenger can change routes at their intermediate stops. Legs which depart before now are skipped.
+func newJourneyPlanner(routes []domain.Route, q domain.JourneyQuery, now time.Time) *journeyPlanner {
+	graph := make(map[string][]domain.Leg)
+	for _, route := range routes {
+		for _, leg := range routeLegs(route) {
+			graph[leg.From] = append(graph[leg.From], leg)
+		}
+	}
+	return &journeyPlanner{graph: graph, query: q, now: now}
+}
+
+//isActive checks if route with status hasn't departed and wasn't cancelled.
+func isActive(status string) bool {
+	for _, active := range domain.ActiveStatuses() {
+		if status == active {
+			return true
+		}
+	}
+	return false
+}
+
+//canFollow checks if passenger can take the leg of active route after previous legs.
+//All legs of journey must be paid in the same currency and taken by different routes,
+//connections are counted by expected times of legs including delays of their routes.
+func (p *journeyPlanner) canFollow(legs []domain.Leg, leg domain.Leg) bool {
+	if !isActive(leg.Route.Status) || leg.ExpectedDeparture().Before(p.now) {
+		return false
+	}
+	if len(legs) == 0 {
+		return !leg.Departure.Before(p.query.Date) && leg.Departure.Before(p.query.Date.AddDate(0, 0, 1))
+	}
+	for _, prev := range legs {
+		if prev.Route.ID == leg.Route.ID {
+			return false
+		}
+	}
+	last := legs[len(legs)-1]
+	return leg.Cost.Currency == legs[0].Cost.Currency &&
+		!leg.ExpectedDeparture().Before(last.ExpectedArrival().Add(p.query.MinConnection))
+}
+
+//keep adds journey to found journeys and drops the worst of them if there are more than maxJourneys.
+func (p *journeyPlanner) keep(j domain.Journey) {
+	p.journeys = append(p.journeys, j)
+	rank(p.journeys, p.query.RankBy)
+	if len(p.journeys) > maxJourneys {
+		p.journeys = p.journeys[:maxJourneys]
+	}
+}
+
+//canImprove checks if journey which starts with legs can be better than the worst kept journey.
+//Arrival, cost and transfers of journey only grow when legs are added, so keys of legs are
+//the lower bound of keys of all journeys which continue them.
+func (p *journeyPlanner) canImprove(legs []domain.Leg) bool {
+	if len(p.journeys) < maxJourneys {
+		return true
+	}
+	return lessKeys(journeyKeys(domain.Journey{Legs: legs}, p.query.RankBy),
+		journeyKeys(p.journeys[len(p.journeys)-1], p.query.RankBy))
+}
+
+//walk finds best journeys to destination from point which is reached by legs.
+func (p *journeyPlanner) walk(point string, legs []domain.Leg, visited map[string]bool) {
+	for _, leg := range p.graph[point] {
+		next := leg.To
+		if visited[next] || !p.canFollow(legs, leg) {
+			continue
+		}
+
+		path := append(legs[:len(legs):len(legs)], leg)
+		if !p.canImprove(path) {
+			continue
+		}
+		if next == p.query.To {
+			p.keep(domain.Journey{Legs: path})
+			continue
+		}
+		if len(path) < p.query.MaxLegs {
+			visited[next] = true
+			p.walk(next, path, visited)
+			delete(visited, next)
+		}
+	}
+}
+
+//journeyKeys returns values for comparing journeys in order of priority for rank key.
+//Journeys are compared by expected arrival including delay of the last route.
+func journeyKeys(j domain.Journey, rankBy string) [3]int64 {
+	arrival := j.Legs[len(j.Legs)-1].ExpectedArrival().Unix()
+	cost := int64(j.Cost().Amount)
+	transfers := int64(j.Transfers())
+	switch rankBy {
+	case domain.RankByCost:
+		return [3]int64{cost, arrival, transfers}
+	case domain.RankByTransfers:
+		return [3]int64{transfers, arrival, cost}
+	}
+	return [3]int64{arrival, cost, transfers}
+}
+
+//lessKeys compares keys of journeys in order of priority.
+func lessKeys(a, b [3]int64) bool {
+	for k := range a {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return false
+}
+
+//rank sorts journeys by rank key.
+func rank(journeys []domain.Journey, rankBy string) {
+	sort.SliceStable(journeys, func(i, j int) bool {
+		return lessKeys(journeyKeys(journeys[i], rankBy), journeyKeys(journeys[j], rankBy))
+	})
+}
+
+//validateJourneyQuery checks request for journey planning.
+func validateJourneyQuery(q domain.JourneyQuery) error {
+	if q.From == "" || q.To == "" {
+		return domain.Invalid("point is empty")
+	}
+	if q.From == q.To {
+		return domain.Invalid("points are equal")
+	}
+	if q.MaxLegs < 1 || q.MaxLegs > maxJourneyLegs {
+		return domain.Invalid("invalid number of legs")
+	}
+	if q.MinConnection < 0 {
+		return domain.Invalid("invalid connection time")
+	}
+	switch q.RankBy {
+	case domain.RankByArrival, domain.RankByCost, domain.RankByTransfers:
+		return nil
+	}
+	return domain.Invalid("invalid rank key")
+}
+
+//PlanJourneys finds best journeys from one point to another with first departure at the date.
+//Journeys consist of up to MaxLegs legs of active routes with free seats between stops of legs,
+//passenger needs at least MinConnection between arrival and next departure.
+//Legs which have already departed are skipped, at most maxJourneys journeys are returned.
+func (r RouteManager) PlanJourneys(ctx context.Context, q domain.JourneyQuery) ([]domain.Journey, error) {
+	err := validateJourneyQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	q.Date = time.Date(q.Date.Year(), q.Date.Month(), q.Date.Day(), 0, 0, 0, 0, q.Date.Location())
+	//routes which started the day before can pass intermediate stops at the date, free seats
+	//of routes are counted on their busiest segments, so they are checked by legs
+	routes, _, err := r.storage.RoutesByQuery(ctx, domain.RouteQuery{
+		From:     q.Date.AddDate(0, 0, -1),
+		To:       q.Date.AddDate(0, 0, q.MaxLegs),
+		Statuses: domain.ActiveStatuses(),
+		SortBy:   domain.SortByStart,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	planner := newJourneyPlanner(routes, q, time.Now())
+	planner.walk(q.From, nil, map[string]bool{q.From: true})
+
+	journeys := planner.journeys
+	if journeys == nil {
+		journeys = []domain.Journey{}
+	}
+	return journeys, nil
+}
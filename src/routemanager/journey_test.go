@@ -0,0 +1,313 @@
+package routemanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanJourneys(t *testing.T) {
+	day := time.Date(time.Now().Year()+1, 04, 12, 0, 0, 0, 0, time.UTC)
+	routes := []domain.Route{
+		{
+			ID:        1,
+			Points:    domain.Points{StartPoint: "Brest", EndPoint: "Minsk"},
+			Start:     day.Add(8 * time.Hour),
+			Duration:  4 * time.Hour,
+			Cost:      domain.Money{Amount: 2000, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusScheduled,
+		},
+		{
+			ID:        2,
+			Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+			Start:     day.Add(12*time.Hour + 10*time.Minute),
+			Duration:  3 * time.Hour,
+			Cost:      domain.Money{Amount: 1500, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusScheduled,
+		},
+		{
+			ID:        3,
+			Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+			Start:     day.Add(13 * time.Hour),
+			Duration:  3 * time.Hour,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusScheduled,
+		},
+		{
+			ID:        4,
+			Points:    domain.Points{StartPoint: "Brest", EndPoint: "Vitebsk"},
+			Start:     day.Add(9 * time.Hour),
+			Duration:  8 * time.Hour,
+			Cost:      domain.Money{Amount: 3000, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusScheduled,
+		},
+		{
+			ID:        5,
+			Points:    domain.Points{StartPoint: "Brest", EndPoint: "Minsk"},
+			Start:     day.Add(-2 * time.Hour),
+			Duration:  4 * time.Hour,
+			Cost:      domain.Money{Amount: 2000, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusScheduled,
+		},
+		{
+			ID:        6,
+			Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Brest"},
+			Start:     day.Add(13 * time.Hour),
+			Duration:  4 * time.Hour,
+			Cost:      domain.Money{Amount: 2000, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusScheduled,
+		},
+		{
+			ID:        7,
+			Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+			Start:     day.Add(13*time.Hour + 30*time.Minute),
+			Duration:  3 * time.Hour,
+			Cost:      domain.Money{Amount: 500, Currency: "EUR"},
+			FreeSeats: 10,
+			Status:    domain.StatusScheduled,
+		},
+		{
+			ID:        8,
+			Points:    domain.Points{StartPoint: "Brest", EndPoint: "Grodno"},
+			Start:     day.Add(8 * time.Hour),
+			Duration:  2 * time.Hour,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusDelayed,
+			Delay:     30 * time.Minute,
+		},
+		{
+			ID:        9,
+			Points:    domain.Points{StartPoint: "Grodno", EndPoint: "Lida"},
+			Start:     day.Add(10*time.Hour + 15*time.Minute),
+			Duration:  time.Hour,
+			Cost:      domain.Money{Amount: 500, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusBoarding,
+		},
+		{
+			ID:        10,
+			Points:    domain.Points{StartPoint: "Grodno", EndPoint: "Lida"},
+			Start:     day.Add(10*time.Hour + 20*time.Minute),
+			Duration:  time.Hour,
+			Cost:      domain.Money{Amount: 500, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusDelayed,
+			Delay:     20 * time.Minute,
+		},
+		{
+			ID:        11,
+			Points:    domain.Points{StartPoint: "Grodno", EndPoint: "Lida"},
+			Start:     day.Add(11 * time.Hour),
+			Duration:  time.Hour,
+			Cost:      domain.Money{Amount: 500, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusCancelled,
+		},
+		{
+			ID:       12,
+			Points:   domain.Points{StartPoint: "Pinsk", EndPoint: "Polotsk"},
+			Start:    day.Add(6 * time.Hour),
+			Duration: 6 * time.Hour,
+			Cost:     domain.Money{Amount: 1800, Currency: "BYN"},
+			Stops: []domain.Stop{
+				{Point: "Pinsk", FreeSeats: 5},
+				{Point: "Minsk", Arrival: 3 * time.Hour, Departure: 3*time.Hour + 10*time.Minute, Fare: 1000,
+					FreeSeats: 5},
+				{Point: "Polotsk", Arrival: 6 * time.Hour, Departure: 6 * time.Hour, Fare: 800},
+			},
+			FreeSeats: 5,
+			Status:    domain.StatusScheduled,
+		},
+		{
+			ID:       13,
+			Points:   domain.Points{StartPoint: "Grodno", EndPoint: "Baranovichi"},
+			Start:    day.Add(7 * time.Hour),
+			Duration: 4 * time.Hour,
+			Cost:     domain.Money{Amount: 1500, Currency: "BYN"},
+			Stops: []domain.Stop{
+				{Point: "Grodno"},
+				{Point: "Slonim", Arrival: 2 * time.Hour, Departure: 2 * time.Hour, Fare: 1000, FreeSeats: 3},
+				{Point: "Baranovichi", Arrival: 4 * time.Hour, Departure: 4 * time.Hour, Fare: 500},
+			},
+			Status: domain.StatusScheduled,
+		},
+	}
+
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+	routestrg.On("RoutesByQuery", mock.Anything, domain.RouteQuery{
+		From:     day.AddDate(0, 0, -1),
+		To:       day.AddDate(0, 0, 2),
+		Statuses: domain.ActiveStatuses(),
+		SortBy:   domain.SortByStart,
+	}).Return(routes, len(routes), nil)
+	routestrg.On("RoutesByQuery", mock.Anything, domain.RouteQuery{
+		From:     day.AddDate(0, 0, -1),
+		To:       day.AddDate(0, 0, 1),
+		Statuses: domain.ActiveStatuses(),
+		SortBy:   domain.SortByStart,
+	}).Return(nil, 0, domain.Unavailable("data hasn't read"))
+
+	testCases := []struct {
+		name          string
+		query         domain.JourneyQuery
+		expectedLegs  [][]int
+		expectedCost  int
+		expectedError error
+	}{
+		{
+			name: "rank by arrival",
+			query: domain.JourneyQuery{From: "Brest", To: "Vitebsk", Date: day.Add(10 * time.Hour),
+				MaxLegs: 2, MinConnection: 10 * time.Minute, RankBy: domain.RankByArrival},
+			expectedLegs: [][]int{{1, 2}, {1, 3}, {4}},
+		},
+		{
+			name: "rank by cost",
+			query: domain.JourneyQuery{From: "Brest", To: "Vitebsk", Date: day,
+				MaxLegs: 2, MinConnection: 10 * time.Minute, RankBy: domain.RankByCost},
+			expectedLegs: [][]int{{1, 3}, {4}, {1, 2}},
+		},
+		{
+			name: "rank by transfers",
+			query: domain.JourneyQuery{From: "Brest", To: "Vitebsk", Date: day,
+				MaxLegs: 2, MinConnection: 10 * time.Minute, RankBy: domain.RankByTransfers},
+			expectedLegs: [][]int{{4}, {1, 2}, {1, 3}},
+		},
+		{
+			name: "long connection",
+			query: domain.JourneyQuery{From: "Brest", To: "Vitebsk", Date: day,
+				MaxLegs: 2, MinConnection: 30 * time.Minute, RankBy: domain.RankByArrival},
+			expectedLegs: [][]int{{1, 3}, {4}},
+		},
+		{
+			name: "delayed routes",
+			query: domain.JourneyQuery{From: "Brest", To: "Lida", Date: day,
+				MaxLegs: 2, MinConnection: 10 * time.Minute, RankBy: domain.RankByArrival},
+			expectedLegs: [][]int{{8, 10}},
+		},
+		{
+			name: "transfer at intermediate stop",
+			query: domain.JourneyQuery{From: "Pinsk", To: "Vitebsk", Date: day,
+				MaxLegs: 2, MinConnection: 10 * time.Minute, RankBy: domain.RankByArrival},
+			expectedLegs: [][]int{{12, 2}, {12, 3}},
+			expectedCost: 2500,
+		},
+		{
+			name: "boarding at intermediate stop",
+			query: domain.JourneyQuery{From: "Slonim", To: "Baranovichi", Date: day,
+				MaxLegs: 2, RankBy: domain.RankByArrival},
+			expectedLegs: [][]int{{13}},
+		},
+		{
+			name: "no free seats on segment",
+			query: domain.JourneyQuery{From: "Grodno", To: "Baranovichi", Date: day,
+				MaxLegs: 2, RankBy: domain.RankByArrival},
+			expectedLegs: [][]int{},
+		},
+		{
+			name: "no journeys",
+			query: domain.JourneyQuery{From: "Vitebsk", To: "Brest", Date: day,
+				MaxLegs: 2, RankBy: domain.RankByArrival},
+			expectedLegs: [][]int{},
+		},
+		{
+			name: "storage error",
+			query: domain.JourneyQuery{From: "Brest", To: "Vitebsk", Date: day,
+				MaxLegs: 1, RankBy: domain.RankByArrival},
+			expectedError: domain.Unavailable("data hasn't read"),
+		},
+		{
+			name:          "equal points",
+			query:         domain.JourneyQuery{From: "Brest", To: "Brest", Date: day, MaxLegs: 2, RankBy: domain.RankByCost},
+			expectedError: domain.Invalid("points are equal"),
+		},
+		{
+			name:          "too many legs",
+			query:         domain.JourneyQuery{From: "Brest", To: "Minsk", Date: day, MaxLegs: 5, RankBy: domain.RankByCost},
+			expectedError: domain.Invalid("invalid number of legs"),
+		},
+		{
+			name:          "invalid rank key",
+			query:         domain.JourneyQuery{From: "Brest", To: "Minsk", Date: day, MaxLegs: 2, RankBy: "id"},
+			expectedError: domain.Invalid("invalid rank key"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			journeys, err := routeman.PlanJourneys(context.Background(), tc.query)
+			require.Equal(t, tc.expectedError, err)
+			if err != nil {
+				return
+			}
+			legs := [][]int{}
+			for _, j := range journeys {
+				var ids []int
+				for _, leg := range j.Legs {
+					ids = append(ids, leg.Route.ID)
+				}
+				legs = append(legs, ids)
+			}
+			assert.Equal(t, tc.expectedLegs, legs)
+			if tc.expectedCost > 0 {
+				assert.Equal(t, tc.expectedCost, journeys[0].Cost().Amount)
+			}
+		})
+	}
+}
+
+func TestJourneyPlannerLimits(t *testing.T) {
+	day := time.Date(2019, 04, 12, 0, 0, 0, 0, time.UTC)
+	var routes []domain.Route
+	for i := 0; i < maxJourneys+10; i++ {
+		routes = append(routes,
+			domain.Route{
+				ID:        2 * i,
+				Points:    domain.Points{StartPoint: "Brest", EndPoint: "Minsk"},
+				Start:     day.Add(time.Duration(i) * 10 * time.Minute),
+				Duration:  4 * time.Hour,
+				Cost:      domain.Money{Amount: 2000, Currency: "BYN"},
+				FreeSeats: 10,
+				Status:    domain.StatusScheduled,
+			},
+			domain.Route{
+				ID:        2*i + 1,
+				Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+				Start:     day.Add(5*time.Hour + time.Duration(i)*10*time.Minute),
+				Duration:  3 * time.Hour,
+				Cost:      domain.Money{Amount: 1500, Currency: "BYN"},
+				FreeSeats: 10,
+				Status:    domain.StatusScheduled,
+			})
+	}
+	q := domain.JourneyQuery{From: "Brest", To: "Vitebsk", Date: day, MaxLegs: 2, RankBy: domain.RankByArrival}
+
+	//routes which departed before now are skipped
+	planner := newJourneyPlanner(routes, q, day.Add(8*time.Hour))
+	planner.walk(q.From, nil, map[string]bool{q.From: true})
+	assert.Empty(t, planner.journeys)
+
+	planner = newJourneyPlanner(routes, q, day.Add(10*time.Minute))
+	planner.walk(q.From, nil, map[string]bool{q.From: true})
+	require.Len(t, planner.journeys, maxJourneys)
+	for _, j := range planner.journeys {
+		assert.False(t, j.Departure().Before(day.Add(10*time.Minute)))
+	}
+	//six journeys arrive at 8:00, seven at 8:10 and eight at 8:20
+	assert.Equal(t, day.Add(8*time.Hour), planner.journeys[0].Arrival())
+	assert.Equal(t, day.Add(8*time.Hour+20*time.Minute), planner.journeys[maxJourneys-1].Arrival())
+}
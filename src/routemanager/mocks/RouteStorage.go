@@ -2,28 +2,30 @@
 
 package mocks
 
+import context "context"
 import domain "github.com/JaneKetko/Buses/src/domain"
 import mock "github.com/stretchr/testify/mock"
+import time "time"
 
 // RouteStorage is an autogenerated mock type for the RouteStorage type
 type RouteStorage struct {
 	mock.Mock
 }
 
-// AddRoute provides a mock function with given fields: _a0
-func (_m *RouteStorage) AddRoute(_a0 *domain.Route) (int, error) {
-	ret := _m.Called(_a0)
+// AddAuditEntry provides a mock function with given fields: ctx, e
+func (_m *RouteStorage) AddAuditEntry(ctx context.Context, e *domain.AuditEntry) (int, error) {
+	ret := _m.Called(ctx, e)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(*domain.Route) int); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuditEntry) int); ok {
+		r0 = rf(ctx, e)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*domain.Route) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuditEntry) error); ok {
+		r1 = rf(ctx, e)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -31,13 +33,120 @@ func (_m *RouteStorage) AddRoute(_a0 *domain.Route) (int, error) {
 	return r0, r1
 }
 
-// DeleteRow provides a mock function with given fields: id
-func (_m *RouteStorage) DeleteRow(id int) error {
-	ret := _m.Called(id)
+// AddRoute provides a mock function with given fields: ctx, r
+func (_m *RouteStorage) AddRoute(ctx context.Context, r *domain.Route) (int, error) {
+	ret := _m.Called(ctx, r)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Route) int); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Route) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddSchedule provides a mock function with given fields: ctx, s
+func (_m *RouteStorage) AddSchedule(ctx context.Context, s *domain.Schedule) (int, error) {
+	ret := _m.Called(ctx, s)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Schedule) int); ok {
+		r0 = rf(ctx, s)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Schedule) error); ok {
+		r1 = rf(ctx, s)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ArchiveRoutes provides a mock function with given fields: ctx, before
+func (_m *RouteStorage) ArchiveRoutes(ctx context.Context, before time.Time) (int, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AuditEntries provides a mock function with given fields: ctx, q
+func (_m *RouteStorage) AuditEntries(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	ret := _m.Called(ctx, q)
+
+	var r0 []domain.AuditEntry
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AuditQuery) []domain.AuditEntry); ok {
+		r0 = rf(ctx, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.AuditEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.AuditQuery) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BookSeat provides a mock function with given fields: ctx, t
+func (_m *RouteStorage) BookSeat(ctx context.Context, t *domain.Ticket) (int, error) {
+	ret := _m.Called(ctx, t)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Ticket) int); ok {
+		r0 = rf(ctx, t)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Ticket) error); ok {
+		r1 = rf(ctx, t)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelBooking provides a mock function with given fields: ctx, id
+func (_m *RouteStorage) CancelBooking(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -45,22 +154,50 @@ func (_m *RouteStorage) DeleteRow(id int) error {
 	return r0
 }
 
-// GetAllData provides a mock function with given fields:
-func (_m *RouteStorage) GetAllData() ([]domain.Route, error) {
-	ret := _m.Called()
+// DeleteRow provides a mock function with given fields: ctx, id, version
+func (_m *RouteStorage) DeleteRow(ctx context.Context, id int, version int) error {
+	ret := _m.Called(ctx, id, version)
 
-	var r0 []domain.Route
-	if rf, ok := ret.Get(0).(func() []domain.Route); ok {
-		r0 = rf()
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, id, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteSchedule provides a mock function with given fields: ctx, id
+func (_m *RouteStorage) DeleteSchedule(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAllSchedules provides a mock function with given fields: ctx
+func (_m *RouteStorage) GetAllSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []domain.Schedule
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Schedule); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]domain.Route)
+			r0 = ret.Get(0).([]domain.Schedule)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -68,13 +205,41 @@ func (_m *RouteStorage) GetAllData() ([]domain.Route, error) {
 	return r0, r1
 }
 
-// RouteByID provides a mock function with given fields: id
-func (_m *RouteStorage) RouteByID(id int) (*domain.Route, error) {
-	ret := _m.Called(id)
+// InTransaction provides a mock function with given fields: ctx, fn
+func (_m *RouteStorage) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	ret := _m.Called(ctx, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreRoute provides a mock function with given fields: ctx, id, version
+func (_m *RouteStorage) RestoreRoute(ctx context.Context, id int, version int) error {
+	ret := _m.Called(ctx, id, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, id, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RouteByID provides a mock function with given fields: ctx, id
+func (_m *RouteStorage) RouteByID(ctx context.Context, id int) (*domain.Route, error) {
+	ret := _m.Called(ctx, id)
 
 	var r0 *domain.Route
-	if rf, ok := ret.Get(0).(func(int) *domain.Route); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int) *domain.Route); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Route)
@@ -82,8 +247,8 @@ func (_m *RouteStorage) RouteByID(id int) (*domain.Route, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -91,25 +256,164 @@ func (_m *RouteStorage) RouteByID(id int) (*domain.Route, error) {
 	return r0, r1
 }
 
-// RoutesByEndPoint provides a mock function with given fields: point
-func (_m *RouteStorage) RoutesByEndPoint(point string) ([]domain.Route, error) {
-	ret := _m.Called(point)
+// RouteTickets provides a mock function with given fields: ctx, routeID
+func (_m *RouteStorage) RouteTickets(ctx context.Context, routeID int) ([]domain.Ticket, error) {
+	ret := _m.Called(ctx, routeID)
+
+	var r0 []domain.Ticket
+	if rf, ok := ret.Get(0).(func(context.Context, int) []domain.Ticket); ok {
+		r0 = rf(ctx, routeID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Ticket)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, routeID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RoutesByQuery provides a mock function with given fields: ctx, q
+func (_m *RouteStorage) RoutesByQuery(ctx context.Context, q domain.RouteQuery) ([]domain.Route, int, error) {
+	ret := _m.Called(ctx, q)
 
 	var r0 []domain.Route
-	if rf, ok := ret.Get(0).(func(string) []domain.Route); ok {
-		r0 = rf(point)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.RouteQuery) []domain.Route); ok {
+		r0 = rf(ctx, q)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]domain.Route)
 		}
 	}
 
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, domain.RouteQuery) int); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, domain.RouteQuery) error); ok {
+		r2 = rf(ctx, q)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ScheduleByID provides a mock function with given fields: ctx, id
+func (_m *RouteStorage) ScheduleByID(ctx context.Context, id int) (*domain.Schedule, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.Schedule
+	if rf, ok := ret.Get(0).(func(context.Context, int) *domain.Schedule); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Schedule)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ScheduledStarts provides a mock function with given fields: ctx, scheduleID, from, to
+func (_m *RouteStorage) ScheduledStarts(ctx context.Context, scheduleID int, from time.Time, to time.Time) ([]time.Time, error) {
+	ret := _m.Called(ctx, scheduleID, from, to)
+
+	var r0 []time.Time
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time, time.Time) []time.Time); ok {
+		r0 = rf(ctx, scheduleID, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]time.Time)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, scheduleID, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SeatStats provides a mock function with given fields: ctx, from
+func (_m *RouteStorage) SeatStats(ctx context.Context, from time.Time) (domain.SeatStats, error) {
+	ret := _m.Called(ctx, from)
+
+	var r0 domain.SeatStats
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) domain.SeatStats); ok {
+		r0 = rf(ctx, from)
+	} else {
+		r0 = ret.Get(0).(domain.SeatStats)
+	}
+
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(point)
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, from)
 	} else {
 		r1 = ret.Error(1)
 	}
 
 	return r0, r1
 }
+
+// SetRouteStatus provides a mock function with given fields: ctx, id, version, status, delay
+func (_m *RouteStorage) SetRouteStatus(ctx context.Context, id int, version int, status string, delay time.Duration) error {
+	ret := _m.Called(ctx, id, version, status, delay)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, string, time.Duration) error); ok {
+		r0 = rf(ctx, id, version, status, delay)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRoute provides a mock function with given fields: ctx, r
+func (_m *RouteStorage) UpdateRoute(ctx context.Context, r *domain.Route) error {
+	ret := _m.Called(ctx, r)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Route) error); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateSchedule provides a mock function with given fields: ctx, s
+func (_m *RouteStorage) UpdateSchedule(ctx context.Context, s *domain.Schedule) error {
+	ret := _m.Called(ctx, s)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Schedule) error); ok {
+		r0 = rf(ctx, s)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
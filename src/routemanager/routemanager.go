@@ -1,77 +1,324 @@
 package routemanager
 
 import (
-	"errors"
+	"context"
 	"time"
 
 	"github.com/JaneKetko/Buses/src/domain"
 )
 
 //RouteStorage - interface for database methods.
+//Methods which change route with expected version fail with precondition error
+//if route has other version, every change increments version of the route.
+//Changes made with context passed to fn of InTransaction are kept only if fn succeeds.
 type RouteStorage interface {
-	GetAllData() ([]domain.Route, error)
-	RouteByID(id int) (*domain.Route, error)
-	DeleteRow(id int) error
-	RoutesByEndPoint(point string) ([]domain.Route, error)
-	AddRoute(*domain.Route) (int, error)
+	InTransaction(ctx context.Context, fn func(context.Context) error) error
+	RouteByID(ctx context.Context, id int) (*domain.Route, error)
+	DeleteRow(ctx context.Context, id, version int) error
+	RoutesByQuery(ctx context.Context, q domain.RouteQuery) ([]domain.Route, int, error)
+	AddRoute(ctx context.Context, r *domain.Route) (int, error)
+	UpdateRoute(ctx context.Context, r *domain.Route) error
+	BookSeat(ctx context.Context, t *domain.Ticket) (int, error)
+	CancelBooking(ctx context.Context, id int) error
+	RouteTickets(ctx context.Context, routeID int) ([]domain.Ticket, error)
+	AddSchedule(ctx context.Context, s *domain.Schedule) (int, error)
+	ScheduleByID(ctx context.Context, id int) (*domain.Schedule, error)
+	GetAllSchedules(ctx context.Context) ([]domain.Schedule, error)
+	UpdateSchedule(ctx context.Context, s *domain.Schedule) error
+	DeleteSchedule(ctx context.Context, id int) error
+	ScheduledStarts(ctx context.Context, scheduleID int, from, to time.Time) ([]time.Time, error)
+	SetRouteStatus(ctx context.Context, id, version int, status string, delay time.Duration) error
+	RestoreRoute(ctx context.Context, id, version int) error
+	ArchiveRoutes(ctx context.Context, before time.Time) (int, error)
+	AddAuditEntry(ctx context.Context, e *domain.AuditEntry) (int, error)
+	AuditEntries(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error)
+	SeatStats(ctx context.Context, from time.Time) (domain.SeatStats, error)
 }
 
+//AnyVersion - version expected by client which matches any current version of route.
+const AnyVersion = 0
+
 //RouteManager - struct for slice of routes.
 type RouteManager struct {
 	storage RouteStorage
+	encode  SnapshotEncoder
 }
 
 //NewRouteManager creates new object of RouteManager struct.
 func NewRouteManager(storage RouteStorage) *RouteManager {
-	return &RouteManager{storage: storage}
+	return &RouteManager{storage: storage, encode: encodeRoute}
+}
+
+//validatePeriod checks date range and departure time of day range of query.
+func validatePeriod(q domain.RouteQuery) error {
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return domain.Invalid("date range is invalid")
+	}
+	if q.DepartAfter < 0 || q.DepartBefore < 0 || q.DepartAfter >= 24*time.Hour ||
+		q.DepartBefore >= 24*time.Hour || (q.DepartBefore > 0 && q.DepartBefore < q.DepartAfter) {
+		return domain.Invalid("time range is invalid")
+	}
+	return nil
 }
 
-//GetAllRoutes gets all routes.
-func (r RouteManager) GetAllRoutes() ([]domain.Route, error) {
-	return r.storage.GetAllData()
+//FindRoutes finds page of routes by query and total number of matched routes.
+func (r RouteManager) FindRoutes(ctx context.Context, q domain.RouteQuery) ([]domain.Route, int, error) {
+	switch q.SortBy {
+	case "", domain.SortByStart, domain.SortByCost, domain.SortByFreeSeats:
+	default:
+		return nil, 0, domain.Invalid("invalid sort key")
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return nil, 0, domain.Invalid("invalid pagination")
+	}
+	err := validatePeriod(q)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = validateStatuses(q.Statuses)
+	if err != nil {
+		return nil, 0, err
+	}
+	return r.storage.RoutesByQuery(ctx, q)
 }
 
 //GetRouteByID gets route by id.
-func (r RouteManager) GetRouteByID(id int) (*domain.Route, error) {
-	return r.storage.RouteByID(id)
+func (r RouteManager) GetRouteByID(ctx context.Context, id int) (*domain.Route, error) {
+	return r.storage.RouteByID(ctx, id)
 }
 
-//CreateNewRoute creates new route in database.
-func (r *RouteManager) CreateNewRoute(route *domain.Route) error {
+//validateCost checks that cost isn't negative and has valid currency.
+func validateCost(cost domain.Money) error {
+	if cost.Amount < 0 {
+		return domain.Invalid("cost is invalid")
+	}
+	if !domain.ValidCurrency(cost.Currency) {
+		return domain.Invalid("currency is invalid")
+	}
+	return nil
+}
+
+//validateRoute checks route data before saving.
+func validateRoute(route *domain.Route) error {
 	if route.Start.Before(time.Now()) {
-		return errors.New("date is invalid")
+		return domain.Invalid("date is invalid")
+	}
+	if route.Duration < 0 {
+		return domain.Invalid("duration is invalid")
+	}
+	if route.AllSeats <= 0 {
+		return domain.Invalid("number of seats is invalid")
+	}
+	err := validateCost(route.Cost)
+	if err != nil {
+		return err
+	}
+	return validateStops(route)
+}
+
+//CreateNewRoute creates new route in database, new route has first version and is scheduled without delay.
+//Free seats of new route can't be more than all its seats, free seats of updated route are counted
+//by stored route instead.
+func (r *RouteManager) CreateNewRoute(ctx context.Context, route *domain.Route) error {
+	err := validateRoute(route)
+	if err != nil {
+		return err
+	}
+	if route.FreeSeats < 0 || route.FreeSeats > route.AllSeats {
+		return domain.Invalid("number of free seats is invalid")
+	}
+	route.Status, route.Delay, route.Version = domain.StatusScheduled, 0, 1
+	for i := range route.Stops {
+		route.Stops[i].FreeSeats = route.FreeSeats
+	}
+	return r.inTransaction(ctx, func(ctx context.Context) error {
+		id, err := r.storage.AddRoute(ctx, route)
+		if err != nil {
+			return err
+		}
+		route.ID = id
+		return r.record(ctx, domain.ActionCreate, id, nil, route)
+	})
+}
+
+//keepSoldSeats sets free seats of updated route by stored route, so seats which were sold stay sold.
+//Free seats change only by difference of all seats, which can't be fewer than sold seats.
+func keepSoldSeats(route, old *domain.Route) error {
+	if route.AllSeats < old.AllSeats-old.FreeSeats {
+		return domain.Conflict("all seats are fewer than sold seats")
+	}
+	route.FreeSeats = old.FreeSeats + route.AllSeats - old.AllSeats
+	return nil
+}
+
+//UpdateRoute replaces data of existing route which is neither cancelled nor departed
+//if the route still has version of new data. Status, delay and schedule of the route are kept,
+//free seats of the route and its stops are counted by stored route and its tickets instead of new data.
+func (r *RouteManager) UpdateRoute(ctx context.Context, route *domain.Route) error {
+	err := validateRoute(route)
+	if err != nil {
+		return err
 	}
-	id, err := r.storage.AddRoute(route)
 
+	old, err := r.storage.RouteByID(ctx, route.ID)
+	if err != nil {
+		return err
+	}
+	err = checkVersion(old, route.Version)
+	if err != nil {
+		return err
+	}
+	err = checkActive(old)
+	if err != nil {
+		return err
+	}
+	err = keepSoldSeats(route, old)
 	if err != nil {
 		return err
 	}
-	route.ID = id
+	if len(route.Stops) != 0 || len(old.Stops) != 0 {
+		tickets, err := r.storage.RouteTickets(ctx, route.ID)
+		if err != nil {
+			return err
+		}
+		err = keepSegmentSeats(route, old, tickets)
+		if err != nil {
+			return err
+		}
+	}
+	route.Status, route.Delay, route.ScheduleID = old.Status, old.Delay, old.ScheduleID
+	route.Version = old.Version
+	return r.inTransaction(ctx, func(ctx context.Context) error {
+		err := r.storage.UpdateRoute(ctx, route)
+		if err != nil {
+			return err
+		}
+		route.Version++
+		return r.record(ctx, domain.ActionUpdate, route.ID, old, route)
+	})
+}
+
+//checkVersion returns precondition error if the route doesn't have version expected by client.
+func checkVersion(route *domain.Route, version int) error {
+	if version != AnyVersion && route.Version != version {
+		return domain.PreconditionFailed("route version doesn't match")
+	}
 	return nil
 }
 
-//DeleteRouteByID deletes route from all routes by id.
-func (r *RouteManager) DeleteRouteByID(id int) error {
-	return r.storage.DeleteRow(id)
+//DeleteRouteByID marks route with version as deleted by id and returns new version of the route,
+//deleted route can be restored with this version until it is archived.
+//AnyVersion deletes route with its current version.
+func (r *RouteManager) DeleteRouteByID(ctx context.Context, id, version int) (int, error) {
+	old, err := r.storage.RouteByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	err = checkVersion(old, version)
+	if err != nil {
+		return 0, err
+	}
+	version = old.Version
+	err = r.inTransaction(ctx, func(ctx context.Context) error {
+		err := r.storage.DeleteRow(ctx, id, version)
+		if err != nil {
+			return err
+		}
+		return r.record(ctx, domain.ActionDelete, id, old, nil)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return version + 1, nil
+}
+
+//RestoreRoute returns deleted route with version back to all routes. Deleted route has
+//no current version, so AnyVersion doesn't match it.
+func (r *RouteManager) RestoreRoute(ctx context.Context, id, version int) (*domain.Route, error) {
+	if version == AnyVersion {
+		return nil, domain.PreconditionFailed("route version doesn't match")
+	}
+	var route *domain.Route
+	err := r.inTransaction(ctx, func(ctx context.Context) error {
+		err := r.storage.RestoreRoute(ctx, id, version)
+		if err != nil {
+			return err
+		}
+		route, err = r.storage.RouteByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return r.record(ctx, domain.ActionRestore, id, nil, route)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return route, nil
 }
 
-//ChooseRoutesByDateAndPoint chooses routes by date and point.
-func (r RouteManager) ChooseRoutesByDateAndPoint(date time.Time, endpoint string) ([]domain.Route, error) {
+//SearchRoutes finds routes by points and departure period ordered by departure time.
+//Cancelled and departed routes are found only if their statuses are requested.
+func (r RouteManager) SearchRoutes(ctx context.Context, q domain.RouteQuery) ([]domain.Route, error) {
+	if q.StartPoint == "" && q.EndPoint == "" {
+		return nil, domain.Invalid("point is empty")
+	}
+	err := validatePeriod(q)
+	if err != nil {
+		return nil, err
+	}
+	err = validateStatuses(q.Statuses)
+	if err != nil {
+		return nil, err
+	}
+	if len(q.Statuses) == 0 {
+		q.Statuses = domain.ActiveStatuses()
+	}
 
-	routes, err := r.storage.RoutesByEndPoint(endpoint)
+	q.SortBy, q.Desc = domain.SortByStart, false
+	q.Limit, q.Offset = 0, 0
+	routes, _, err := r.storage.RoutesByQuery(ctx, q)
 	if err != nil {
 		return nil, err
 	}
+	if routes == nil {
+		routes = []domain.Route{}
+	}
+	return routes, nil
+}
 
-	var routesDate []domain.Route
-	for _, route := range routes {
-		diff := route.Start.Sub(date).Hours()
-		if diff >= 0 && diff < 24 {
-			routesDate = append(routesDate, route)
-		}
+//BookSeat books one seat on the route for passenger between stops of the ticket,
+//empty stops mean the whole route. Booking increments version of the route,
+//so clients changing the route have to send its current version or AnyVersion.
+func (r *RouteManager) BookSeat(ctx context.Context, ticket *domain.Ticket) error {
+	if ticket.Passenger == "" {
+		return domain.Invalid("passenger is empty")
+	}
+	route, err := r.storage.RouteByID(ctx, ticket.RouteID)
+	if err != nil {
+		return err
 	}
-	if len(routesDate) == 0 {
-		return nil, errors.New("no such routes")
+	err = checkActive(route)
+	if err != nil {
+		return err
+	}
+	departure, err := fillTicketStops(ticket, route)
+	if err != nil {
+		return err
+	}
+	if departure.Add(route.Delay).Before(time.Now()) {
+		return domain.Conflict("route has already departed")
 	}
-	return routesDate, nil
+
+	ticket.Booked = time.Now().UTC().Truncate(time.Second)
+	id, err := r.storage.BookSeat(ctx, ticket)
+	if err != nil {
+		return err
+	}
+	ticket.ID = id
+	return markCommitted(ctx, nil)
+}
+
+//CancelBooking cancels booking by ticket id and increments version of its route.
+func (r *RouteManager) CancelBooking(ctx context.Context, id int) error {
+	return markCommitted(ctx, r.storage.CancelBooking(ctx, id))
 }
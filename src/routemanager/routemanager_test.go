@@ -1,6 +1,7 @@
 package routemanager
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -8,41 +9,39 @@ import (
 	"github.com/JaneKetko/Buses/src/domain"
 	"github.com/JaneKetko/Buses/src/routemanager/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
-func TestRoutesByEndPoint(t *testing.T) {
+//runInTransaction makes mock storage call functions passed to InTransaction.
+func runInTransaction(routestrg *mocks.RouteStorage) {
+	routestrg.On("InTransaction", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+}
+
+func TestSearchRoutes(t *testing.T) {
 	routes := []domain.Route{
 		{
 			ID: 1,
-			Points: domain.Points{
-				StartPoint: "Vitebsk",
-				EndPoint:   "Minsk",
-			},
-			Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
-			FreeSeats: 12,
-			AllSeats:  13,
-		},
-		{
-			ID: 2,
 			Points: domain.Points{
 				StartPoint: "Grodno",
 				EndPoint:   "Minsk",
 			},
 			Start:     time.Date(2019, 04, 12, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
 		{
-			ID: 3,
+			ID: 2,
 			Points: domain.Points{
-				StartPoint: "Pinsk",
-				EndPoint:   "Mir",
+				StartPoint: "Vitebsk",
+				EndPoint:   "Minsk",
 			},
-			Start:     time.Date(2019, 04, 10, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Start:     time.Date(2019, 04, 13, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
@@ -53,50 +52,82 @@ func TestRoutesByEndPoint(t *testing.T) {
 
 	testCases := []struct {
 		name           string
-		date           time.Time
-		endPoint       string
+		query          domain.RouteQuery
+		storageQuery   domain.RouteQuery
 		expectedRoutes []domain.Route
 		expectedError  error
 		expTotalRoutes []domain.Route
 		expTotalError  error
 	}{
 		{
-			name:           "successful test",
-			date:           time.Date(2019, 04, 12, 10, 0, 0, 0, time.UTC),
-			endPoint:       "Minsk",
-			expectedRoutes: routes[:2],
-			expectedError:  nil,
-			expTotalRoutes: routes[1:2],
-			expTotalError:  nil,
+			name: "successful test",
+			query: domain.RouteQuery{
+				EndPoint: "Minsk",
+				From:     time.Date(2019, 04, 12, 0, 0, 0, 0, time.UTC),
+				To:       time.Date(2019, 04, 14, 0, 0, 0, 0, time.UTC),
+				Limit:    1,
+			},
+			storageQuery: domain.RouteQuery{
+				EndPoint: "Minsk",
+				From:     time.Date(2019, 04, 12, 0, 0, 0, 0, time.UTC),
+				To:       time.Date(2019, 04, 14, 0, 0, 0, 0, time.UTC),
+				Statuses: domain.ActiveStatuses(),
+				SortBy:   domain.SortByStart,
+			},
+			expectedRoutes: routes,
+			expTotalRoutes: routes,
 		},
 		{
-			name:           "no routes by endpoint",
-			date:           time.Date(2019, 04, 10, 10, 0, 0, 0, time.UTC),
-			endPoint:       "Grodno",
+			name:  "no routes",
+			query: domain.RouteQuery{StartPoint: "Mir", DepartAfter: 8 * time.Hour},
+			storageQuery: domain.RouteQuery{StartPoint: "Mir", DepartAfter: 8 * time.Hour,
+				Statuses: domain.ActiveStatuses(), SortBy: domain.SortByStart},
 			expectedRoutes: nil,
-			expectedError:  errors.New("no such routes by this endpoint"),
-			expTotalRoutes: nil,
-			expTotalError:  errors.New("no such routes by this endpoint"),
+			expTotalRoutes: []domain.Route{},
+		},
+		{
+			name:  "storage error",
+			query: domain.RouteQuery{StartPoint: "Lida", Statuses: []string{domain.StatusCancelled}},
+			storageQuery: domain.RouteQuery{StartPoint: "Lida", Statuses: []string{domain.StatusCancelled},
+				SortBy: domain.SortByStart},
+			expectedError: domain.Unavailable("data hasn't read"),
+			expTotalError: domain.Unavailable("data hasn't read"),
+		},
+		{
+			name:          "no point",
+			query:         domain.RouteQuery{},
+			expTotalError: domain.Invalid("point is empty"),
+		},
+		{
+			name: "invalid date range",
+			query: domain.RouteQuery{
+				EndPoint: "Minsk",
+				From:     time.Date(2019, 04, 14, 0, 0, 0, 0, time.UTC),
+				To:       time.Date(2019, 04, 12, 0, 0, 0, 0, time.UTC),
+			},
+			expTotalError: domain.Invalid("date range is invalid"),
 		},
 		{
-			name:           "no routes by date",
-			date:           time.Date(2022, 04, 10, 10, 0, 0, 0, time.UTC),
-			endPoint:       "Mir",
-			expectedRoutes: routes[2:],
-			expectedError:  nil,
-			expTotalRoutes: nil,
-			expTotalError:  errors.New("no such routes"),
+			name:          "invalid time range",
+			query:         domain.RouteQuery{EndPoint: "Minsk", DepartAfter: 10 * time.Hour, DepartBefore: 8 * time.Hour},
+			expTotalError: domain.Invalid("time range is invalid"),
+		},
+		{
+			name:          "invalid status",
+			query:         domain.RouteQuery{EndPoint: "Minsk", Statuses: []string{"lost"}},
+			expTotalError: domain.Invalid(`invalid status "lost"`),
 		},
 	}
 
 	for _, tc := range testCases {
-		routestrg.On("RoutesByEndPoint", tc.endPoint).Return(tc.expectedRoutes, tc.expectedError)
+		routestrg.On("RoutesByQuery", mock.Anything, tc.storageQuery).Return(tc.expectedRoutes, len(tc.expectedRoutes),
+			tc.expectedError)
 	}
 
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			rt, err := routeman.ChooseRoutesByDateAndPoint(tc.date, tc.endPoint)
+			rt, err := routeman.SearchRoutes(context.Background(), tc.query)
 			require.Equal(t, tc.expTotalError, err)
 			assert.Equal(t, tc.expTotalRoutes, rt)
 		})
@@ -106,6 +137,8 @@ func TestRoutesByEndPoint(t *testing.T) {
 func TestCreateNewRoute(t *testing.T) {
 	var routestrg mocks.RouteStorage
 	routeman := NewRouteManager(&routestrg)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
 
 	routes := []domain.Route{
 		{
@@ -114,7 +147,7 @@ func TestCreateNewRoute(t *testing.T) {
 				EndPoint:   "Minsk",
 			},
 			Start:     time.Date(2002, 04, 23, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
@@ -123,8 +156,8 @@ func TestCreateNewRoute(t *testing.T) {
 				StartPoint: "Grodno",
 				EndPoint:   "Minsk",
 			},
-			Start:     time.Date(2020, 04, 12, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Start:     time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
@@ -133,12 +166,18 @@ func TestCreateNewRoute(t *testing.T) {
 				StartPoint: "Grodno",
 				EndPoint:   "Mir",
 			},
-			Start:     time.Date(2021, 04, 12, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Start:     time.Date(time.Now().Year()+2, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
 	}
+	overbooked := routes[2]
+	overbooked.FreeSeats = 14
+	negative := routes[2]
+	negative.FreeSeats = -1
+	noSeats := routes[2]
+	noSeats.FreeSeats, noSeats.AllSeats = 0, 0
 
 	testCases := []struct {
 		name          string
@@ -152,7 +191,22 @@ func TestCreateNewRoute(t *testing.T) {
 			route:         &routes[0],
 			expectedID:    1,
 			expectedError: nil,
-			expTotalError: errors.New("date is invalid"),
+			expTotalError: domain.Invalid("date is invalid"),
+		},
+		{
+			name:          "more free seats than all seats",
+			route:         &overbooked,
+			expTotalError: domain.Invalid("number of free seats is invalid"),
+		},
+		{
+			name:          "negative free seats",
+			route:         &negative,
+			expTotalError: domain.Invalid("number of free seats is invalid"),
+		},
+		{
+			name:          "no seats",
+			route:         &noSeats,
+			expTotalError: domain.Invalid("number of seats is invalid"),
 		},
 		{
 			name:          "errors",
@@ -171,7 +225,7 @@ func TestCreateNewRoute(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		routestrg.On("AddRoute",
+		routestrg.On("AddRoute", mock.Anything,
 			tc.route).
 			Return(tc.expectedID, tc.expectedError)
 	}
@@ -179,13 +233,13 @@ func TestCreateNewRoute(t *testing.T) {
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			err := routeman.CreateNewRoute(tc.route)
+			err := routeman.CreateNewRoute(context.Background(), tc.route)
 			require.Equal(t, tc.expTotalError, err)
 		})
 	}
 }
 
-func TestGetAllRoutes(t *testing.T) {
+func TestGetRouteByID(t *testing.T) {
 	routes := []domain.Route{
 		{
 			ID: 1,
@@ -194,7 +248,7 @@ func TestGetAllRoutes(t *testing.T) {
 				EndPoint:   "Minsk",
 			},
 			Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
@@ -203,32 +257,159 @@ func TestGetAllRoutes(t *testing.T) {
 	routeman := NewRouteManager(&routestrg)
 
 	testCases := []struct {
-		name           string
-		expectedRoutes []domain.Route
-		expectedError  error
+		name          string
+		routeID       int
+		expectedRoute *domain.Route
+		expectedError error
 	}{
 		{
-			name:           "successful test",
-			expectedRoutes: routes,
-			expectedError:  nil,
+			name:          "successful test",
+			routeID:       1,
+			expectedRoute: &routes[0],
+			expectedError: nil,
+		},
+		{
+			name:          "no route",
+			routeID:       2,
+			expectedRoute: nil,
+			expectedError: domain.NotFound("no such route"),
 		},
 	}
 
 	for _, tc := range testCases {
-		routestrg.On("GetAllData").Return(tc.expectedRoutes, tc.expectedError)
+		routestrg.On("RouteByID", mock.Anything, tc.routeID).Return(tc.expectedRoute, tc.expectedError)
 	}
 
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			rt, err := routeman.GetAllRoutes()
+			rt, err := routeman.GetRouteByID(context.Background(), tc.routeID)
 			require.Equal(t, tc.expectedError, err)
-			assert.Equal(t, tc.expectedRoutes, rt)
+			assert.Equal(t, tc.expectedRoute, rt)
 		})
 	}
 }
 
-func TestGetRouteByID(t *testing.T) {
+func TestDeleteRouteByID(t *testing.T) {
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+	routestrg.On("RouteByID", mock.Anything, 1).Return(&domain.Route{ID: 1, Version: 1}, nil)
+	routestrg.On("RouteByID", mock.Anything, 2).Return(nil, domain.NotFound("no such route"))
+	routestrg.On("RouteByID", mock.Anything, 3).Return(&domain.Route{ID: 3, Version: 4}, nil)
+	routestrg.On("RouteByID", mock.Anything, 4).Return(&domain.Route{ID: 4, Version: 5}, nil)
+	routestrg.On("DeleteRow", mock.Anything, 1, 1).Return(nil)
+	routestrg.On("DeleteRow", mock.Anything, 3, 4).Return(domain.PreconditionFailed("route was changed"))
+	routestrg.On("DeleteRow", mock.Anything, 4, 5).Return(nil)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+
+	testCases := []struct {
+		name            string
+		routeID         int
+		version         int
+		expectedVersion int
+		expectedError   error
+	}{
+		{
+			name:            "successful test",
+			routeID:         1,
+			version:         1,
+			expectedVersion: 2,
+			expectedError:   nil,
+		},
+		{
+			name:          "no route",
+			routeID:       2,
+			version:       1,
+			expectedError: domain.NotFound("no such route"),
+		},
+		{
+			name:          "other version",
+			routeID:       1,
+			version:       2,
+			expectedError: domain.PreconditionFailed("route version doesn't match"),
+		},
+		{
+			name:          "changed in storage",
+			routeID:       3,
+			version:       4,
+			expectedError: domain.PreconditionFailed("route was changed"),
+		},
+		{
+			name:            "any version",
+			routeID:         4,
+			version:         AnyVersion,
+			expectedVersion: 6,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			version, err := routeman.DeleteRouteByID(context.Background(), tc.routeID, tc.version)
+			require.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedVersion, version)
+		})
+	}
+}
+
+func TestRestoreRoute(t *testing.T) {
+	route := domain.Route{
+		ID:        1,
+		Points:    domain.Points{StartPoint: "Vitebsk", EndPoint: "Minsk"},
+		Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+		Status:    domain.StatusScheduled,
+		Version:   3,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+	routestrg.On("RestoreRoute", mock.Anything, 1, 2).Return(nil)
+	routestrg.On("RestoreRoute", mock.Anything, 2, 2).Return(domain.NotFound("no such deleted route"))
+	routestrg.On("RouteByID", mock.Anything, 1).Return(&route, nil)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+
+	testCases := []struct {
+		name          string
+		routeID       int
+		version       int
+		expectedRoute *domain.Route
+		expectedError error
+	}{
+		{
+			name:          "successful test",
+			routeID:       1,
+			version:       2,
+			expectedRoute: &route,
+		},
+		{
+			name:          "no deleted route",
+			routeID:       2,
+			version:       2,
+			expectedError: domain.NotFound("no such deleted route"),
+		},
+		{
+			name:          "any version",
+			routeID:       1,
+			version:       AnyVersion,
+			expectedError: domain.PreconditionFailed("route version doesn't match"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rt, err := routeman.RestoreRoute(context.Background(), tc.routeID, tc.version)
+			require.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRoute, rt)
+		})
+	}
+}
+
+func TestBookSeat(t *testing.T) {
 	routes := []domain.Route{
 		{
 			ID: 1,
@@ -237,78 +418,353 @@ func TestGetRouteByID(t *testing.T) {
 				EndPoint:   "Minsk",
 			},
 			Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
+		{
+			ID: 2,
+			Points: domain.Points{
+				StartPoint: "Grodno",
+				EndPoint:   "Minsk",
+			},
+			Start:     time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 0,
+			AllSeats:  13,
+		},
+		{
+			ID: 3,
+			Points: domain.Points{
+				StartPoint: "Grodno",
+				EndPoint:   "Mir",
+			},
+			Start:     time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+			AllSeats:  13,
+		},
+		{
+			ID:        7,
+			Points:    domain.Points{StartPoint: "Grodno", EndPoint: "Mir"},
+			Start:     time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+			AllSeats:  13,
+			Status:    domain.StatusCancelled,
+		},
+		{
+			ID:        8,
+			Points:    domain.Points{StartPoint: "Grodno", EndPoint: "Mir"},
+			Start:     time.Now().Add(-10 * time.Minute),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+			AllSeats:  13,
+			Status:    domain.StatusDelayed,
+			Delay:     time.Hour,
+		},
 	}
 	var routestrg mocks.RouteStorage
 	routeman := NewRouteManager(&routestrg)
 
 	testCases := []struct {
 		name          string
-		routeID       int
-		expectedRoute *domain.Route
+		ticket        *domain.Ticket
+		route         *domain.Route
+		routeError    error
+		expectedID    int
+		expectedError error
+		expTotalError error
+	}{
+		{
+			name:          "empty passenger",
+			ticket:        &domain.Ticket{RouteID: 6},
+			expTotalError: domain.Invalid("passenger is empty"),
+		},
+		{
+			name:          "no route",
+			ticket:        &domain.Ticket{RouteID: 4, Passenger: "Ivanov"},
+			routeError:    domain.NotFound("no such route"),
+			expTotalError: domain.NotFound("no such route"),
+		},
+		{
+			name:          "departed route",
+			ticket:        &domain.Ticket{RouteID: 1, Passenger: "Ivanov"},
+			route:         &routes[0],
+			expTotalError: domain.Conflict("route has already departed"),
+		},
+		{
+			name:          "no free seats",
+			ticket:        &domain.Ticket{RouteID: 2, Passenger: "Ivanov"},
+			route:         &routes[1],
+			expectedError: domain.Conflict("no free seats"),
+			expTotalError: domain.Conflict("no free seats"),
+		},
+		{
+			name:          "successful test",
+			ticket:        &domain.Ticket{RouteID: 3, Passenger: "Ivanov"},
+			route:         &routes[2],
+			expectedID:    5,
+			expTotalError: nil,
+		},
+		{
+			name:          "cancelled route",
+			ticket:        &domain.Ticket{RouteID: 7, Passenger: "Ivanov"},
+			route:         &routes[3],
+			expTotalError: domain.Conflict("route is cancelled"),
+		},
+		{
+			name:       "delayed route",
+			ticket:     &domain.Ticket{RouteID: 8, Passenger: "Ivanov"},
+			route:      &routes[4],
+			expectedID: 6,
+		},
+	}
+
+	for _, tc := range testCases {
+		routestrg.On("RouteByID", mock.Anything, tc.ticket.RouteID).Return(tc.route, tc.routeError)
+		routestrg.On("BookSeat", mock.Anything, tc.ticket).Return(tc.expectedID, tc.expectedError)
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := routeman.BookSeat(context.Background(), tc.ticket)
+			require.Equal(t, tc.expTotalError, err)
+			assert.Equal(t, tc.expectedID, tc.ticket.ID)
+		})
+	}
+}
+
+func TestCancelBooking(t *testing.T) {
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+
+	testCases := []struct {
+		name          string
+		ticketID      int
 		expectedError error
 	}{
 		{
 			name:          "successful test",
-			routeID:       1,
-			expectedRoute: &routes[0],
+			ticketID:      1,
 			expectedError: nil,
 		},
 		{
-			name:          "no route",
-			routeID:       2,
-			expectedRoute: nil,
-			expectedError: errors.New("no such route"),
+			name:          "no ticket",
+			ticketID:      2,
+			expectedError: domain.NotFound("no such ticket"),
 		},
 	}
 
 	for _, tc := range testCases {
-		routestrg.On("RouteByID", tc.routeID).Return(tc.expectedRoute, tc.expectedError)
+		routestrg.On("CancelBooking", mock.Anything, tc.ticketID).Return(tc.expectedError)
 	}
 
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			rt, err := routeman.GetRouteByID(tc.routeID)
+			err := routeman.CancelBooking(context.Background(), tc.ticketID)
 			require.Equal(t, tc.expectedError, err)
-			assert.Equal(t, tc.expectedRoute, rt)
 		})
 	}
 }
 
-func TestDeleteRouteByID(t *testing.T) {
+func TestUpdateRoute(t *testing.T) {
 	var routestrg mocks.RouteStorage
 	routeman := NewRouteManager(&routestrg)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+
+	routes := []domain.Route{
+		{
+			ID: 1,
+			Points: domain.Points{
+				StartPoint: "Vitebsk",
+				EndPoint:   "Minsk",
+			},
+			Start:     time.Date(2002, 04, 23, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+			AllSeats:  13,
+		},
+		{
+			ID: 2,
+			Points: domain.Points{
+				StartPoint: "Grodno",
+				EndPoint:   "Minsk",
+			},
+			Start:     time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+			AllSeats:  13,
+		},
+		{
+			ID: 3,
+			Points: domain.Points{
+				StartPoint: "Grodno",
+				EndPoint:   "Mir",
+			},
+			Start:     time.Date(time.Now().Year()+2, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+			AllSeats:  13,
+		},
+	}
+
+	routes[2].Version = 3
+	stored := routes[2]
+	stored.Status, stored.Delay, stored.ScheduleID = domain.StatusDelayed, 15*time.Minute, 7
+	cancelled := routes[1]
+	cancelled.ID, cancelled.Status = 4, domain.StatusCancelled
+	stale := routes[2]
+	stale.ID = 5
+	changed := stale
+	changed.Version = 4
+	anyVersion := routes[2]
+	anyVersion.ID, anyVersion.Version = 6, AnyVersion
+	storedAny := changed
+	storedAny.ID = 6
 
 	testCases := []struct {
 		name          string
-		routeID       int
+		route         *domain.Route
+		storedRoute   *domain.Route
+		storedError   error
 		expectedError error
+		expTotalError error
 	}{
+		{
+			name:          "invalid date",
+			route:         &routes[0],
+			expTotalError: domain.Invalid("date is invalid"),
+		},
+		{
+			name:          "no route",
+			route:         &routes[1],
+			storedError:   domain.NotFound("no such route"),
+			expTotalError: domain.NotFound("no such route"),
+		},
+		{
+			name:          "cancelled route",
+			route:         &cancelled,
+			storedRoute:   &cancelled,
+			expTotalError: domain.Conflict("route is cancelled"),
+		},
+		{
+			name:          "other version",
+			route:         &stale,
+			storedRoute:   &changed,
+			expTotalError: domain.PreconditionFailed("route version doesn't match"),
+		},
 		{
 			name:          "successful test",
-			routeID:       1,
+			route:         &routes[2],
+			storedRoute:   &stored,
 			expectedError: nil,
+			expTotalError: nil,
 		},
 		{
-			name:          "no route",
-			routeID:       2,
-			expectedError: errors.New("no such route"),
+			name:        "any version",
+			route:       &anyVersion,
+			storedRoute: &storedAny,
 		},
 	}
 
 	for _, tc := range testCases {
-		routestrg.On("DeleteRow", tc.routeID).Return(tc.expectedError)
+		routestrg.On("RouteByID", mock.Anything, tc.route.ID).Return(tc.storedRoute, tc.storedError)
+		routestrg.On("UpdateRoute", mock.Anything, tc.route).Return(tc.expectedError)
 	}
 
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			err := routeman.DeleteRouteByID(tc.routeID)
-			require.Equal(t, tc.expectedError, err)
+			err := routeman.UpdateRoute(context.Background(), tc.route)
+			require.Equal(t, tc.expTotalError, err)
+		})
+	}
+	assert.Equal(t, domain.StatusDelayed, routes[2].Status)
+	assert.Equal(t, 15*time.Minute, routes[2].Delay)
+	assert.Equal(t, 5, anyVersion.Version)
+	assert.Equal(t, 7, routes[2].ScheduleID)
+	assert.Equal(t, 4, routes[2].Version)
+}
+
+func TestFindRoutes(t *testing.T) {
+	routes := []domain.Route{
+		{
+			ID: 1,
+			Points: domain.Points{
+				StartPoint: "Vitebsk",
+				EndPoint:   "Minsk",
+			},
+			Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+			AllSeats:  13,
+		},
+	}
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+
+	testCases := []struct {
+		name           string
+		query          domain.RouteQuery
+		expectedRoutes []domain.Route
+		expectedTotal  int
+		expectedError  error
+		expTotalError  error
+	}{
+		{
+			name:           "successful test",
+			query:          domain.RouteQuery{EndPoint: "Minsk", SortBy: domain.SortByCost, Limit: 1},
+			expectedRoutes: routes,
+			expectedTotal:  3,
+		},
+		{
+			name:          "invalid sort key",
+			query:         domain.RouteQuery{SortBy: "id"},
+			expTotalError: domain.Invalid("invalid sort key"),
+		},
+		{
+			name:          "invalid pagination",
+			query:         domain.RouteQuery{Offset: -1},
+			expTotalError: domain.Invalid("invalid pagination"),
+		},
+		{
+			name:          "invalid status",
+			query:         domain.RouteQuery{Statuses: []string{domain.StatusScheduled, "lost"}},
+			expTotalError: domain.Invalid(`invalid status "lost"`),
+		},
+		{
+			name: "invalid date range",
+			query: domain.RouteQuery{
+				From: time.Date(2019, 04, 23, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2019, 04, 20, 0, 0, 0, 0, time.UTC),
+			},
+			expTotalError: domain.Invalid("date range is invalid"),
+		},
+		{
+			name:          "storage error",
+			query:         domain.RouteQuery{EndPoint: "Mir"},
+			expectedError: domain.Unavailable("data hasn't read"),
+			expTotalError: domain.Unavailable("data hasn't read"),
+		},
+	}
+
+	for _, tc := range testCases {
+		routestrg.On("RoutesByQuery", mock.Anything, tc.query).Return(tc.expectedRoutes, tc.expectedTotal, tc.expectedError)
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rt, total, err := routeman.FindRoutes(context.Background(), tc.query)
+			require.Equal(t, tc.expTotalError, err)
+			if err == nil {
+				assert.Equal(t, tc.expectedRoutes, rt)
+				assert.Equal(t, tc.expectedTotal, total)
+			}
 		})
 	}
 }
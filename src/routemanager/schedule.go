@@ -0,0 +1,160 @@
+package routemanager
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//validateSchedule checks schedule data before saving.
+func validateSchedule(s *domain.Schedule) error {
+	if s.Points.StartPoint == "" || s.Points.EndPoint == "" {
+		return domain.Invalid("point is empty")
+	}
+	if s.Departure < 0 || s.Departure >= 24*time.Hour {
+		return domain.Invalid("departure is invalid")
+	}
+	if len(s.Weekdays) == 0 {
+		return domain.Invalid("weekdays are empty")
+	}
+	for _, weekday := range s.Weekdays {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return domain.Invalid("weekdays are invalid")
+		}
+	}
+	if s.ValidFrom.IsZero() || s.ValidTo.Before(s.ValidFrom) {
+		return domain.Invalid("validity period is invalid")
+	}
+	if s.AllSeats <= 0 || s.Duration < 0 {
+		return domain.Invalid("schedule data is invalid")
+	}
+	return validateCost(s.Cost)
+}
+
+//CreateSchedule creates new schedule in database.
+func (r *RouteManager) CreateSchedule(ctx context.Context, s *domain.Schedule) error {
+	err := validateSchedule(s)
+	if err != nil {
+		return err
+	}
+	id, err := r.storage.AddSchedule(ctx, s)
+	if err != nil {
+		return err
+	}
+	s.ID = id
+	return markCommitted(ctx, nil)
+}
+
+//GetAllSchedules gets all schedules.
+func (r RouteManager) GetAllSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	return r.storage.GetAllSchedules(ctx)
+}
+
+//GetScheduleByID gets schedule by id.
+func (r RouteManager) GetScheduleByID(ctx context.Context, id int) (*domain.Schedule, error) {
+	return r.storage.ScheduleByID(ctx, id)
+}
+
+//UpdateSchedule replaces data of existing schedule. Trips which were already generated
+//aren't changed.
+func (r *RouteManager) UpdateSchedule(ctx context.Context, s *domain.Schedule) error {
+	err := validateSchedule(s)
+	if err != nil {
+		return err
+	}
+	return markCommitted(ctx, r.storage.UpdateSchedule(ctx, s))
+}
+
+//DeleteScheduleByID deletes schedule by id. Trips which were already generated aren't deleted.
+func (r *RouteManager) DeleteScheduleByID(ctx context.Context, id int) error {
+	return markCommitted(ctx, r.storage.DeleteSchedule(ctx, id))
+}
+
+//generateSchedule creates trips of the schedule which start after now and before end
+//and don't exist yet. Every trip is recorded in audit log in transaction of its creation.
+func (r *RouteManager) generateSchedule(ctx context.Context, s domain.Schedule, now, end time.Time) (int, error) {
+	starts, err := r.storage.ScheduledStarts(ctx, s.ID, now, end)
+	if err != nil {
+		return 0, err
+	}
+	exist := make(map[time.Time]bool, len(starts))
+	for _, start := range starts {
+		exist[start] = true
+	}
+
+	var created int
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		trip := s.Trip(day)
+		if !s.RunsOn(day) || exist[trip.Start] || !trip.Start.After(now) || !trip.Start.Before(end) {
+			continue
+		}
+		trip.Version = 1
+		err = r.inTransaction(ctx, func(ctx context.Context) error {
+			id, err := r.storage.AddRoute(ctx, &trip)
+			if err != nil {
+				return err
+			}
+			trip.ID = id
+			return r.record(ctx, domain.ActionCreate, id, nil, &trip)
+		})
+		if domain.ErrorKind(err) == domain.ErrConflict {
+			//the trip was generated concurrently after its schedule was read
+			continue
+		}
+		if err != nil {
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
+
+//GenerateTrips creates routes of all schedules which start during horizon from now.
+//Trips which already exist are skipped, so generation can be rerun safely.
+func (r *RouteManager) GenerateTrips(ctx context.Context, now time.Time, horizon time.Duration) (int, error) {
+	if horizon <= 0 {
+		return 0, domain.Invalid("horizon is invalid")
+	}
+	schedules, err := r.storage.GetAllSchedules(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now = now.UTC().Truncate(time.Second)
+	var created int
+	for _, s := range schedules {
+		n, err := r.generateSchedule(ctx, s, now, now.Add(horizon))
+		created += n
+		if err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+//StartGenerator generates trips of schedules for rolling horizon every interval until ctx is done.
+//Every generation has to finish during interval, trips are recorded in audit log on behalf of Generator.
+func (r *RouteManager) StartGenerator(ctx context.Context, horizon, interval time.Duration) {
+	ctx = WithActor(ctx, Generator)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, interval)
+		created, err := r.GenerateTrips(runCtx, time.Now(), horizon)
+		cancel()
+		if err != nil {
+			log.Println(err)
+		}
+		if created > 0 {
+			log.Printf("%d trips were generated by schedules\n", created)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package routemanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testSchedule() domain.Schedule {
+	return domain.Schedule{
+		ID: 1,
+		Points: domain.Points{
+			StartPoint: "Minsk",
+			EndPoint:   "Grodno",
+		},
+		Departure:  9 * time.Hour,
+		Weekdays:   []time.Weekday{time.Monday, time.Wednesday},
+		ValidFrom:  time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:    time.Date(2019, 5, 31, 0, 0, 0, 0, time.UTC),
+		Exceptions: []time.Time{time.Date(2019, 5, 8, 0, 0, 0, 0, time.UTC)},
+		Cost:       domain.Money{Amount: 1500, Currency: "BYN"},
+		AllSeats:   40,
+		Duration:   4 * time.Hour,
+	}
+}
+
+func TestCreateSchedule(t *testing.T) {
+	testCases := []struct {
+		name          string
+		change        func(s *domain.Schedule)
+		expectedError error
+	}{
+		{
+			name:   "successful test",
+			change: func(s *domain.Schedule) {},
+		},
+		{
+			name:          "empty point",
+			change:        func(s *domain.Schedule) { s.Points.EndPoint = "" },
+			expectedError: domain.Invalid("point is empty"),
+		},
+		{
+			name:          "wrong departure",
+			change:        func(s *domain.Schedule) { s.Departure = 25 * time.Hour },
+			expectedError: domain.Invalid("departure is invalid"),
+		},
+		{
+			name:          "no weekdays",
+			change:        func(s *domain.Schedule) { s.Weekdays = nil },
+			expectedError: domain.Invalid("weekdays are empty"),
+		},
+		{
+			name:          "wrong weekday",
+			change:        func(s *domain.Schedule) { s.Weekdays = []time.Weekday{7} },
+			expectedError: domain.Invalid("weekdays are invalid"),
+		},
+		{
+			name:          "wrong period",
+			change:        func(s *domain.Schedule) { s.ValidTo = s.ValidFrom.AddDate(0, 0, -1) },
+			expectedError: domain.Invalid("validity period is invalid"),
+		},
+		{
+			name:          "no seats",
+			change:        func(s *domain.Schedule) { s.AllSeats = 0 },
+			expectedError: domain.Invalid("schedule data is invalid"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var routestrg mocks.RouteStorage
+			rm := NewRouteManager(&routestrg)
+			s := testSchedule()
+			s.ID = 0
+			tc.change(&s)
+			routestrg.On("AddSchedule", mock.Anything, &s).Return(3, nil)
+
+			err := rm.CreateSchedule(context.Background(), &s)
+			assert.Equal(t, tc.expectedError, err)
+			if tc.expectedError == nil {
+				assert.Equal(t, 3, s.ID)
+			}
+		})
+	}
+}
+
+func TestScheduleRunsOn(t *testing.T) {
+	s := testSchedule()
+	testCases := []struct {
+		name     string
+		day      time.Time
+		expected bool
+	}{
+		{name: "monday", day: time.Date(2019, 5, 6, 0, 0, 0, 0, time.UTC), expected: true},
+		{name: "time of day is ignored", day: time.Date(2019, 5, 15, 18, 30, 0, 0, time.UTC), expected: true},
+		{name: "tuesday", day: time.Date(2019, 5, 7, 0, 0, 0, 0, time.UTC)},
+		{name: "exception", day: time.Date(2019, 5, 8, 0, 0, 0, 0, time.UTC)},
+		{name: "before period", day: time.Date(2019, 4, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "after period", day: time.Date(2019, 6, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, s.RunsOn(tc.day))
+		})
+	}
+}
+
+func TestGenerateTrips(t *testing.T) {
+	s := testSchedule()
+	now := time.Date(2019, 5, 6, 10, 0, 0, 0, time.UTC)
+	end := now.Add(7 * 24 * time.Hour)
+
+	t.Run("departed and cancelled trips aren't created", func(t *testing.T) {
+		var routestrg mocks.RouteStorage
+		rm := NewRouteManager(&routestrg)
+		routestrg.On("GetAllSchedules", mock.Anything).Return([]domain.Schedule{s}, nil)
+		routestrg.On("ScheduledStarts", mock.Anything, 1, now, end).
+			Return([]time.Time{time.Date(2019, 5, 13, 9, 0, 0, 0, time.UTC)}, nil)
+
+		n, err := rm.GenerateTrips(context.Background(), now, end.Sub(now))
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+		routestrg.AssertNotCalled(t, "AddRoute", mock.Anything, mock.Anything)
+	})
+
+	t.Run("missing trips are created", func(t *testing.T) {
+		var routestrg mocks.RouteStorage
+		rm := NewRouteManager(&routestrg)
+		routestrg.On("GetAllSchedules", mock.Anything).Return([]domain.Schedule{s}, nil)
+		routestrg.On("ScheduledStarts", mock.Anything, 1, now, end.Add(7*24*time.Hour)).
+			Return([]time.Time{time.Date(2019, 5, 13, 9, 0, 0, 0, time.UTC)}, nil)
+		var created []time.Time
+		routestrg.On("AddRoute", mock.Anything, mock.AnythingOfType("*domain.Route")).Return(10, nil).
+			Run(func(args mock.Arguments) {
+				route := args.Get(1).(*domain.Route)
+				assert.Equal(t, 1, route.ScheduleID)
+				assert.Equal(t, 40, route.FreeSeats)
+				created = append(created, route.Start)
+			})
+		runInTransaction(&routestrg)
+		var entries []domain.AuditEntry
+		routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil).Run(func(args mock.Arguments) {
+			entries = append(entries, *args.Get(1).(*domain.AuditEntry))
+		})
+
+		n, err := rm.GenerateTrips(WithActor(context.Background(), Generator), now, 14*24*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []time.Time{
+			time.Date(2019, 5, 15, 9, 0, 0, 0, time.UTC),
+			time.Date(2019, 5, 20, 9, 0, 0, 0, time.UTC),
+		}, created)
+		require.Len(t, entries, 2)
+		for _, e := range entries {
+			assert.Equal(t, 10, e.RouteID)
+			assert.Equal(t, domain.ActionCreate, e.Action)
+			assert.Equal(t, Generator, e.Actor)
+			assert.NotNil(t, e.After)
+		}
+	})
+
+	t.Run("concurrently generated trips are skipped", func(t *testing.T) {
+		var routestrg mocks.RouteStorage
+		rm := NewRouteManager(&routestrg)
+		routestrg.On("GetAllSchedules", mock.Anything).Return([]domain.Schedule{s}, nil)
+		routestrg.On("ScheduledStarts", mock.Anything, 1, now, end.Add(7*24*time.Hour)).
+			Return([]time.Time{time.Date(2019, 5, 13, 9, 0, 0, 0, time.UTC)}, nil)
+		routestrg.On("AddRoute", mock.Anything, mock.AnythingOfType("*domain.Route")).
+			Return(0, domain.Conflict("schedule already has route with such start")).Once()
+		routestrg.On("AddRoute", mock.Anything, mock.AnythingOfType("*domain.Route")).Return(11, nil).Once()
+		runInTransaction(&routestrg)
+		routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil).Once()
+
+		n, err := rm.GenerateTrips(context.Background(), now, 14*24*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		routestrg.AssertExpectations(t)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		var routestrg mocks.RouteStorage
+		rm := NewRouteManager(&routestrg)
+		routestrg.On("GetAllSchedules", mock.Anything).Return(nil, domain.Unavailable("data hasn't read"))
+
+		_, err := rm.GenerateTrips(context.Background(), now, 24*time.Hour)
+		assert.Equal(t, domain.Unavailable("data hasn't read"), err)
+	})
+
+	t.Run("invalid horizon", func(t *testing.T) {
+		var routestrg mocks.RouteStorage
+		rm := NewRouteManager(&routestrg)
+		_, err := rm.GenerateTrips(context.Background(), now, 0)
+		assert.Equal(t, domain.Invalid("horizon is invalid"), err)
+	})
+}
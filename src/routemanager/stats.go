@@ -0,0 +1,14 @@
+package routemanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//UpcomingSeats counts routes which start after now and haven't departed or been cancelled
+//and their free and all seats.
+func (r *RouteManager) UpcomingSeats(ctx context.Context, now time.Time) (domain.SeatStats, error) {
+	return r.storage.SeatStats(ctx, now.UTC().Truncate(time.Second))
+}
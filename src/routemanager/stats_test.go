@@ -0,0 +1,25 @@
+package routemanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpcomingSeats(t *testing.T) {
+	now := time.Date(2019, 04, 12, 10, 30, 15, 500, time.FixedZone("MSK", 3*60*60))
+
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+	stats := domain.SeatStats{Routes: 2, FreeSeats: 30, AllSeats: 45}
+	routestrg.On("SeatStats", context.Background(), time.Date(2019, 04, 12, 7, 30, 15, 0, time.UTC)).Return(stats, nil)
+
+	res, err := routeman.UpcomingSeats(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, stats, res)
+}
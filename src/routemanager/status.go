@@ -0,0 +1,107 @@
+package routemanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//validStatus checks that status is one of known statuses of route.
+func validStatus(status string) bool {
+	switch status {
+	case domain.StatusScheduled, domain.StatusBoarding, domain.StatusDelayed, domain.StatusDeparted,
+		domain.StatusCancelled:
+		return true
+	}
+	return false
+}
+
+//validateStatuses checks statuses of route query.
+func validateStatuses(statuses []string) error {
+	for _, status := range statuses {
+		if !validStatus(status) {
+			return domain.Invalid(fmt.Sprintf("invalid status %q", status))
+		}
+	}
+	return nil
+}
+
+//canChangeStatus checks if route can go from one status to another.
+//Delayed routes can be delayed again to change delay.
+func canChangeStatus(from, to string) bool {
+	switch from {
+	case domain.StatusScheduled, domain.StatusDelayed:
+		return to == domain.StatusBoarding || to == domain.StatusDelayed || to == domain.StatusCancelled
+	case domain.StatusBoarding:
+		return to == domain.StatusDeparted || to == domain.StatusDelayed || to == domain.StatusCancelled
+	}
+	return false
+}
+
+//validateStatusChange checks new status and delay, delay must be positive for delayed status
+//and zero for others.
+func validateStatusChange(status string, delay time.Duration) error {
+	if !validStatus(status) {
+		return domain.Invalid(fmt.Sprintf("invalid status %q", status))
+	}
+	if (status == domain.StatusDelayed) != (delay > 0) || delay < 0 {
+		return domain.Invalid("delay is invalid")
+	}
+	return nil
+}
+
+//checkActive returns conflict error if the route was cancelled or has departed.
+func checkActive(route *domain.Route) error {
+	switch route.Status {
+	case domain.StatusCancelled:
+		return domain.Conflict("route is cancelled")
+	case domain.StatusDeparted:
+		return domain.Conflict("route has already departed")
+	}
+	return nil
+}
+
+//ChangeRouteStatus moves the route with version to new status. Delay is set by delayed status only,
+//other statuses keep current delay of the route.
+func (r *RouteManager) ChangeRouteStatus(ctx context.Context, id, version int, status string,
+	delay time.Duration) (*domain.Route, error) {
+	err := validateStatusChange(status, delay)
+	if err != nil {
+		return nil, err
+	}
+
+	route, err := r.storage.RouteByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = checkVersion(route, version)
+	if err != nil {
+		return nil, err
+	}
+	if route.Status == status && status != domain.StatusDelayed {
+		return route, nil
+	}
+	if !canChangeStatus(route.Status, status) {
+		return nil, domain.Conflict(fmt.Sprintf("route can't change status from %s to %s", route.Status, status))
+	}
+	if status != domain.StatusDelayed {
+		delay = route.Delay
+	}
+
+	err = r.inTransaction(ctx, func(ctx context.Context) error {
+		err := r.storage.SetRouteStatus(ctx, id, route.Version, status, delay)
+		if err != nil {
+			return err
+		}
+		before := snapshot(route)
+		route.Status, route.Delay = status, delay
+		route.Version++
+		return r.record(ctx, domain.ActionStatus, id, before, route)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return route, nil
+}
@@ -0,0 +1,172 @@
+package routemanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func statusRoute(id int, status string, delay time.Duration) domain.Route {
+	route := stopsRoute()
+	route.ID, route.Status, route.Delay, route.Version = id, status, delay, 1
+	return route
+}
+
+func TestCanChangeStatus(t *testing.T) {
+	testCases := []struct {
+		from     string
+		to       string
+		expected bool
+	}{
+		{domain.StatusScheduled, domain.StatusBoarding, true},
+		{domain.StatusScheduled, domain.StatusDelayed, true},
+		{domain.StatusScheduled, domain.StatusCancelled, true},
+		{domain.StatusScheduled, domain.StatusDeparted, false},
+		{domain.StatusDelayed, domain.StatusDelayed, true},
+		{domain.StatusDelayed, domain.StatusScheduled, false},
+		{domain.StatusBoarding, domain.StatusDeparted, true},
+		{domain.StatusBoarding, domain.StatusScheduled, false},
+		{domain.StatusDeparted, domain.StatusCancelled, false},
+		{domain.StatusCancelled, domain.StatusScheduled, false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.from+" to "+tc.to, func(t *testing.T) {
+			assert.Equal(t, tc.expected, canChangeStatus(tc.from, tc.to))
+		})
+	}
+}
+
+func TestChangeRouteStatus(t *testing.T) {
+	routes := []domain.Route{
+		statusRoute(1, domain.StatusScheduled, 0),
+		statusRoute(2, domain.StatusDelayed, 20*time.Minute),
+		statusRoute(3, domain.StatusBoarding, 0),
+		statusRoute(4, domain.StatusCancelled, 0),
+		statusRoute(5, domain.StatusScheduled, 0),
+	}
+
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+	for i := range routes {
+		route := routes[i]
+		routestrg.On("RouteByID", mock.Anything, route.ID).Return(&route, nil)
+	}
+	routestrg.On("RouteByID", mock.Anything, 6).Return(nil, domain.NotFound("no such route"))
+	routestrg.On("SetRouteStatus", mock.Anything, 1, 1, domain.StatusDelayed, 30*time.Minute).Return(nil)
+	routestrg.On("SetRouteStatus", mock.Anything, 2, 1, domain.StatusBoarding, 20*time.Minute).Return(nil)
+	routestrg.On("SetRouteStatus", mock.Anything, 5, 1, domain.StatusCancelled, time.Duration(0)).
+		Return(domain.PreconditionFailed("route was changed"))
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+
+	testCases := []struct {
+		name            string
+		id              int
+		version         int
+		status          string
+		delay           time.Duration
+		expectedStatus  string
+		expectedDelay   time.Duration
+		expectedVersion int
+		expectedError   error
+	}{
+		{
+			name:            "delay route",
+			id:              1,
+			version:         1,
+			status:          domain.StatusDelayed,
+			delay:           30 * time.Minute,
+			expectedStatus:  domain.StatusDelayed,
+			expectedDelay:   30 * time.Minute,
+			expectedVersion: 2,
+		},
+		{
+			name:            "boarding keeps delay",
+			id:              2,
+			version:         1,
+			status:          domain.StatusBoarding,
+			expectedStatus:  domain.StatusBoarding,
+			expectedDelay:   20 * time.Minute,
+			expectedVersion: 2,
+		},
+		{
+			name:            "same status",
+			id:              3,
+			version:         1,
+			status:          domain.StatusBoarding,
+			expectedStatus:  domain.StatusBoarding,
+			expectedVersion: 1,
+		},
+		{
+			name:          "invalid status",
+			id:            1,
+			version:       1,
+			status:        "lost",
+			expectedError: domain.Invalid(`invalid status "lost"`),
+		},
+		{
+			name:          "delayed without delay",
+			id:            1,
+			version:       1,
+			status:        domain.StatusDelayed,
+			expectedError: domain.Invalid("delay is invalid"),
+		},
+		{
+			name:          "delay of other status",
+			id:            1,
+			version:       1,
+			status:        domain.StatusBoarding,
+			delay:         time.Minute,
+			expectedError: domain.Invalid("delay is invalid"),
+		},
+		{
+			name:          "final status",
+			id:            4,
+			version:       1,
+			status:        domain.StatusBoarding,
+			expectedError: domain.Conflict("route can't change status from cancelled to boarding"),
+		},
+		{
+			name:          "other version",
+			id:            3,
+			version:       2,
+			status:        domain.StatusCancelled,
+			expectedError: domain.PreconditionFailed("route version doesn't match"),
+		},
+		{
+			name:          "no route",
+			id:            6,
+			version:       1,
+			status:        domain.StatusCancelled,
+			expectedError: domain.NotFound("no such route"),
+		},
+		{
+			name:          "changed in storage",
+			id:            5,
+			version:       1,
+			status:        domain.StatusCancelled,
+			expectedError: domain.PreconditionFailed("route was changed"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			route, err := routeman.ChangeRouteStatus(context.Background(), tc.id, tc.version, tc.status, tc.delay)
+			require.Equal(t, tc.expectedError, err)
+			if err == nil {
+				assert.Equal(t, tc.expectedStatus, route.Status)
+				assert.Equal(t, tc.expectedDelay, route.Delay)
+				assert.Equal(t, tc.expectedVersion, route.Version)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package routemanager
+
+import (
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//validateStopPoints checks that stops go from start point to end point without repeats.
+func validateStopPoints(route *domain.Route) error {
+	stops := route.Stops
+	if len(stops) < 2 || stops[0].Point != route.Points.StartPoint ||
+		stops[len(stops)-1].Point != route.Points.EndPoint {
+		return domain.Invalid("stops don't match points")
+	}
+
+	seen := make(map[string]bool)
+	for _, stop := range stops {
+		if seen[stop.Point] {
+			return domain.Invalid("stops are repeated")
+		}
+		seen[stop.Point] = true
+	}
+	return nil
+}
+
+//validateStopSchedule checks order of arrival and departure offsets and fares of stops.
+func validateStopSchedule(route *domain.Route) error {
+	stops := route.Stops
+	if stops[0].Arrival != 0 || stops[0].Departure != 0 || stops[len(stops)-1].Arrival != route.Duration {
+		return domain.Invalid("stops schedule is invalid")
+	}
+
+	var fares int
+	for i, stop := range stops {
+		if stop.Departure < stop.Arrival || (i > 0 && stop.Arrival < stops[i-1].Departure) {
+			return domain.Invalid("stops schedule is invalid")
+		}
+		if stop.Fare < 0 || (i == 0 && stop.Fare != 0) {
+			return domain.Invalid("fare is invalid")
+		}
+		fares += stop.Fare
+	}
+	if fares != route.Cost.Amount {
+		return domain.Invalid("fares don't match cost")
+	}
+	return nil
+}
+
+//validateStops checks stops of the route if they are set.
+func validateStops(route *domain.Route) error {
+	if len(route.Stops) == 0 {
+		return nil
+	}
+	err := validateStopPoints(route)
+	if err != nil {
+		return err
+	}
+	return validateStopSchedule(route)
+}
+
+//stopPoints returns points of stops of the route or its end points if the route has no stops.
+func stopPoints(route *domain.Route) []string {
+	if len(route.Stops) == 0 {
+		return []string{route.Points.StartPoint, route.Points.EndPoint}
+	}
+	points := make([]string, 0, len(route.Stops))
+	for _, stop := range route.Stops {
+		points = append(points, stop.Point)
+	}
+	return points
+}
+
+//pointIndex returns position of point in points or -1 if there is no such point.
+func pointIndex(points []string, point string) int {
+	for i, p := range points {
+		if p == point {
+			return i
+		}
+	}
+	return -1
+}
+
+//keepSegmentSeats sets free seats of stops of updated route by booked tickets of stored route, so seats
+//which were sold between stops stay sold. Stops used by tickets can't be removed or reordered.
+func keepSegmentSeats(route, old *domain.Route, tickets []domain.Ticket) error {
+	//Seats of the last stop are never sold, so they show free seats of the route without tickets.
+	unsold := old.FreeSeats + len(tickets)
+	if len(old.Stops) != 0 {
+		unsold = old.Stops[len(old.Stops)-1].FreeSeats
+	}
+	unsold += route.AllSeats - old.AllSeats
+
+	points := stopPoints(route)
+	sold := make([]int, len(points))
+	for _, ticket := range tickets {
+		if ticket.From == "" {
+			ticket.From = old.Points.StartPoint
+		}
+		if ticket.To == "" {
+			ticket.To = old.Points.EndPoint
+		}
+		from, to := pointIndex(points, ticket.From), pointIndex(points, ticket.To)
+		if from < 0 || to < 0 || from >= to {
+			return domain.Conflict("stops of booked tickets can't be removed or reordered")
+		}
+		for i := from; i < to; i++ {
+			sold[i]++
+		}
+	}
+
+	if len(route.Stops) == 0 {
+		return nil
+	}
+	route.FreeSeats = unsold
+	for i := range route.Stops {
+		route.Stops[i].FreeSeats = unsold - sold[i]
+		if i < len(route.Stops)-1 && route.Stops[i].FreeSeats < route.FreeSeats {
+			route.FreeSeats = route.Stops[i].FreeSeats
+		}
+	}
+	if route.FreeSeats < 0 {
+		return domain.Conflict("all seats are fewer than sold seats")
+	}
+	return nil
+}
+
+//fillTicketStops sets stops of the ticket to ends of the route if they are empty,
+//checks them and counts cost of the ticket. It returns departure time from the first stop.
+func fillTicketStops(ticket *domain.Ticket, route *domain.Route) (time.Time, error) {
+	if ticket.From == "" {
+		ticket.From = route.Points.StartPoint
+	}
+	if ticket.To == "" {
+		ticket.To = route.Points.EndPoint
+	}
+
+	if len(route.Stops) == 0 {
+		if ticket.From != route.Points.StartPoint || ticket.To != route.Points.EndPoint {
+			return time.Time{}, domain.Invalid("no such stop")
+		}
+		ticket.Cost = route.Cost
+		return route.Start, nil
+	}
+
+	from, to := route.StopIndex(ticket.From), route.StopIndex(ticket.To)
+	if from < 0 || to < 0 {
+		return time.Time{}, domain.Invalid("no such stop")
+	}
+	if from >= to {
+		return time.Time{}, domain.Invalid("stops order is invalid")
+	}
+	ticket.Cost = route.SegmentCost(from, to)
+	return route.Start.Add(route.Stops[from].Departure), nil
+}
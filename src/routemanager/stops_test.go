@@ -0,0 +1,282 @@
+package routemanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func stopsRoute() domain.Route {
+	return domain.Route{
+		Points: domain.Points{
+			StartPoint: "Brest",
+			EndPoint:   "Vitebsk",
+		},
+		Start:     time.Date(time.Now().Year()+1, 04, 12, 8, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 3000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+		Duration:  8 * time.Hour,
+		Stops: []domain.Stop{
+			{Point: "Brest"},
+			{Point: "Minsk", Arrival: 4 * time.Hour, Departure: 4*time.Hour + 20*time.Minute, Fare: 2000},
+			{Point: "Vitebsk", Arrival: 8 * time.Hour, Departure: 8 * time.Hour, Fare: 1000},
+		},
+	}
+}
+
+func TestValidateStops(t *testing.T) {
+	testCases := []struct {
+		name          string
+		change        func(r *domain.Route)
+		expectedError error
+	}{
+		{
+			name:   "successful test",
+			change: func(r *domain.Route) {},
+		},
+		{
+			name:   "no stops",
+			change: func(r *domain.Route) { r.Stops = nil },
+		},
+		{
+			name:          "wrong end",
+			change:        func(r *domain.Route) { r.Stops[2].Point = "Orsha" },
+			expectedError: domain.Invalid("stops don't match points"),
+		},
+		{
+			name:          "one stop",
+			change:        func(r *domain.Route) { r.Stops = r.Stops[:1] },
+			expectedError: domain.Invalid("stops don't match points"),
+		},
+		{
+			name:          "repeated stop",
+			change:        func(r *domain.Route) { r.Stops[1].Point = "Brest" },
+			expectedError: domain.Invalid("stops are repeated"),
+		},
+		{
+			name:          "departure before arrival",
+			change:        func(r *domain.Route) { r.Stops[1].Departure = 3 * time.Hour },
+			expectedError: domain.Invalid("stops schedule is invalid"),
+		},
+		{
+			name:          "arrival doesn't match duration",
+			change:        func(r *domain.Route) { r.Duration = 9 * time.Hour },
+			expectedError: domain.Invalid("stops schedule is invalid"),
+		},
+		{
+			name:          "negative fare",
+			change:        func(r *domain.Route) { r.Stops[1].Fare = -1 },
+			expectedError: domain.Invalid("fare is invalid"),
+		},
+		{
+			name:          "fares don't match cost",
+			change:        func(r *domain.Route) { r.Cost.Amount = 2500 },
+			expectedError: domain.Invalid("fares don't match cost"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			route := stopsRoute()
+			tc.change(&route)
+			assert.Equal(t, tc.expectedError, validateStops(&route))
+		})
+	}
+}
+
+func TestCreateRouteWithStops(t *testing.T) {
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+
+	route := stopsRoute()
+	route.Stops[1].FreeSeats = 3
+	routestrg.On("AddRoute", mock.Anything, &route).Return(1, nil)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+
+	err := routeman.CreateNewRoute(context.Background(), &route)
+	require.NoError(t, err)
+	for _, stop := range route.Stops {
+		assert.Equal(t, 12, stop.FreeSeats)
+	}
+}
+
+func TestUpdateRouteWithStops(t *testing.T) {
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+	routestrg.On("UpdateRoute", mock.Anything, mock.Anything).Return(nil)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+
+	booked := stopsRoute()
+	booked.ID, booked.FreeSeats = 1, 9
+	booked.Stops[0].FreeSeats, booked.Stops[1].FreeSeats, booked.Stops[2].FreeSeats = 9, 11, 12
+	unbooked := stopsRoute()
+	unbooked.ID = 2
+	for i := range unbooked.Stops {
+		unbooked.Stops[i].FreeSeats = unbooked.FreeSeats
+	}
+	through := stopsRoute()
+	through.ID, through.FreeSeats = 3, 11
+	through.Stops[0].FreeSeats, through.Stops[1].FreeSeats, through.Stops[2].FreeSeats = 11, 11, 12
+	routestrg.On("RouteByID", mock.Anything, 1).Return(&booked, nil)
+	routestrg.On("RouteByID", mock.Anything, 2).Return(&unbooked, nil)
+	routestrg.On("RouteByID", mock.Anything, 3).Return(&through, nil)
+	routestrg.On("RouteTickets", mock.Anything, 1).Return([]domain.Ticket{
+		{ID: 1, RouteID: 1, From: "Brest", To: "Minsk"},
+		{ID: 2, RouteID: 1, From: "Brest", To: "Minsk"},
+		{ID: 3, RouteID: 1, From: "Brest", To: "Vitebsk"},
+	}, nil)
+	routestrg.On("RouteTickets", mock.Anything, 2).Return(nil, nil)
+	routestrg.On("RouteTickets", mock.Anything, 3).Return([]domain.Ticket{
+		{ID: 4, RouteID: 3, From: "Brest", To: "Vitebsk"},
+	}, nil)
+
+	testCases := []struct {
+		name          string
+		id            int
+		change        func(r *domain.Route)
+		expectedSeats []int
+		expectedError error
+	}{
+		{
+			name:          "seats of client",
+			id:            1,
+			change:        func(r *domain.Route) { r.Stops[0].FreeSeats, r.Stops[1].FreeSeats = 13, 14 },
+			expectedSeats: []int{9, 11, 12},
+		},
+		{
+			name:          "more seats",
+			id:            1,
+			change:        func(r *domain.Route) { r.AllSeats = 15 },
+			expectedSeats: []int{11, 13, 14},
+		},
+		{
+			name:          "changed stops of booked route",
+			id:            1,
+			change:        func(r *domain.Route) { r.Stops[1].Point = "Orsha" },
+			expectedError: domain.Conflict("stops of booked tickets can't be removed or reordered"),
+		},
+		{
+			name: "added stop of booked route",
+			id:   1,
+			change: func(r *domain.Route) {
+				r.Stops = append(r.Stops[:2:2], domain.Stop{Point: "Orsha", Arrival: 6 * time.Hour,
+					Departure: 6 * time.Hour}, r.Stops[2])
+			},
+			expectedSeats: []int{9, 11, 11, 12},
+		},
+		{
+			name: "removed stop of booked route",
+			id:   1,
+			change: func(r *domain.Route) {
+				r.Stops = []domain.Stop{r.Stops[0], r.Stops[2]}
+				r.Stops[1].Fare = 3000
+			},
+			expectedError: domain.Conflict("stops of booked tickets can't be removed or reordered"),
+		},
+		{
+			name:          "removed stops of booked route",
+			id:            1,
+			change:        func(r *domain.Route) { r.Stops = nil },
+			expectedError: domain.Conflict("stops of booked tickets can't be removed or reordered"),
+		},
+		{
+			name:          "changed stops",
+			id:            2,
+			change:        func(r *domain.Route) { r.Stops[1].Point = "Orsha" },
+			expectedSeats: []int{12, 12, 12},
+		},
+		{
+			name:          "changed stops between stops of tickets",
+			id:            3,
+			change:        func(r *domain.Route) { r.Stops[1].Point = "Orsha" },
+			expectedSeats: []int{11, 11, 12},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			route := stopsRoute()
+			route.ID = tc.id
+			tc.change(&route)
+			err := routeman.UpdateRoute(context.Background(), &route)
+			require.Equal(t, tc.expectedError, err)
+			if err != nil {
+				return
+			}
+			var seats []int
+			for _, stop := range route.Stops {
+				seats = append(seats, stop.FreeSeats)
+			}
+			assert.Equal(t, tc.expectedSeats, seats)
+			assert.Equal(t, tc.expectedSeats[0], route.FreeSeats)
+		})
+	}
+}
+
+func TestBookSeatBetweenStops(t *testing.T) {
+	var routestrg mocks.RouteStorage
+	routeman := NewRouteManager(&routestrg)
+
+	route := stopsRoute()
+	route.ID = 1
+	routestrg.On("RouteByID", mock.Anything, 1).Return(&route, nil)
+	routestrg.On("BookSeat", mock.Anything, mock.Anything).Return(7, nil)
+
+	testCases := []struct {
+		name          string
+		ticket        domain.Ticket
+		expectedCost  int
+		expectedFrom  string
+		expectedTo    string
+		expectedError error
+	}{
+		{
+			name:         "whole route",
+			ticket:       domain.Ticket{RouteID: 1, Passenger: "Ivanov"},
+			expectedCost: 3000,
+			expectedFrom: "Brest",
+			expectedTo:   "Vitebsk",
+		},
+		{
+			name:         "segment",
+			ticket:       domain.Ticket{RouteID: 1, Passenger: "Ivanov", From: "Minsk", To: "Vitebsk"},
+			expectedCost: 1000,
+			expectedFrom: "Minsk",
+			expectedTo:   "Vitebsk",
+		},
+		{
+			name:          "no such stop",
+			ticket:        domain.Ticket{RouteID: 1, Passenger: "Ivanov", From: "Orsha"},
+			expectedError: domain.Invalid("no such stop"),
+		},
+		{
+			name:          "wrong order",
+			ticket:        domain.Ticket{RouteID: 1, Passenger: "Ivanov", From: "Vitebsk", To: "Minsk"},
+			expectedError: domain.Invalid("stops order is invalid"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := routeman.BookSeat(context.Background(), &tc.ticket)
+			require.Equal(t, tc.expectedError, err)
+			if err == nil {
+				assert.Equal(t, tc.expectedCost, tc.ticket.Cost.Amount)
+				assert.Equal(t, tc.expectedFrom, tc.ticket.From)
+				assert.Equal(t, tc.expectedTo, tc.ticket.To)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/config"
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+)
+
+//Names of read-only search endpoints which can be public.
+const (
+	nameSearchRoutes = "search_routes"
+	namePlanJourneys = "plan_journeys"
+	nameGetRoutes    = "get_routes"
+	nameGetRoute     = "get_route"
+)
+
+//tokenLeeway - allowed difference between clocks of server and issuer of tokens.
+const tokenLeeway = time.Minute
+
+//tokenClient - prefix of names of clients identified by tokens. Names of API keys can't contain colon,
+//so subject of token can't match name of API key.
+const tokenClient = "jwt:"
+
+//clientKey - key of name of authenticated client in context.
+type clientKey struct{}
+
+//apiKey - static key of client.
+type apiKey struct {
+	name string
+	key  []byte
+}
+
+//authenticator - struct for checking credentials of clients: static API keys
+//and JWT bearer tokens signed with HS256 secret or RS256 key.
+type authenticator struct {
+	keys      []apiKey
+	secret    []byte
+	publicKey *rsa.PublicKey
+	parser    *jwt.Parser
+}
+
+//unauthorized creates error about missing or invalid credentials.
+func unauthorized(message string) error {
+	return &domain.Error{Kind: errUnauthorized, Message: message}
+}
+
+//parseAPIKeys parses keys of clients in form "name:key".
+func parseAPIKeys(entries []string) ([]apiKey, error) {
+	keys := make([]apiKey, 0, len(entries))
+	for i, entry := range entries {
+		name, key, ok := strings.Cut(entry, ":")
+		if !ok || name == "" || key == "" {
+			return nil, fmt.Errorf("API key #%d isn't in form name:key", i+1)
+		}
+		keys = append(keys, apiKey{name: name, key: []byte(key)})
+	}
+	return keys, nil
+}
+
+//newAuthenticator creates authenticator by keys from config.
+//It returns nil if no keys are configured and authentication is disabled.
+func newAuthenticator(c *config.Config) (*authenticator, error) {
+	if len(c.APIKeys) == 0 && c.JWTSecret == "" && c.JWTPublicKey == "" {
+		return nil, nil
+	}
+	keys, err := parseAPIKeys(c.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	a := &authenticator{keys: keys, secret: []byte(c.JWTSecret)}
+
+	var methods []string
+	if c.JWTSecret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if c.JWTPublicKey != "" {
+		data, err := os.ReadFile(c.JWTPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		a.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT public key: %v", err)
+		}
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(tokenLeeway),
+	}
+	if c.JWTIssuer != "" {
+		options = append(options, jwt.WithIssuer(c.JWTIssuer))
+	}
+	if c.JWTAudience != "" {
+		options = append(options, jwt.WithAudience(c.JWTAudience))
+	}
+	a.parser = jwt.NewParser(options...)
+	return a, nil
+}
+
+//keyName returns name of client which has API key.
+func (a *authenticator) keyName(key string) (string, error) {
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(k.key, []byte(key)) == 1 {
+			return k.name, nil
+		}
+	}
+	return "", unauthorized("invalid API key")
+}
+
+//verificationKey returns key for checking signature of token.
+func (a *authenticator) verificationKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return a.secret, nil
+	case *jwt.SigningMethodRSA:
+		return a.publicKey, nil
+	}
+	return nil, errors.New("unexpected signing method")
+}
+
+//tokenSubject checks signature and claims of token and returns its subject.
+//Token has to contain subject and expiration time.
+func (a *authenticator) tokenSubject(token string) (string, error) {
+	var claims jwt.RegisteredClaims
+	_, err := a.parser.ParseWithClaims(token, &claims, a.verificationKey)
+	if err != nil {
+		return "", &domain.Error{Kind: errUnauthorized, Message: "invalid token", Details: err.Error()}
+	}
+	if claims.Subject == "" {
+		return "", unauthorized("token has no subject")
+	}
+	return claims.Subject, nil
+}
+
+//identify returns name of client by API key from X-API-Key header or by bearer token
+//from Authorization header, clients of tokens are named by subject with tokenClient prefix.
+//Empty name means that request has no credentials.
+func (a *authenticator) identify(r *http.Request) (string, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return a.keyName(key)
+	}
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", nil
+	}
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", unauthorized("unsupported authorization scheme")
+	}
+	subject, err := a.tokenSubject(strings.TrimSpace(token))
+	if err != nil {
+		return "", err
+	}
+	return tokenClient + subject, nil
+}
+
+//isPublic checks if request is made to read-only search endpoint and these endpoints are public.
+func (b *BusStation) isPublic(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if !b.config.PublicSearch || route == nil {
+		return false
+	}
+	switch route.GetName() {
+	case nameSearchRoutes, namePlanJourneys, nameGetRoutes, nameGetRoute:
+		return true
+	}
+	return false
+}
+
+//authenticate makes changes of routes during request on behalf of client identified by credentials.
+//Requests with invalid credentials are rejected and charged to rate limit of IP address of client,
+//so guessing of credentials is throttled.
+func (b *BusStation) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, err := b.auth.identify(r)
+		if err != nil {
+			if b.allow(w, r, addressID(r)) {
+				writeUnauthorized(w, err)
+			}
+			return
+		}
+		if name != "" {
+			ctx := context.WithValue(r.Context(), clientKey{}, name)
+			r = r.WithContext(routemanager.WithActor(ctx, name))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+//writeUnauthorized writes error of authentication with challenge of supported scheme.
+func writeUnauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="busstation"`)
+	writeError(w, err)
+}
+
+//authorize allows handling of request only by clients which have one of roles, admins are allowed
+//everything. Public endpoints are allowed to every client, other endpoints require authenticated client.
+//All endpoints are allowed to everyone if authentication is disabled.
+func (b *BusStation) authorize(next http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if b.auth == nil || b.isPublic(r) {
+			next(w, r)
+			return
+		}
+		name, ok := r.Context().Value(clientKey{}).(string)
+		if !ok {
+			writeUnauthorized(w, unauthorized("authentication is required"))
+			return
+		}
+		allowed, err := b.access.Allowed(r.Context(), name, roles...)
+		if err == nil && !allowed {
+			err = &domain.Error{Kind: errForbidden, Message: "access is denied"}
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/gorilla/mux"
+)
+
+//Codes of errors in responses.
+const (
+	codeNotFound    = "not_found"
+	codeValidation  = "validation_error"
+	codeConflict    = "conflict"
+	codeUnavailable = "unavailable"
+	codeInternal    = "internal_error"
+
+	codePrecondition         = "precondition_failed"
+	codePreconditionRequired = "precondition_required"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeTooManyRequests      = "rate_limited"
+	codeKeyReused            = "idempotency_key_reused"
+)
+
+//errPreconditionRequired - kind of error about change of route requested without If-Match header.
+var errPreconditionRequired = errors.New("precondition required") //nolint:gochecknoglobals
+
+//errUnauthorized - kind of error about request without valid credentials of client.
+var errUnauthorized = errors.New("unauthorized") //nolint:gochecknoglobals
+
+//errForbidden - kind of error about request of client which doesn't have required role.
+var errForbidden = errors.New("forbidden") //nolint:gochecknoglobals
+
+//errTooManyRequests - kind of error about request of client which exceeded rate limit.
+var errTooManyRequests = errors.New("too many requests") //nolint:gochecknoglobals
+
+//errKeyReused - kind of error about request with idempotency key of other request.
+var errKeyReused = errors.New("idempotency key reused") //nolint:gochecknoglobals
+
+//errorServer - struct for encoding error response.
+type errorServer struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+}
+
+//errorStatus returns HTTP status and code of response for kind of error.
+func errorStatus(kind error) (int, string) {
+	switch kind {
+	case domain.ErrNotFound:
+		return http.StatusNotFound, codeNotFound
+	case domain.ErrValidation:
+		return http.StatusBadRequest, codeValidation
+	case domain.ErrConflict:
+		return http.StatusConflict, codeConflict
+	case domain.ErrPrecondition:
+		return http.StatusPreconditionFailed, codePrecondition
+	case errPreconditionRequired:
+		return http.StatusPreconditionRequired, codePreconditionRequired
+	case errUnauthorized:
+		return http.StatusUnauthorized, codeUnauthorized
+	case errForbidden:
+		return http.StatusForbidden, codeForbidden
+	case errTooManyRequests:
+		return http.StatusTooManyRequests, codeTooManyRequests
+	case errKeyReused:
+		return http.StatusUnprocessableEntity, codeKeyReused
+	case domain.ErrUnavailable:
+		return http.StatusServiceUnavailable, codeUnavailable
+	}
+	return http.StatusInternalServerError, codeInternal
+}
+
+//contextError converts errors of cancelled or expired context of request to unavailable error.
+func contextError(err error) error {
+	switch err {
+	case context.DeadlineExceeded:
+		return &domain.Error{Kind: domain.ErrUnavailable, Message: "request timed out", Details: err.Error()}
+	case context.Canceled:
+		return &domain.Error{Kind: domain.ErrUnavailable, Message: "request cancelled", Details: err.Error()}
+	}
+	return err
+}
+
+//writeError writes error as JSON response with status which matches kind of the error.
+//Messages of errors of unknown kind aren't shown to client.
+func writeError(w http.ResponseWriter, err error) {
+	err = contextError(err)
+	status, code := errorStatus(domain.ErrorKind(err))
+	body := errorServer{Code: code, Message: err.Error()}
+	if e, ok := err.(*domain.Error); ok {
+		body.Details = e.Details
+	}
+	if status == http.StatusInternalServerError {
+		log.Println(err)
+		body.Message = "internal error"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(body)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+//invalidJSON converts error of decoding JSON to validation error.
+func invalidJSON(err error) error {
+	if err == nil || domain.ErrorKind(err) != nil {
+		return err
+	}
+	return &domain.Error{Kind: domain.ErrValidation, Message: "invalid request body", Details: err.Error()}
+}
+
+//decodeJSON decodes body of request to v.
+func decodeJSON(r *http.Request, v interface{}) error {
+	return invalidJSON(json.NewDecoder(r.Body).Decode(v))
+}
+
+//idParam gets id from path of request.
+func idParam(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return 0, &domain.Error{Kind: domain.ErrValidation, Message: "invalid id", Details: err.Error()}
+	}
+	return id, nil
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+)
+
+//etag returns entity tag of version of route.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+//pageETag returns entity tag of page of routes which changes if any route of the page
+//or number of found routes is changed.
+func pageETag(page routesPage) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%d:%d", page.Total, page.Limit, page.Offset)
+	for _, route := range page.Routes {
+		fmt.Fprintf(h, ";%d:%d", route.ID, route.Version)
+	}
+	return `"` + strconv.FormatUint(h.Sum64(), 16) + `"`
+}
+
+//matchETag checks if list of entity tags from header contains tag or *, weak tags are compared by value.
+func matchETag(header, tag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+//notModified sets ETag header of response and writes Not Modified response
+//if If-None-Match header of request matches tag.
+func notModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+	if !matchETag(r.Header.Get("If-None-Match"), tag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+//ifMatch gets version of route expected by client from If-Match header which is required
+//for changes of routes. Header must contain one strong entity tag of route version
+//or * which matches any current version of route.
+func ifMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, &domain.Error{Kind: errPreconditionRequired, Message: "If-Match header is required"}
+	}
+	if header == "*" {
+		return routemanager.AnyVersion, nil
+	}
+	if len(header) < 2 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return 0, domain.PreconditionFailed("route version doesn't match")
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version <= 0 {
+		return 0, domain.PreconditionFailed("route version doesn't match")
+	}
+	return version, nil
+}
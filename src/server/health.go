@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//Statuses of server and its dependencies in responses of health endpoints.
+const (
+	statusOK           = "ok"
+	statusReady        = "ready"
+	statusNotReady     = "not_ready"
+	statusShuttingDown = "shutting_down"
+	statusUp           = "up"
+	statusDown         = "down"
+)
+
+//check - check of availability of dependency of server by its name.
+type check struct {
+	name string
+	ping func(ctx context.Context) error
+}
+
+//AddCheck adds check of dependency, e.g. database, which has to be available for server to be ready.
+//It has to be called before server is started.
+func (b *BusStation) AddCheck(name string, ping func(ctx context.Context) error) {
+	b.checks = append(b.checks, check{name: name, ping: ping})
+}
+
+//runChecks runs all checks concurrently with configured timeout and returns states of dependencies
+//and whether all of them are available.
+func (b *BusStation) runChecks(ctx context.Context) (map[string]componentServer, bool) {
+	if timeout := time.Duration(b.config.HealthTimeout) * time.Second; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	components := make(map[string]componentServer, len(b.checks))
+	up := true
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range b.checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			start := time.Now()
+			err := c.ping(ctx)
+			component := componentServer{Status: statusUp, LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				component.Status, component.Error = statusDown, err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			components[c.name] = component
+			up = up && err == nil
+		}(c)
+	}
+	wg.Wait()
+	return components, up
+}
+
+//writeHealth writes state of server with status code, health responses aren't cached.
+func writeHealth(w http.ResponseWriter, code int, health healthServer) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(health)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+//healthz reports that server is alive, dependencies aren't checked.
+func (b *BusStation) healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, healthServer{Status: statusOK})
+}
+
+//readyz reports whether server can handle requests: all dependencies are available
+//and server isn't shutting down.
+func (b *BusStation) readyz(w http.ResponseWriter, r *http.Request) {
+	if b.draining.Load() {
+		writeHealth(w, http.StatusServiceUnavailable, healthServer{Status: statusShuttingDown})
+		return
+	}
+	components, up := b.runChecks(r.Context())
+	if !up {
+		writeHealth(w, http.StatusServiceUnavailable, healthServer{Status: statusNotReady, Components: components})
+		return
+	}
+	writeHealth(w, http.StatusOK, healthServer{Status: statusReady, Components: components})
+}
+
+//shutdown makes server not ready, waits configured delay for load balancer to notice it
+//and stops server after handling of active requests during configured timeout.
+func (b *BusStation) shutdown(srv *http.Server) error {
+	b.draining.Store(true)
+	time.Sleep(time.Duration(b.config.ShutdownDelay) * time.Second)
+
+	ctx := context.Background()
+	if timeout := time.Duration(b.config.ShutdownTimeout) * time.Second; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return srv.Shutdown(ctx)
+}
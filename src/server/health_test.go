@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect"
+
+	"github.com/JaneKetko/Buses/src/config"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	cfg := &config.Config{
+		PortServer:    8000,
+		APIKeys:       []string{"disp:d"},
+		HealthTimeout: 1,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+	var err error
+	busstation.auth, err = newAuthenticator(cfg)
+	require.NoError(t, err)
+	var cacheErr error
+	busstation.AddCheck("database", func(ctx context.Context) error { return nil })
+	busstation.AddCheck("cache", func(ctx context.Context) error { return cacheErr })
+
+	server := httptest.NewServer(busstation.handler())
+	defer server.Close()
+	e := httpexpect.New(t, server.URL)
+
+	res := e.Request(http.MethodGet, "/healthz").Expect()
+	res.Status(http.StatusOK)
+	res.Header("Cache-Control").Equal("no-store")
+	res.JSON().Object().Equal(map[string]interface{}{"status": statusOK})
+
+	obj := e.Request(http.MethodGet, "/readyz").Expect().Status(http.StatusOK).JSON().Object()
+	obj.ValueEqual("status", statusReady)
+	obj.Value("components").Object().Value("database").Object().ValueEqual("status", statusUp)
+	obj.Value("components").Object().Value("cache").Object().ValueEqual("status", statusUp).NotContainsKey("error")
+
+	cacheErr = errors.New("connection refused")
+	obj = e.Request(http.MethodGet, "/readyz").Expect().Status(http.StatusServiceUnavailable).JSON().Object()
+	obj.ValueEqual("status", statusNotReady)
+	obj.Value("components").Object().Value("database").Object().ValueEqual("status", statusUp)
+	obj.Value("components").Object().Value("cache").Object().ValueEqual("status", statusDown).
+		ValueEqual("error", "connection refused")
+
+	busstation.draining.Store(true)
+	e.Request(http.MethodGet, "/readyz").Expect().Status(http.StatusServiceUnavailable).
+		JSON().Object().Equal(map[string]interface{}{"status": statusShuttingDown})
+	e.Request(http.MethodGet, "/healthz").Expect().Status(http.StatusOK)
+}
+
+func TestReadinessTimeout(t *testing.T) {
+	busstation := &BusStation{config: &config.Config{HealthTimeout: 1}}
+	busstation.AddCheck("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	components, up := busstation.runChecks(context.Background())
+	assert.False(t, up)
+	assert.Equal(t, statusDown, components["database"].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), components["database"].Error)
+}
+
+func TestShutdown(t *testing.T) {
+	busstation := &BusStation{config: &config.Config{ShutdownTimeout: 1}}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(busstation.readyz)}
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(l)
+	}()
+
+	require.NoError(t, busstation.shutdown(srv))
+	assert.True(t, busstation.draining.Load())
+	assert.Equal(t, http.ErrServerClosed, <-served)
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+)
+
+//maxIdempotencyKey - maximal length of Idempotency-Key header.
+const maxIdempotencyKey = 255
+
+//recorder - response writer which keeps copy of status and body of response.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+//WriteHeader implements http.ResponseWriter.
+func (rec *recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+//Write implements http.ResponseWriter.
+func (rec *recorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
+
+//requestHash returns hash of method, path and body of request.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.RequestURI())
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//recordKey returns key of record which is unique for client and idempotency key.
+func recordKey(r *http.Request, key string) string {
+	h := sha256.Sum256([]byte(clientID(r) + "\n" + key))
+	return hex.EncodeToString(h[:])
+}
+
+//replay writes stored response to request with the same idempotency key.
+func replay(w http.ResponseWriter, rec *domain.IdempotencyRecord) {
+	if rec.ContentType != "" {
+		w.Header().Set("Content-Type", rec.ContentType)
+	}
+	if rec.ETag != "" {
+		w.Header().Set("ETag", rec.ETag)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(rec.Status)
+	_, err := w.Write(rec.Body)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+//replayStored responds to retry of request with stored response. Request with the same key
+//and other method, path or body is rejected.
+func (b *BusStation) replayStored(w http.ResponseWriter, r *http.Request, rec *domain.IdempotencyRecord) {
+	stored, err := b.idempotency.IdempotencyRecordByKey(r.Context(), rec.Key)
+	if domain.ErrorKind(err) == domain.ErrNotFound {
+		err = domain.Conflict("request with the idempotency key is being handled")
+	}
+	switch {
+	case err != nil:
+		writeError(w, err)
+	case stored.Hash != rec.Hash:
+		writeError(w, &domain.Error{Kind: errKeyReused, Message: "idempotency key is used for other request"})
+	case stored.Status == 0:
+		writeError(w, domain.Conflict("request with the idempotency key is being handled"))
+	default:
+		replay(w, stored)
+	}
+}
+
+//retriable reports whether request with response of status can be retried with the same
+//idempotency key. Server errors are retriable only if changes of request weren't committed,
+//responses to unauthenticated or forbidden requests aren't stored at all.
+func retriable(ctx context.Context, status int) bool {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return true
+	case status >= http.StatusInternalServerError:
+		return !routemanager.Committed(ctx)
+	}
+	return false
+}
+
+//saveResponse stores response to request with idempotency key. Responses which allow to retry
+//the request aren't stored.
+func (b *BusStation) saveResponse(r *http.Request, rec *domain.IdempotencyRecord, resp *recorder) {
+	ctx := context.WithoutCancel(r.Context())
+	var err error
+	if retriable(ctx, resp.status) {
+		err = b.idempotency.DeleteIdempotencyRecord(ctx, rec.Key)
+	} else {
+		rec.Status = resp.status
+		if rec.Status == 0 {
+			rec.Status = http.StatusOK
+		}
+		rec.ContentType = resp.Header().Get("Content-Type")
+		rec.ETag = resp.Header().Get("ETag")
+		rec.Body = resp.body.Bytes()
+		err = b.idempotency.CompleteIdempotencyRecord(ctx, rec)
+	}
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+//idempotent handles request to mutating endpoint with Idempotency-Key header only once,
+//its response is stored for configured TTL and replayed on retries of the request.
+func (b *BusStation) idempotent(next http.Handler) http.Handler {
+	ttl := time.Duration(b.config.IdempotencyTTL) * time.Hour
+	if b.idempotency == nil || ttl <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			writeError(w, domain.Invalid("idempotency key is too long"))
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, invalidJSON(err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		rec := domain.IdempotencyRecord{Key: recordKey(r, key), Hash: requestHash(r, body),
+			Expires: time.Now().Add(ttl)}
+		err = b.idempotency.AddIdempotencyRecord(r.Context(), &rec)
+		if domain.ErrorKind(err) == domain.ErrConflict {
+			b.replayStored(w, r, &rec)
+			return
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := &recorder{ResponseWriter: w}
+		r = r.WithContext(routemanager.WithCommitTracking(r.Context()))
+		next.ServeHTTP(resp, r)
+		b.saveResponse(r, &rec, resp)
+	})
+}
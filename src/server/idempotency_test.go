@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect"
+
+	"github.com/JaneKetko/Buses/src/config"
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/memstorage"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	cfg := &config.Config{
+		PortServer:     8000,
+		IdempotencyTTL: 1,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	storage := memstorage.NewMemStorage()
+	busstation := NewBusStation(routeman, nil, storage, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+	routestrg.On("AddRoute", mock.Anything, mock.AnythingOfType("*domain.Route")).
+		Return(0, domain.Unavailable("database is down")).Once()
+	routestrg.On("AddRoute", mock.Anything, mock.AnythingOfType("*domain.Route")).Return(7, nil).Once()
+	routestrg.On("RouteByID", mock.Anything, 7).Return(&domain.Route{ID: 7, Version: 1}, nil)
+	routestrg.On("DeleteRow", mock.Anything, 7, 1).Return(nil).Once()
+
+	body := map[string]interface{}{
+		"points":     map[string]interface{}{"startpoint": "Brest", "endpoint": "Vitebsk"},
+		"start_time": time.Date(2030, 5, 14, 10, 0, 0, 0, time.UTC),
+		"cost":       30,
+		"freeseats":  12,
+		"allseats":   13,
+	}
+	create := func() *httpexpect.Response {
+		return e.Request(http.MethodPost, "/routes").WithHeader("Idempotency-Key", "create-1").
+			WithJSON(body).Expect()
+	}
+
+	create().Status(http.StatusServiceUnavailable)
+
+	res := create()
+	res.Status(http.StatusOK)
+	res.Header("Idempotent-Replayed").Empty()
+	created := res.Body().Raw()
+
+	res = create()
+	res.Status(http.StatusOK)
+	res.Header("Idempotent-Replayed").Equal("true")
+	res.Header("Content-Type").Equal("application/json")
+	res.Body().Equal(created)
+	routestrg.AssertNumberOfCalls(t, "AddRoute", 2)
+
+	body["cost"] = 40
+	create().Status(http.StatusUnprocessableEntity).JSON().Object().ValueEqual("code", codeKeyReused)
+
+	remove := func() *httpexpect.Response {
+		return e.Request(http.MethodDelete, "/routes/7").WithHeader("Idempotency-Key", "delete-1").
+			WithHeader("If-Match", `"1"`).Expect()
+	}
+	remove().Status(http.StatusOK).Header("ETag").Equal(`"2"`)
+	res = remove()
+	res.Status(http.StatusOK)
+	res.Header("ETag").Equal(`"2"`)
+	res.Header("Idempotent-Replayed").Equal("true")
+	routestrg.AssertNumberOfCalls(t, "DeleteRow", 1)
+
+	e.Request(http.MethodDelete, "/routes/7").WithHeader("Idempotency-Key", strings.Repeat("k", 256)).
+		Expect().Status(http.StatusBadRequest)
+}
+
+func TestIdempotencyKeyInProgress(t *testing.T) {
+	cfg := &config.Config{
+		PortServer:     8000,
+		IdempotencyTTL: 1,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	storage := memstorage.NewMemStorage()
+	busstation := NewBusStation(routeman, nil, storage, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	r := httptest.NewRequest(http.MethodDelete, "/routes/7", nil)
+	r = r.WithContext(context.WithValue(r.Context(), clientKey{}, "root"))
+	rec := domain.IdempotencyRecord{Key: recordKey(r, "delete-1"), Hash: requestHash(r, nil),
+		Expires: time.Now().Add(time.Hour)}
+	require.NoError(t, storage.AddIdempotencyRecord(context.Background(), &rec))
+
+	e.Request(http.MethodDelete, "/routes/7").WithHeader("Idempotency-Key", "delete-1").
+		Expect().Status(http.StatusConflict)
+	routestrg.AssertNotCalled(t, "DeleteRow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIdempotencyKeyRetriable(t *testing.T) {
+	cfg := &config.Config{
+		PortServer:     8000,
+		IdempotencyTTL: 1,
+	}
+	tests := []struct {
+		name     string
+		status   int
+		cancel   error
+		replayed bool
+	}{
+		{"committed server error is stored", http.StatusInternalServerError, nil, true},
+		{"failed server error isn't stored", http.StatusServiceUnavailable,
+			domain.Unavailable("database is down"), false},
+		{"unauthorized isn't stored", http.StatusUnauthorized, nil, false},
+		{"forbidden isn't stored", http.StatusForbidden, nil, false},
+		{"client error is stored", http.StatusNotFound, nil, true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var routestrg mocks.RouteStorage
+			routestrg.On("CancelBooking", mock.Anything, 3).Return(tc.cancel)
+			busstation := NewBusStation(routemanager.NewRouteManager(&routestrg), nil,
+				memstorage.NewMemStorage(), cfg)
+
+			var calls int
+			handler := busstation.idempotent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				_ = busstation.routes.CancelBooking(r.Context(), 3)
+				w.WriteHeader(tc.status)
+			}))
+			for i := 0; i < 2; i++ {
+				r := httptest.NewRequest(http.MethodDelete, "/tickets/3", nil)
+				r.Header.Set("Idempotency-Key", "cancel-3")
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				require.Equal(t, tc.status, w.Code)
+			}
+			if tc.replayed {
+				require.Equal(t, 1, calls)
+			} else {
+				require.Equal(t, 2, calls)
+			}
+		})
+	}
+}
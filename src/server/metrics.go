@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+//namespace - prefix of names of metrics of server.
+const namespace = "busstation"
+
+//seatsTimeout - maximal time of counting of seats of upcoming routes during scrape.
+const seatsTimeout = 5 * time.Second
+
+//unmatchedRoute - route label of requests which don't match any route, so paths of scanners
+//don't create new series.
+const unmatchedRoute = "unmatched"
+
+//metrics - struct for collecting metrics of requests, queries to database and routes for Prometheus.
+type metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	queries  *prometheus.HistogramVec
+}
+
+//newMetrics creates registry with metrics of process, requests, queries and seats of upcoming routes.
+func newMetrics(routes *routemanager.RouteManager) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of handled HTTP requests by route template, method and status.",
+		}, []string{"route", "method", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of handling of HTTP requests by route template, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Duration of calls of methods of database storage.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.latency,
+		m.queries,
+		newSeatsCollector(routes),
+	)
+	return m
+}
+
+//handler returns handler of scrapes of metrics. Metrics which can't be collected are skipped.
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		ErrorLog:      log.Default(),
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+//seatsCollector - collector of number of upcoming routes and their seats which are counted during scrape.
+type seatsCollector struct {
+	routes    *routemanager.RouteManager
+	upcoming  *prometheus.Desc
+	freeSeats *prometheus.Desc
+	allSeats  *prometheus.Desc
+}
+
+//newSeatsCollector creates collector of seats of upcoming routes.
+func newSeatsCollector(routes *routemanager.RouteManager) *seatsCollector {
+	return &seatsCollector{
+		routes: routes,
+		upcoming: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "upcoming_routes"),
+			"Number of routes which haven't departed or been cancelled yet.", nil, nil),
+		freeSeats: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "upcoming_free_seats"),
+			"Number of free seats of upcoming routes.", nil, nil),
+		allSeats: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "upcoming_seats"),
+			"Number of all seats of upcoming routes.", nil, nil),
+	}
+}
+
+//Describe implements prometheus.Collector.
+func (c *seatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.upcoming
+	ch <- c.freeSeats
+	ch <- c.allSeats
+}
+
+//Collect implements prometheus.Collector.
+func (c *seatsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), seatsTimeout)
+	defer cancel()
+	stats, err := c.routes.UpcomingSeats(ctx, time.Now())
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.upcoming, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.upcoming, prometheus.GaugeValue, float64(stats.Routes))
+	ch <- prometheus.MustNewConstMetric(c.freeSeats, prometheus.GaugeValue, float64(stats.FreeSeats))
+	ch <- prometheus.MustNewConstMetric(c.allSeats, prometheus.GaugeValue, float64(stats.AllSeats))
+}
+
+//statusWriter - response writer which keeps status of response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+//WriteHeader implements http.ResponseWriter.
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+//Write implements http.ResponseWriter.
+func (w *statusWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+//methodNotAllowed responds to request with method which isn't allowed for matched path.
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+//instrument counts requests and measures their latency by template of matched route, method and status.
+//Middlewares of router don't run for unmatched requests, so their handlers are instrumented separately.
+func (b *BusStation) instrument(next http.Handler) http.Handler {
+	if b.metrics == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		template := unmatchedRoute
+		if route := mux.CurrentRoute(r); route != nil {
+			template, _ = route.GetPathTemplate()
+		}
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		labels := prometheus.Labels{"route": template, "method": r.Method, "status": strconv.Itoa(sw.status)}
+		b.metrics.requests.With(labels).Inc()
+		b.metrics.latency.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+//ObserveQuery records duration of call of method of database storage.
+func (b *BusStation) ObserveQuery(method string, d time.Duration) {
+	if b.metrics != nil {
+		b.metrics.queries.WithLabelValues(method).Observe(d.Seconds())
+	}
+}
+
+//MonitorDB adds statistics of pool of connections of database to metrics.
+func (b *BusStation) MonitorDB(db *sql.DB) {
+	if b.metrics != nil {
+		b.metrics.registry.MustRegister(collectors.NewDBStatsCollector(db, b.config.DBName))
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect"
+
+	"github.com/JaneKetko/Buses/src/config"
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	cfg := &config.Config{
+		PortServer:   8000,
+		APIKeys:      []string{"disp:d"},
+		PublicSearch: true,
+		Metrics:      true,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+	var err error
+	busstation.auth, err = newAuthenticator(cfg)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(busstation.handler())
+	defer server.Close()
+	e := httpexpect.New(t, server.URL)
+
+	routestrg.On("RouteByID", mock.Anything, 1).Return(&domain.Route{ID: 1, Version: 1}, nil)
+	routestrg.On("SeatStats", mock.Anything, mock.AnythingOfType("time.Time")).
+		Return(domain.SeatStats{Routes: 2, FreeSeats: 15, AllSeats: 40}, nil).Once()
+	routestrg.On("SeatStats", mock.Anything, mock.AnythingOfType("time.Time")).
+		Return(domain.SeatStats{}, domain.Unavailable("data hasn't read")).Once()
+
+	e.Request(http.MethodGet, "/routes/1").Expect().Status(http.StatusOK)
+	e.Request(http.MethodGet, "/routes/1").Expect().Status(http.StatusOK)
+	e.Request(http.MethodGet, "/routes/df2").Expect().Status(http.StatusBadRequest)
+	e.Request(http.MethodDelete, "/routes/1").Expect().Status(http.StatusUnauthorized)
+	e.Request(http.MethodGet, "/wp-login.php").Expect().Status(http.StatusNotFound)
+	e.Request(http.MethodPost, "/routes/1").Expect().Status(http.StatusMethodNotAllowed)
+	busstation.ObserveQuery("RouteByID", 20*time.Millisecond)
+
+	body := e.Request(http.MethodGet, "/metrics").Expect().Status(http.StatusOK).Body()
+	body.Contains(`busstation_http_requests_total{method="GET",route="/routes/{id}",status="200"} 2`)
+	body.Contains(`busstation_http_requests_total{method="GET",route="/routes/{id}",status="400"} 1`)
+	body.Contains(`busstation_http_requests_total{method="DELETE",route="/routes/{id}",status="401"} 1`)
+	body.Contains(`busstation_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	body.Contains(`busstation_http_requests_total{method="POST",route="unmatched",status="405"} 1`)
+	body.Contains(`busstation_http_request_duration_seconds_count{method="GET",route="/routes/{id}",status="200"} 2`)
+	body.Contains(`busstation_db_query_duration_seconds_count{method="RouteByID"} 1`)
+	body.Contains("busstation_upcoming_routes 2")
+	body.Contains("busstation_upcoming_free_seats 15")
+	body.Contains("busstation_upcoming_seats 40")
+	body.Contains("go_goroutines")
+
+	body = e.Request(http.MethodGet, "/metrics").Expect().Status(http.StatusOK).Body()
+	body.NotContains("busstation_upcoming_routes")
+	body.Contains(`busstation_db_query_duration_seconds_count{method="RouteByID"} 1`)
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+	busstation.ObserveQuery("RouteByID", time.Millisecond)
+
+	server := httptest.NewServer(busstation.handler())
+	defer server.Close()
+	e := httpexpect.New(t, server.URL)
+
+	e.Request(http.MethodGet, "/metrics").Expect().Status(http.StatusNotFound)
+}
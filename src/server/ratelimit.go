@@ -0,0 +1,160 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+)
+
+//sweepInterval - interval between removals of buckets which became full.
+const sweepInterval = time.Minute
+
+//bucket - tokens of client and time of their last update.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+//limit - result of taking token from bucket of client.
+//Reset is time after which bucket is full, RetryAfter is time after which token can be taken.
+type limit struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+//limiter - struct for limiting rate of requests of clients by token buckets with the same rate and burst.
+type limiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     int
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+//newLimiter creates limiter which allows perMinute requests per minute and burst requests at once.
+//It returns nil if rate isn't positive and limiting is disabled.
+func newLimiter(perMinute, burst int) *limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &limiter{
+		rate:    float64(perMinute) / 60,
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+//duration returns time needed to get number of tokens.
+func (l *limiter) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+//refill adds tokens which were accumulated by bucket since its last update.
+func (l *limiter) refill(b *bucket, now time.Time) {
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+}
+
+//sweep removes buckets which became full, such buckets are the same as new ones.
+func (l *limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+//take takes token from bucket of client by key.
+func (l *limiter) take(key string) limit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), updated: now}
+		l.buckets[key] = b
+	}
+	l.refill(b, now)
+
+	res := limit{allowed: b.tokens >= 1}
+	if res.allowed {
+		b.tokens--
+	} else {
+		res.retryAfter = l.duration(1 - b.tokens)
+	}
+	res.remaining = int(b.tokens)
+	res.reset = l.duration(float64(l.burst) - b.tokens)
+	return res
+}
+
+//seconds formats duration as number of whole seconds rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+//addressID returns key of client for rate limiting by IP address.
+func addressID(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+//clientID returns key of client for rate limiting: name of authenticated client or IP address.
+func clientID(r *http.Request) string {
+	if name, ok := r.Context().Value(clientKey{}).(string); ok {
+		return "client:" + name
+	}
+	return addressID(r)
+}
+
+//allow takes token of client by key from limiter of read or write endpoints and reports state
+//of its limit in X-RateLimit-* headers. Request which exceeds the limit is rejected.
+func (b *BusStation) allow(w http.ResponseWriter, r *http.Request, key string) bool {
+	l := b.writeLimiter
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		l = b.readLimiter
+	}
+	if l == nil {
+		return true
+	}
+
+	res := l.take(key)
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(l.burst))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.remaining))
+	w.Header().Set("X-RateLimit-Reset", seconds(res.reset))
+	if !res.allowed {
+		w.Header().Set("Retry-After", seconds(res.retryAfter))
+		writeError(w, &domain.Error{Kind: errTooManyRequests, Message: "rate limit is exceeded"})
+	}
+	return res.allowed
+}
+
+//limitRate rejects requests of client which exceeds rate limit of read or write endpoints.
+func (b *BusStation) limitRate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if b.allow(w, r, clientID(r)) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
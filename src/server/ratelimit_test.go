@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect"
+
+	"github.com/JaneKetko/Buses/src/accessmanager"
+	"github.com/JaneKetko/Buses/src/config"
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2030, 5, 14, 10, 0, 0, 0, time.UTC)
+	l := newLimiter(30, 2)
+	l.now = func() time.Time { return now }
+
+	assert.Equal(t, limit{allowed: true, remaining: 1, reset: 2 * time.Second}, l.take("a"))
+	assert.Equal(t, limit{allowed: true, remaining: 0, reset: 4 * time.Second}, l.take("a"))
+	assert.Equal(t, limit{allowed: false, remaining: 0, reset: 4 * time.Second, retryAfter: 2 * time.Second},
+		l.take("a"))
+	assert.Equal(t, limit{allowed: true, remaining: 1, reset: 2 * time.Second}, l.take("b"))
+
+	now = now.Add(time.Second)
+	res := l.take("a")
+	assert.False(t, res.allowed)
+	assert.Equal(t, time.Second, res.retryAfter)
+
+	now = now.Add(time.Second)
+	assert.Equal(t, limit{allowed: true, remaining: 0, reset: 4 * time.Second}, l.take("a"))
+
+	now = now.Add(sweepInterval)
+	l.take("c")
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestNewLimiter(t *testing.T) {
+	assert.Nil(t, newLimiter(0, 10))
+	assert.Equal(t, 1, newLimiter(60, 0).burst)
+}
+
+func TestLimitRate(t *testing.T) {
+	cfg := &config.Config{
+		PortServer:     8000,
+		ReadRateLimit:  1,
+		ReadBurst:      2,
+		WriteRateLimit: 1,
+		WriteBurst:     1,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+	now := time.Now()
+	busstation.readLimiter.now = func() time.Time { return now }
+	busstation.writeLimiter.now = func() time.Time { return now }
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	routestrg.On("RouteByID", mock.Anything, 1).Return(&domain.Route{ID: 1, Version: 1}, nil)
+
+	res := e.Request(http.MethodGet, "/routes/1").Expect()
+	res.Status(http.StatusOK)
+	res.Header("X-RateLimit-Limit").Equal("2")
+	res.Header("X-RateLimit-Remaining").Equal("1")
+	res.Header("X-RateLimit-Reset").Equal("60")
+
+	e.Request(http.MethodGet, "/routes/1").Expect().Status(http.StatusOK).Header("X-RateLimit-Remaining").Equal("0")
+
+	res = e.Request(http.MethodGet, "/routes/1").Expect()
+	res.Status(http.StatusTooManyRequests)
+	res.Header("Retry-After").Equal("60")
+	res.Header("X-RateLimit-Remaining").Equal("0")
+	res.JSON().Object().ValueEqual("code", codeTooManyRequests)
+
+	res = e.Request(http.MethodDelete, "/routes/df2").Expect()
+	res.Status(http.StatusBadRequest)
+	res.Header("X-RateLimit-Limit").Equal("1")
+	e.Request(http.MethodDelete, "/routes/df2").Expect().Status(http.StatusTooManyRequests)
+}
+
+func TestClientID(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/routes", nil)
+	r.RemoteAddr = "10.0.0.7:51234"
+	assert.Equal(t, "ip:10.0.0.7", clientID(r))
+
+	r = r.WithContext(context.WithValue(r.Context(), clientKey{}, "kiosk"))
+	assert.Equal(t, "client:kiosk", clientID(r))
+}
+
+func TestLimitFailedAuthentication(t *testing.T) {
+	cfg := &config.Config{
+		PortServer:     8000,
+		APIKeys:        []string{"disp:d"},
+		Admins:         []string{"disp"},
+		WriteRateLimit: 1,
+		WriteBurst:     2,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+	var err error
+	busstation.auth, err = newAuthenticator(cfg)
+	require.NoError(t, err)
+	busstation.access = accessmanager.NewAccessManager(nil, cfg.Admins)
+	now := time.Now()
+	busstation.writeLimiter.now = func() time.Time { return now }
+
+	server := httptest.NewServer(busstation.handler())
+	defer server.Close()
+	e := httpexpect.New(t, server.URL)
+
+	guess := func() *httpexpect.Response {
+		return e.Request(http.MethodDelete, "/routes/1").WithHeader("X-API-Key", "guess").Expect()
+	}
+	guess().Status(http.StatusUnauthorized)
+	guess().Status(http.StatusUnauthorized)
+	res := guess()
+	res.Status(http.StatusTooManyRequests)
+	res.Header("Retry-After").Equal("60")
+
+	e.Request(http.MethodDelete, "/routes/1").WithHeader("X-API-Key", "d").Expect().
+		Status(http.StatusPreconditionRequired)
+}
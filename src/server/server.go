@@ -1,73 +1,213 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/JaneKetko/Buses/src/accessmanager"
 	"github.com/JaneKetko/Buses/src/config"
+	"github.com/JaneKetko/Buses/src/domain"
 	"github.com/JaneKetko/Buses/src/routemanager"
 	"github.com/gorilla/mux"
 )
 
 //BusStation - struct for describing bus station: manager for work with route info and configuration for server.
 type BusStation struct {
-	routes *routemanager.RouteManager
-	config *config.Config
+	routes       *routemanager.RouteManager
+	access       *accessmanager.AccessManager
+	idempotency  routemanager.IdempotencyStorage
+	config       *config.Config
+	auth         *authenticator
+	readLimiter  *limiter
+	writeLimiter *limiter
+	metrics      *metrics
+	checks       []check
+	draining     atomic.Bool
 }
 
 //NewBusStation - constructor for BusStation.
-func NewBusStation(r *routemanager.RouteManager, c *config.Config) *BusStation {
-	return &BusStation{
-		routes: r,
-		config: c,
+//Metrics are collected if they are enabled in config, snapshots of routes in audit log
+//are kept in representation of API.
+func NewBusStation(r *routemanager.RouteManager, a *accessmanager.AccessManager, i routemanager.IdempotencyStorage,
+	c *config.Config) *BusStation {
+	b := &BusStation{
+		routes:       r,
+		access:       a,
+		idempotency:  i,
+		config:       c,
+		readLimiter:  newLimiter(c.ReadRateLimit, c.ReadBurst),
+		writeLimiter: newLimiter(c.WriteRateLimit, c.WriteBurst),
 	}
+	if c.Metrics {
+		b.metrics = newMetrics(r)
+	}
+	r.SetSnapshotEncoder(encodeSnapshot)
+	return b
+}
+
+//Pagination limits for list of routes.
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+//Default parameters of journey planning.
+const (
+	defaultLegs       = 3
+	defaultConnection = 15
+)
+
+//intParam parses non-negative integer query parameter, def is returned if parameter is absent.
+func intParam(values url.Values, name string, def int) (int, error) {
+	param := values.Get(name)
+	if param == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil || n < 0 {
+		return 0, domain.Invalid(fmt.Sprintf("invalid %s argument", name))
+	}
+	return n, nil
+}
+
+//costParam parses cost query parameter to cents, zero is returned if parameter is absent.
+func costParam(values url.Values, name string) (int, error) {
+	param := values.Get(name)
+	if param == "" {
+		return 0, nil
+	}
+	cost, err := domain.ParseAmount(param)
+	if err != nil || cost < 0 {
+		return 0, domain.Invalid(fmt.Sprintf("invalid %s argument", name))
+	}
+	return cost, nil
+}
+
+//dateParam parses date query parameter, zero time is returned if parameter is absent.
+func dateParam(values url.Values, name string) (time.Time, error) {
+	param := values.Get(name)
+	if param == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse("2006-01-02", param)
+	if err != nil {
+		return time.Time{}, domain.Invalid(fmt.Sprintf("invalid %s argument", name))
+	}
+	return date, nil
+}
+
+//statusParam parses comma separated list of statuses, nil is returned if parameter is absent.
+func statusParam(values url.Values) []string {
+	param := values.Get("status")
+	if param == "" {
+		return nil
+	}
+	return strings.Split(param, ",")
+}
+
+//parseRouteQuery gets filters, sorting and pagination of routes from query parameters.
+func parseRouteQuery(values url.Values) (domain.RouteQuery, error) {
+	q := domain.RouteQuery{
+		StartPoint: values.Get("startpoint"),
+		EndPoint:   values.Get("endpoint"),
+		Statuses:   statusParam(values),
+		SortBy:     strings.TrimPrefix(values.Get("sort"), "-"),
+		Desc:       strings.HasPrefix(values.Get("sort"), "-"),
+	}
+
+	var err error
+	if q.From, err = dateParam(values, "date_from"); err != nil {
+		return q, err
+	}
+	if q.To, err = dateParam(values, "date_to"); err != nil {
+		return q, err
+	}
+	if !q.To.IsZero() {
+		q.To = q.To.AddDate(0, 0, 1)
+	}
+	if q.MinFreeSeats, err = intParam(values, "min_freeseats", 0); err != nil {
+		return q, err
+	}
+	if q.MaxCost, err = costParam(values, "max_cost"); err != nil {
+		return q, err
+	}
+	if q.Limit, err = intParam(values, "limit", defaultLimit); err != nil {
+		return q, err
+	}
+	if q.Limit == 0 || q.Limit > maxLimit {
+		return q, domain.Invalid("invalid limit argument")
+	}
+	q.Offset, err = intParam(values, "offset", 0)
+	return q, err
 }
 
 func (b *BusStation) getRoutes(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	rts, err := b.routes.GetAllRoutes()
+	q, err := parseRouteQuery(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
-	rserver := make([]routeServer, 0)
+	rts, total, err := b.routes.FindRoutes(r.Context(), q)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	page := routesPage{
+		Routes: make([]routeServer, 0),
+		Total:  total,
+		Limit:  q.Limit,
+		Offset: q.Offset,
+	}
 	for _, rt := range rts {
 		route := routeToRouteServer(rt)
-		rserver = append(rserver, route)
+		page.Routes = append(page.Routes, route)
+	}
+	if notModified(w, r, pageETag(page)) {
+		return
 	}
 
-	err = json.NewEncoder(w).Encode(rserver)
+	err = json.NewEncoder(w).Encode(page)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 }
 
 func (b *BusStation) getRoute(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	params := mux.Vars(r)
-	idparam := params["id"]
-	id, err := strconv.Atoi(idparam)
+	id, err := idParam(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
-	route, err := b.routes.GetRouteByID(id)
+	route, err := b.routes.GetRouteByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+	if notModified(w, r, etag(route.Version)) {
 		return
 	}
 	rserver := routeToRouteServer(*route)
 	err = json.NewEncoder(w).Encode(&rserver)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 }
@@ -75,91 +215,787 @@ func (b *BusStation) getRoute(w http.ResponseWriter, r *http.Request) {
 func (b *BusStation) createRoute(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var rserver routeServer
-	err := json.NewDecoder(r.Body).Decode(&rserver)
+	err := decodeJSON(r, &rserver)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
 	route := routeServerToRoute(rserver)
-	err = b.routes.CreateNewRoute(&route)
+	err = b.routes.CreateNewRoute(r.Context(), &route)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(route.Version))
+	rsencode := routeToRouteServer(route)
+	err = json.NewEncoder(w).Encode(&rsencode)
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+//updateRoute replaces route with version from If-Match header. Version of route is incremented
+//by bookings too, so If-Match: * can be sent to replace route regardless of its version.
+func (b *BusStation) updateRoute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := idParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var rserver routeServer
+	err = decodeJSON(r, &rserver)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	rserver.ID = id
+	b.saveRoute(w, r, routeServerToRoute(rserver), version)
+}
+
+func (b *BusStation) patchRoute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := idParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var patch interface{}
+	err = decodeJSON(r, &patch)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	route, err := b.routes.GetRouteByID(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if version == routemanager.AnyVersion {
+		//the patch is applied to the read route, so it mustn't be changed meanwhile
+		version = route.Version
+	}
+	original, err := json.Marshal(routeToRouteServer(*route))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var doc interface{}
+	err = json.Unmarshal(original, &doc)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	patched, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var rserver routeServer
+	err = invalidJSON(json.Unmarshal(patched, &rserver))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	rserver.ID = id
+	b.saveRoute(w, r, routeServerToRoute(rserver), version)
+}
+
+//saveRoute updates route which has version and writes updated route to response.
+func (b *BusStation) saveRoute(w http.ResponseWriter, r *http.Request, route domain.Route, version int) {
+	route.Version = version
+	err := b.routes.UpdateRoute(r.Context(), &route)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(route.Version))
 	rsencode := routeToRouteServer(route)
 	err = json.NewEncoder(w).Encode(&rsencode)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 	}
 }
 
 func (b *BusStation) deleteRoute(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	params := mux.Vars(r)
-	id, err := strconv.Atoi(params["id"])
+	id, err := idParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	version, err := ifMatch(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
-	err = b.routes.DeleteRouteByID(id)
+	version, err = b.routes.DeleteRouteByID(r.Context(), id, version)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(version))
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write([]byte("the route was deleted successfully"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
+		return
+	}
+}
+
+//restoreRoute returns deleted route back and responds with restored route.
+//If-Match header has to contain entity tag from response to deletion of the route.
+func (b *BusStation) restoreRoute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := idParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	route, err := b.routes.RestoreRoute(r.Context(), id, version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("ETag", etag(route.Version))
+	rserver := routeToRouteServer(*route)
+	err = json.NewEncoder(w).Encode(&rserver)
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+//changeRouteStatus moves route to status from request body.
+func (b *BusStation) changeRouteStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := idParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var sserver statusServer
+	err = decodeJSON(r, &sserver)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	route, err := b.routes.ChangeRouteStatus(r.Context(), id, version, sserver.Status,
+		time.Duration(sserver.Delay)*time.Minute)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
+	w.Header().Set("ETag", etag(route.Version))
+	rsencode := routeToRouteServer(*route)
+	err = json.NewEncoder(w).Encode(&rsencode)
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+//clockParam parses time of day query parameter in format hh:mm as offset from midnight.
+func clockParam(values url.Values, name string) (time.Duration, error) {
+	param := values.Get(name)
+	if param == "" {
+		return 0, nil
+	}
+	t, err := time.Parse("15:04", param)
+	if err != nil {
+		return 0, domain.Invalid(fmt.Sprintf("invalid %s argument", name))
+	}
+	return domain.TimeOfDay(t), nil
+}
+
+//parseSearchQuery gets points and departure period for route search from query parameters.
+//Parameter date selects one day, date_from and date_to select range of days.
+func parseSearchQuery(values url.Values) (domain.RouteQuery, error) {
+	q := domain.RouteQuery{
+		StartPoint: values.Get("startpoint"),
+		EndPoint:   values.Get("endpoint"),
+		Statuses:   statusParam(values),
+	}
+	if q.EndPoint == "" {
+		q.EndPoint = values.Get("point")
+	}
+
+	var err error
+	if values.Get("date") != "" {
+		values.Set("date_from", values.Get("date"))
+		values.Set("date_to", values.Get("date"))
+	}
+	if q.From, err = dateParam(values, "date_from"); err != nil {
+		return q, err
+	}
+	if q.To, err = dateParam(values, "date_to"); err != nil {
+		return q, err
+	}
+	if !q.To.IsZero() {
+		q.To = q.To.AddDate(0, 0, 1)
+	}
+	if q.DepartAfter, err = clockParam(values, "time_from"); err != nil {
+		return q, err
+	}
+	q.DepartBefore, err = clockParam(values, "time_to")
+	return q, err
 }
 
 func (b *BusStation) searchRoutes(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	params := mux.Vars(r)
-	searchDate := params["date"]
-	endpoint := params["point"]
-	date, err := time.Parse("2006-01-02", searchDate)
+	q, err := parseSearchQuery(r.URL.Query())
 	if err != nil {
-		http.Error(w, "Invalid date argument!", http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
-	routesDate, err := b.routes.ChooseRoutesByDateAndPoint(date, endpoint)
+	routes, err := b.routes.SearchRoutes(r.Context(), q)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 	rserver := make([]routeServer, 0)
-	for _, rt := range routesDate {
+	for _, rt := range routes {
 		route := routeToRouteServer(rt)
 		rserver = append(rserver, route)
 	}
 	err = json.NewEncoder(w).Encode(rserver)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
+		return
+	}
+}
+
+//parseJourneyQuery gets parameters of journey planning from query parameters.
+func parseJourneyQuery(values url.Values) (domain.JourneyQuery, error) {
+	q := domain.JourneyQuery{
+		From:   values.Get("from"),
+		To:     values.Get("to"),
+		RankBy: values.Get("sort"),
+	}
+	if q.RankBy == "" {
+		q.RankBy = domain.RankByArrival
+	}
+
+	date, err := time.Parse("2006-01-02", values.Get("date"))
+	if err != nil {
+		return q, domain.Invalid("invalid date argument")
+	}
+	q.Date = date
+	if q.MaxLegs, err = intParam(values, "max_legs", defaultLegs); err != nil {
+		return q, err
+	}
+	connection, err := intParam(values, "min_connection", defaultConnection)
+	q.MinConnection = time.Duration(connection) * time.Minute
+	return q, err
+}
+
+func (b *BusStation) planJourneys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q, err := parseJourneyQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	journeys, err := b.routes.PlanJourneys(r.Context(), q)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	jserver := make([]journeyServer, 0, len(journeys))
+	for _, j := range journeys {
+		jserver = append(jserver, journeyToJourneyServer(j))
+	}
+	err = json.NewEncoder(w).Encode(jserver)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 }
 
+func (b *BusStation) bookSeat(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	routeID, err := idParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var tserver ticketServer
+	err = decodeJSON(r, &tserver)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ticket := ticketServerToTicket(tserver)
+	ticket.RouteID = routeID
+	err = b.routes.BookSeat(r.Context(), &ticket)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	tsencode := ticketToTicketServer(ticket)
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(&tsencode)
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+func (b *BusStation) cancelBooking(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := idParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = b.routes.CancelBooking(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write([]byte("the booking was cancelled successfully"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+func (b *BusStation) getSchedules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	schedules, err := b.routes.GetAllSchedules(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sserver := make([]scheduleServer, 0, len(schedules))
+	for _, s := range schedules {
+		sserver = append(sserver, scheduleToScheduleServer(s))
+	}
+	err = json.NewEncoder(w).Encode(sserver)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+func (b *BusStation) getSchedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := idParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	schedule, err := b.routes.GetScheduleByID(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	sserver := scheduleToScheduleServer(*schedule)
+	err = json.NewEncoder(w).Encode(&sserver)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+func (b *BusStation) createSchedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var sserver scheduleServer
+	err := decodeJSON(r, &sserver)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	schedule, err := scheduleServerToSchedule(sserver)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	err = b.routes.CreateSchedule(r.Context(), &schedule)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ssencode := scheduleToScheduleServer(schedule)
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(&ssencode)
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+func (b *BusStation) updateSchedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := idParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var sserver scheduleServer
+	err = decodeJSON(r, &sserver)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	sserver.ID = id
+	schedule, err := scheduleServerToSchedule(sserver)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	err = b.routes.UpdateSchedule(r.Context(), &schedule)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ssencode := scheduleToScheduleServer(schedule)
+	err = json.NewEncoder(w).Encode(&ssencode)
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+func (b *BusStation) deleteSchedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := idParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = b.routes.DeleteScheduleByID(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write([]byte("the schedule was deleted successfully"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+//generateTrips runs generation of trips by schedules for configured horizon immediately.
+func (b *BusStation) generateTrips(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	horizon := time.Duration(b.config.ScheduleHorizon) * 24 * time.Hour
+	created, err := b.routes.GenerateTrips(r.Context(), time.Now(), horizon)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(map[string]int{"created": created})
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+//encodeAudit writes entries of audit log as JSON array.
+func encodeAudit(w http.ResponseWriter, entries []domain.AuditEntry) {
+	aserver := make([]auditServer, 0, len(entries))
+	for _, e := range entries {
+		aserver = append(aserver, auditToAuditServer(e))
+	}
+	err := json.NewEncoder(w).Encode(aserver)
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+//routeHistory responds with all changes of the route.
+func (b *BusStation) routeHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := idParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	entries, err := b.routes.RouteHistory(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	encodeAudit(w, entries)
+}
+
+//timeParam parses time query parameter in RFC 3339 format or date in format yyyy-mm-dd,
+//zero time is returned if parameter is absent.
+func timeParam(values url.Values, name string) (time.Time, error) {
+	param := values.Get(name)
+	if param == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		t, err := time.Parse(layout, param)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, domain.Invalid(fmt.Sprintf("invalid %s argument", name))
+}
+
+//parseAuditQuery gets filters and pagination of audit log from query parameters.
+func parseAuditQuery(values url.Values) (domain.AuditQuery, error) {
+	q := domain.AuditQuery{Actor: values.Get("actor")}
+
+	var err error
+	if q.From, err = timeParam(values, "from"); err != nil {
+		return q, err
+	}
+	if q.To, err = timeParam(values, "to"); err != nil {
+		return q, err
+	}
+	if q.Limit, err = intParam(values, "limit", defaultLimit); err != nil {
+		return q, err
+	}
+	if q.Limit == 0 || q.Limit > maxLimit {
+		return q, domain.Invalid("invalid limit argument")
+	}
+	q.Offset, err = intParam(values, "offset", 0)
+	return q, err
+}
+
+//getAudit responds with entries of audit log filtered by actor and time range.
+func (b *BusStation) getAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	entries, err := b.routes.AuditLog(r.Context(), q)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	encodeAudit(w, entries)
+}
+
+//encodeIdentity writes identity of client as JSON response.
+func encodeIdentity(w http.ResponseWriter, identity domain.Identity) {
+	err := json.NewEncoder(w).Encode(identityServer(identity))
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+//getIdentities responds with all clients which have roles.
+func (b *BusStation) getIdentities(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	identities, err := b.access.GetAllIdentities(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	iservers := make([]identityServer, 0, len(identities))
+	for _, identity := range identities {
+		iservers = append(iservers, identityServer(identity))
+	}
+	err = json.NewEncoder(w).Encode(iservers)
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+//getIdentity responds with roles of client by name.
+func (b *BusStation) getIdentity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	identity, err := b.access.GetIdentity(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	encodeIdentity(w, *identity)
+}
+
+//saveIdentity assigns roles from request body to client by name.
+func (b *BusStation) saveIdentity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var iserver identityServer
+	err := decodeJSON(r, &iserver)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	identity := domain.Identity{Name: mux.Vars(r)["name"], Roles: iserver.Roles}
+	err = b.access.SaveIdentity(r.Context(), &identity)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	encodeIdentity(w, identity)
+}
+
+//deleteIdentity removes all roles of client by name.
+func (b *BusStation) deleteIdentity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := b.access.DeleteIdentity(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write([]byte("the identity was deleted successfully"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+//withTimeout limits time of handling of request by configured timeout.
+//Queries to storage are cancelled when timeout expires or client disconnects.
+//Zero timeout doesn't limit time of handling.
+func (b *BusStation) withTimeout(next http.Handler) http.Handler {
+	timeout := time.Duration(b.config.RequestTimeout) * time.Second
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (b *BusStation) managerHandlers() *mux.Router {
+	everyone := []string{domain.RolePassenger, domain.RoleCashier, domain.RoleDispatcher}
+	sellers := []string{domain.RolePassenger, domain.RoleCashier}
+	dispatcher, cashier := domain.RoleDispatcher, domain.RoleCashier
+
 	router := mux.NewRouter()
-	router.HandleFunc("/route_search", b.searchRoutes).Queries("date", "{date}", "point", "{point}").
-		Methods(http.MethodGet)
-	router.HandleFunc("/routes", b.getRoutes).Methods(http.MethodGet)
-	router.HandleFunc("/routes", b.createRoute).Methods(http.MethodPost)
-	router.HandleFunc("/routes/{id}", b.getRoute).Methods(http.MethodGet)
-	router.HandleFunc("/routes/{id}", b.deleteRoute).Methods(http.MethodDelete)
+	router.NotFoundHandler = b.instrument(http.NotFoundHandler())
+	router.MethodNotAllowedHandler = b.instrument(http.HandlerFunc(methodNotAllowed))
+	router.Use(b.instrument)
+	router.Use(b.withTimeout)
+	if b.auth != nil {
+		router.Use(b.authenticate)
+	}
+	router.Use(b.limitRate)
+	router.Use(b.idempotent)
+	router.HandleFunc("/route_search", b.authorize(b.searchRoutes, everyone...)).Methods(http.MethodGet).
+		Name(nameSearchRoutes)
+	router.HandleFunc("/journeys", b.authorize(b.planJourneys, everyone...)).Methods(http.MethodGet).
+		Name(namePlanJourneys)
+	router.HandleFunc("/routes", b.authorize(b.getRoutes, everyone...)).Methods(http.MethodGet).Name(nameGetRoutes)
+	router.HandleFunc("/routes", b.authorize(b.createRoute, dispatcher)).Methods(http.MethodPost)
+	router.HandleFunc("/routes/{id}", b.authorize(b.getRoute, everyone...)).Methods(http.MethodGet).Name(nameGetRoute)
+	router.HandleFunc("/routes/{id}", b.authorize(b.updateRoute, dispatcher)).Methods(http.MethodPut)
+	router.HandleFunc("/routes/{id}", b.authorize(b.patchRoute, dispatcher)).Methods(http.MethodPatch)
+	router.HandleFunc("/routes/{id}", b.authorize(b.deleteRoute, dispatcher)).Methods(http.MethodDelete)
+	router.HandleFunc("/routes/{id}/status", b.authorize(b.changeRouteStatus, dispatcher)).Methods(http.MethodPut)
+	router.HandleFunc("/routes/{id}/restore", b.authorize(b.restoreRoute, dispatcher)).Methods(http.MethodPost)
+	router.HandleFunc("/routes/{id}/history", b.authorize(b.routeHistory, dispatcher)).Methods(http.MethodGet)
+	router.HandleFunc("/routes/{id}/bookings", b.authorize(b.bookSeat, sellers...)).Methods(http.MethodPost)
+	router.HandleFunc("/bookings/{id}", b.authorize(b.cancelBooking, cashier)).Methods(http.MethodDelete)
+	router.HandleFunc("/schedules", b.authorize(b.getSchedules, dispatcher)).Methods(http.MethodGet)
+	router.HandleFunc("/schedules", b.authorize(b.createSchedule, dispatcher)).Methods(http.MethodPost)
+	router.HandleFunc("/schedules/generate", b.authorize(b.generateTrips, dispatcher)).Methods(http.MethodPost)
+	router.HandleFunc("/schedules/{id}", b.authorize(b.getSchedule, dispatcher)).Methods(http.MethodGet)
+	router.HandleFunc("/schedules/{id}", b.authorize(b.updateSchedule, dispatcher)).Methods(http.MethodPut)
+	router.HandleFunc("/schedules/{id}", b.authorize(b.deleteSchedule, dispatcher)).Methods(http.MethodDelete)
+	router.HandleFunc("/audit", b.authorize(b.getAudit)).Methods(http.MethodGet)
+	router.HandleFunc("/identities", b.authorize(b.getIdentities)).Methods(http.MethodGet)
+	router.HandleFunc("/identities/{name}", b.authorize(b.getIdentity)).Methods(http.MethodGet)
+	router.HandleFunc("/identities/{name}", b.authorize(b.saveIdentity)).Methods(http.MethodPut)
+	router.HandleFunc("/identities/{name}", b.authorize(b.deleteIdentity)).Methods(http.MethodDelete)
 	return router
 }
 
+//handler returns handler of endpoints of API and of health and metrics endpoints
+//which don't require authentication and aren't rate limited.
+func (b *BusStation) handler() http.Handler {
+	root := http.NewServeMux()
+	root.HandleFunc("/healthz", b.healthz)
+	root.HandleFunc("/readyz", b.readyz)
+	if b.metrics != nil {
+		root.Handle("/metrics", b.metrics.handler())
+	}
+	root.Handle("/", b.managerHandlers())
+	return root
+}
+
 //StartServer - Start work with server
+//Authentication is enabled if keys of clients are configured.
+//Server is stopped gracefully on SIGINT or SIGTERM.
 func (b *BusStation) StartServer() {
+	auth, err := newAuthenticator(b.config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if auth == nil {
+		log.Println("authentication is disabled, all endpoints are available without credentials")
+	}
+	b.auth = auth
+
+	srv := &http.Server{Addr: ":" + strconv.Itoa(b.config.PortServer), Handler: b.handler()}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		log.Println("shutting down server")
+		err := b.shutdown(srv)
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
 	fmt.Printf("Started server at http://localhost%v.\n", ":"+strconv.Itoa(b.config.PortServer))
-	router := b.managerHandlers()
-	log.Fatal(http.ListenAndServe(":"+strconv.Itoa(b.config.PortServer), router))
+	err = srv.ListenAndServe()
+	if err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-stopped
 }
@@ -1,8 +1,9 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,12 +11,39 @@ import (
 
 	"github.com/gavv/httpexpect"
 
+	"github.com/JaneKetko/Buses/src/accessmanager"
 	"github.com/JaneKetko/Buses/src/config"
 	"github.com/JaneKetko/Buses/src/domain"
 	"github.com/JaneKetko/Buses/src/routemanager"
 	"github.com/JaneKetko/Buses/src/routemanager/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
+//runInTransaction makes mock storage call functions passed to InTransaction.
+func runInTransaction(routestrg *mocks.RouteStorage) {
+	routestrg.On("InTransaction", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+}
+
+//newAdminServer creates server of bus station where requests are made by admin "root"
+//authenticated by API key.
+func newAdminServer(t *testing.T, b *BusStation) (*httptest.Server, *httpexpect.Expect) {
+	b.config.APIKeys, b.config.Admins = []string{"root:r"}, []string{"root"}
+	var err error
+	b.auth, err = newAuthenticator(b.config)
+	require.NoError(t, err)
+	b.access = accessmanager.NewAccessManager(nil, b.config.Admins)
+
+	server := httptest.NewServer(b.managerHandlers())
+	return server, httpexpect.New(t, server.URL).Builder(func(r *httpexpect.Request) {
+		r.WithHeader("X-API-Key", "r")
+	})
+}
+
 func TestGetRoutes(t *testing.T) {
 
 	cfg := &config.Config{
@@ -23,12 +51,10 @@ func TestGetRoutes(t *testing.T) {
 	}
 	var routestrg mocks.RouteStorage
 	routeman := routemanager.NewRouteManager(&routestrg)
-	busstation := NewBusStation(routeman, cfg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
 
-	s := busstation.managerHandlers()
-	server := httptest.NewServer(s)
+	server, e := newAdminServer(t, busstation)
 	defer server.Close()
-	e := httpexpect.New(t, server.URL)
 
 	routes := []domain.Route{
 		{
@@ -38,52 +64,94 @@ func TestGetRoutes(t *testing.T) {
 				EndPoint:   "Minsk",
 			},
 			Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
 	}
 	testCases := []struct {
 		name           string
+		query          string
+		routeQuery     domain.RouteQuery
 		expectedStatus int
 		expectedRoutes []domain.Route
+		expectedTotal  int
 		expectedError  error
 	}{
 		{
 			name:           "successful test",
+			query:          "",
+			routeQuery:     domain.RouteQuery{Limit: defaultLimit},
+			expectedStatus: http.StatusOK,
+			expectedRoutes: routes,
+			expectedTotal:  1,
+			expectedError:  nil,
+		},
+		{
+			name: "filters and pagination",
+			query: "startpoint=Vitebsk&endpoint=Minsk&date_from=2019-04-01&date_to=2019-04-30" +
+				"&min_freeseats=2&max_cost=10.5&sort=-cost&limit=1&offset=3",
+			routeQuery: domain.RouteQuery{
+				StartPoint:   "Vitebsk",
+				EndPoint:     "Minsk",
+				From:         time.Date(2019, 04, 1, 0, 0, 0, 0, time.UTC),
+				To:           time.Date(2019, 05, 1, 0, 0, 0, 0, time.UTC),
+				MinFreeSeats: 2,
+				MaxCost:      1050,
+				SortBy:       domain.SortByCost,
+				Desc:         true,
+				Limit:        1,
+				Offset:       3,
+			},
 			expectedStatus: http.StatusOK,
 			expectedRoutes: routes,
+			expectedTotal:  4,
 			expectedError:  nil,
 		},
 		{
 			name:           "errors",
+			query:          "endpoint=Mir",
+			routeQuery:     domain.RouteQuery{EndPoint: "Mir", Limit: defaultLimit},
 			expectedStatus: http.StatusInternalServerError,
 			expectedRoutes: nil,
 			expectedError:  errors.New("smth bad"),
 		},
+		{
+			name:           "invalid sort key",
+			query:          "sort=id",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid limit",
+			query:          "limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid date",
+			query:          "date_from=2019-04",
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
-	routestrg.On("GetAllData").Return(testCases[0].expectedRoutes, testCases[0].expectedError)
-
-	t.Run(testCases[0].name, func(t *testing.T) {
-		res := e.Request(http.MethodGet, "/routes").Expect()
-		res.Status(testCases[0].expectedStatus)
-	})
-
-	var rtstrg mocks.RouteStorage
-	routeman = routemanager.NewRouteManager(&rtstrg)
-	busstation = NewBusStation(routeman, cfg)
-
-	s = busstation.managerHandlers()
-	server = httptest.NewServer(s)
-	defer server.Close()
-	e = httpexpect.New(t, server.URL)
+	for _, tc := range testCases {
+		routestrg.On("RoutesByQuery", mock.Anything, tc.routeQuery).
+			Return(tc.expectedRoutes, tc.expectedTotal, tc.expectedError)
+	}
 
-	rtstrg.On("GetAllData").Return(testCases[1].expectedRoutes, testCases[1].expectedError)
-	t.Run(testCases[1].name, func(t *testing.T) {
-		res := e.Request(http.MethodGet, "/routes").Expect()
-		res.Status(testCases[1].expectedStatus)
-	})
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := e.Request(http.MethodGet, "/routes").WithQueryString(tc.query).Expect()
+			res.Status(tc.expectedStatus)
+			if tc.expectedStatus == http.StatusOK {
+				obj := res.JSON().Object()
+				obj.ValueEqual("total", tc.expectedTotal)
+				obj.ValueEqual("limit", tc.routeQuery.Limit)
+				obj.ValueEqual("offset", tc.routeQuery.Offset)
+				obj.Value("routes").Array().Length().Equal(len(tc.expectedRoutes))
+			}
+		})
+	}
 }
 
 func TestGetRoute(t *testing.T) {
@@ -93,12 +161,10 @@ func TestGetRoute(t *testing.T) {
 	}
 	var routestrg mocks.RouteStorage
 	routeman := routemanager.NewRouteManager(&routestrg)
-	busstation := NewBusStation(routeman, cfg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
 
-	s := busstation.managerHandlers()
-	server := httptest.NewServer(s)
+	server, e := newAdminServer(t, busstation)
 	defer server.Close()
-	e := httpexpect.New(t, server.URL)
 
 	routes := []domain.Route{
 		{
@@ -108,7 +174,7 @@ func TestGetRoute(t *testing.T) {
 				EndPoint:   "Minsk",
 			},
 			Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
@@ -134,20 +200,20 @@ func TestGetRoute(t *testing.T) {
 			name:           "no route",
 			routeID:        2,
 			paramID:        "2",
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusNotFound,
 			expectedRoute:  nil,
-			expectedError:  errors.New("no such route"),
+			expectedError:  domain.NotFound("no such route"),
 		},
 		{
 			name:           "invalid id",
 			paramID:        "df2",
 			expectedStatus: http.StatusBadRequest,
 			expectedRoute:  nil,
-			expectedError:  errors.New("no such route"),
+			expectedError:  domain.NotFound("no such route"),
 		},
 	}
 	for _, tc := range testCases {
-		routestrg.On("RouteByID", tc.routeID).Return(tc.expectedRoute, tc.expectedError)
+		routestrg.On("RouteByID", mock.Anything, tc.routeID).Return(tc.expectedRoute, tc.expectedError)
 	}
 
 	for _, tc := range testCases {
@@ -159,18 +225,97 @@ func TestGetRoute(t *testing.T) {
 	}
 }
 
+func TestConditionalGet(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	route := domain.Route{
+		ID:        1,
+		Points:    domain.Points{StartPoint: "Vitebsk", EndPoint: "Minsk"},
+		Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+		Version:   3,
+	}
+	changed := route
+	changed.Version = 4
+	routestrg.On("RouteByID", mock.Anything, 1).Return(&route, nil)
+	routestrg.On("RoutesByQuery", mock.Anything, domain.RouteQuery{Limit: defaultLimit}).
+		Return([]domain.Route{route}, 1, nil).Once()
+	routestrg.On("RoutesByQuery", mock.Anything, domain.RouteQuery{Limit: defaultLimit}).
+		Return([]domain.Route{route}, 1, nil).Once()
+	routestrg.On("RoutesByQuery", mock.Anything, domain.RouteQuery{Limit: defaultLimit}).
+		Return([]domain.Route{changed}, 1, nil).Once()
+
+	res := e.Request(http.MethodGet, "/routes/1").Expect()
+	res.Status(http.StatusOK)
+	res.Header("ETag").Equal(`"3"`)
+	res.JSON().Object().ValueEqual("version", 3)
+
+	testCases := []struct {
+		name           string
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{
+			name:           "same version",
+			ifNoneMatch:    `"3"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "weak tag in list",
+			ifNoneMatch:    `"1", W/"3"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "any tag",
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "other version",
+			ifNoneMatch:    `"2"`,
+			expectedStatus: http.StatusOK,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := e.Request(http.MethodGet, "/routes/1").WithHeader("If-None-Match", tc.ifNoneMatch).Expect()
+			res.Status(tc.expectedStatus)
+			res.Header("ETag").Equal(`"3"`)
+		})
+	}
+
+	page := e.Request(http.MethodGet, "/routes").Expect()
+	page.Status(http.StatusOK)
+	tag := page.Header("ETag").NotEmpty().Raw()
+	e.Request(http.MethodGet, "/routes").WithHeader("If-None-Match", tag).Expect().
+		Status(http.StatusNotModified).Body().Empty()
+	e.Request(http.MethodGet, "/routes").WithHeader("If-None-Match", tag).Expect().
+		Status(http.StatusOK).Header("ETag").NotEqual(tag)
+}
+
 func TestCreateRoute(t *testing.T) {
 	cfg := &config.Config{
 		PortServer: 8000,
 	}
 	var routestrg mocks.RouteStorage
 	routeman := routemanager.NewRouteManager(&routestrg)
-	busstation := NewBusStation(routeman, cfg)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
 
-	s := busstation.managerHandlers()
-	server := httptest.NewServer(s)
+	server, e := newAdminServer(t, busstation)
 	defer server.Close()
-	e := httpexpect.New(t, server.URL)
 
 	routes := []domain.Route{
 		{
@@ -179,7 +324,7 @@ func TestCreateRoute(t *testing.T) {
 				EndPoint:   "Minsk",
 			},
 			Start:     time.Date(2002, 04, 23, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
@@ -188,10 +333,22 @@ func TestCreateRoute(t *testing.T) {
 				StartPoint: "Grodno",
 				EndPoint:   "Minsk",
 			},
-			Start:     time.Date(2021, 04, 12, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Start:     time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
+			Status:    domain.StatusScheduled,
+			Version:   1,
+		},
+		{
+			Points: domain.Points{
+				StartPoint: "Grodno",
+				EndPoint:   "Lida",
+			},
+			Start:     time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 14,
+			AllSeats:  13,
 		},
 	}
 	testCases := []struct {
@@ -206,7 +363,12 @@ func TestCreateRoute(t *testing.T) {
 			route:          &routes[0],
 			expectedStatus: http.StatusBadRequest,
 			expectedID:     1,
-			expectedError:  errors.New("date is invalid"),
+			expectedError:  domain.Invalid("date is invalid"),
+		},
+		{
+			name:           "overbooked route",
+			route:          &routes[2],
+			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name:           "successful test",
@@ -218,7 +380,7 @@ func TestCreateRoute(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		routestrg.On("AddRoute",
+		routestrg.On("AddRoute", mock.Anything,
 			tc.route).
 			Return(tc.expectedID, tc.expectedError)
 	}
@@ -239,17 +401,18 @@ func TestDeleteRoute(t *testing.T) {
 	}
 	var routestrg mocks.RouteStorage
 	routeman := routemanager.NewRouteManager(&routestrg)
-	busstation := NewBusStation(routeman, cfg)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
 
-	s := busstation.managerHandlers()
-	server := httptest.NewServer(s)
+	server, e := newAdminServer(t, busstation)
 	defer server.Close()
-	e := httpexpect.New(t, server.URL)
 
 	testCases := []struct {
 		name           string
 		routeID        int
 		paramID        string
+		ifMatch        string
 		expectedStatus int
 		expectedError  error
 	}{
@@ -257,6 +420,7 @@ func TestDeleteRoute(t *testing.T) {
 			name:           "successful test",
 			routeID:        1,
 			paramID:        "1",
+			ifMatch:        `"1"`,
 			expectedStatus: http.StatusOK,
 			expectedError:  nil,
 		},
@@ -264,26 +428,280 @@ func TestDeleteRoute(t *testing.T) {
 			name:           "no route",
 			routeID:        2,
 			paramID:        "2",
-			expectedStatus: http.StatusInternalServerError,
-			expectedError:  errors.New("no such route"),
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  domain.NotFound("no such route"),
+		},
+		{
+			name:           "invalid id",
+			paramID:        "df2",
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  domain.NotFound("no such route"),
+		},
+		{
+			name:           "no If-Match",
+			routeID:        3,
+			paramID:        "3",
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+		{
+			name:           "other version",
+			routeID:        4,
+			paramID:        "4",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "invalid If-Match",
+			routeID:        5,
+			paramID:        "5",
+			ifMatch:        "1",
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		routestrg.On("RouteByID", mock.Anything, tc.routeID).
+			Return(&domain.Route{ID: tc.routeID, Version: 1}, tc.expectedError)
+		routestrg.On("DeleteRow", mock.Anything, tc.routeID, 1).Return(nil)
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := e.Request(http.MethodDelete, "/routes/"+tc.paramID)
+			if tc.ifMatch != "" {
+				req = req.WithHeader("If-Match", tc.ifMatch)
+			}
+			res := req.Expect()
+			res.Status(tc.expectedStatus)
+			if tc.expectedStatus == http.StatusOK {
+				res.Header("ETag").Equal(`"2"`)
+			}
+		})
+	}
+}
+
+func TestRestoreRoute(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	route := domain.Route{
+		ID:        1,
+		Points:    domain.Points{StartPoint: "Grodno", EndPoint: "Minsk"},
+		Start:     time.Date(2019, 04, 12, 10, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+		Status:    domain.StatusScheduled,
+		Version:   3,
+	}
+	routestrg.On("RestoreRoute", mock.Anything, 1, 2).Return(nil)
+	routestrg.On("RestoreRoute", mock.Anything, 1, 1).Return(domain.PreconditionFailed("route was changed"))
+	routestrg.On("RestoreRoute", mock.Anything, 2, 2).Return(domain.NotFound("no such deleted route"))
+	routestrg.On("RouteByID", mock.Anything, 1).Return(&route, nil)
+
+	testCases := []struct {
+		name           string
+		paramID        string
+		ifMatch        string
+		expectedStatus int
+	}{
+		{
+			name:           "successful test",
+			paramID:        "1",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "no deleted route",
+			paramID:        "2",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			paramID:        "df2",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "other version",
+			paramID:        "1",
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "no If-Match",
+			paramID:        "1",
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := e.Request(http.MethodPost, "/routes/"+tc.paramID+"/restore")
+			if tc.ifMatch != "" {
+				req = req.WithHeader("If-Match", tc.ifMatch)
+			}
+			res := req.Expect()
+			res.Status(tc.expectedStatus)
+			if tc.expectedStatus == http.StatusOK {
+				res.Header("ETag").Equal(`"3"`)
+				res.JSON().Object().ValueEqual("id", 1).ValueEqual("status", domain.StatusScheduled).
+					ValueEqual("version", 3)
+			}
+		})
+	}
+}
+
+func TestRouteHistory(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	route := domain.Route{
+		ID:        1,
+		Points:    domain.Points{StartPoint: "Grodno", EndPoint: "Minsk"},
+		Start:     time.Date(2019, 04, 12, 10, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+		Status:    domain.StatusScheduled,
+	}
+	snapshot, err := encodeSnapshot(&route)
+	require.NoError(t, err)
+	entries := []domain.AuditEntry{
+		{ID: 1, RouteID: 1, Action: domain.ActionCreate, Actor: "admin",
+			Time: time.Date(2019, 04, 10, 9, 0, 0, 0, time.UTC), After: snapshot},
+		{ID: 3, RouteID: 1, Action: domain.ActionDelete, Actor: "dispatcher",
+			Time: time.Date(2019, 04, 11, 9, 0, 0, 0, time.UTC), Before: snapshot},
+	}
+	routestrg.On("AuditEntries", mock.Anything, domain.AuditQuery{RouteID: 1}).Return(entries, nil)
+	routestrg.On("AuditEntries", mock.Anything, domain.AuditQuery{RouteID: 2}).Return(nil, nil)
+
+	testCases := []struct {
+		name           string
+		paramID        string
+		expectedStatus int
+		expectedLen    int
+	}{
+		{
+			name:           "successful test",
+			paramID:        "1",
+			expectedStatus: http.StatusOK,
+			expectedLen:    2,
+		},
+		{
+			name:           "no history",
+			paramID:        "2",
+			expectedStatus: http.StatusOK,
 		},
 		{
 			name:           "invalid id",
 			paramID:        "df2",
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  errors.New("no such route"),
 		},
 	}
 
 	for _, tc := range testCases {
-		routestrg.On("DeleteRow", tc.routeID).Return(tc.expectedError)
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := e.Request(http.MethodGet, "/routes/"+tc.paramID+"/history").Expect()
+			res.Status(tc.expectedStatus)
+			if tc.expectedStatus == http.StatusOK {
+				res.JSON().Array().Length().Equal(tc.expectedLen)
+			}
+		})
+	}
+
+	res := e.Request(http.MethodGet, "/routes/1/history").Expect().Status(http.StatusOK).JSON().Array()
+	created := res.Element(0).Object()
+	created.ValueEqual("action", domain.ActionCreate).ValueEqual("actor", "admin").
+		ValueEqual("time", "2019-04-10T09:00:00Z").ValueEqual("before", nil)
+	created.Value("after").Object().ValueEqual("id", 1).ValueEqual("status", domain.StatusScheduled)
+	res.Element(1).Object().ValueEqual("action", domain.ActionDelete).ValueEqual("after", nil)
+}
+
+func TestGetAudit(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	entries := []domain.AuditEntry{{ID: 1, RouteID: 1, Action: domain.ActionCreate, Actor: "admin",
+		Time: time.Date(2019, 04, 10, 9, 0, 0, 0, time.UTC)}}
+	routestrg.On("AuditEntries", mock.Anything, domain.AuditQuery{Limit: 50}).Return(entries, nil)
+	routestrg.On("AuditEntries", mock.Anything, domain.AuditQuery{
+		Actor:  "admin",
+		From:   time.Date(2019, 04, 10, 0, 0, 0, 0, time.UTC),
+		To:     time.Date(2019, 04, 10, 12, 30, 0, 0, time.UTC),
+		Limit:  10,
+		Offset: 5,
+	}).Return(entries, nil)
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name:           "all entries",
+			query:          "",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "filters",
+			query:          "actor=admin&from=2019-04-10&to=2019-04-10T12:30:00Z&limit=10&offset=5",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid time",
+			query:          "from=10.04.2019",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid time range",
+			query:          "from=2019-04-10&to=2019-04-09",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid limit",
+			query:          "limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			res := e.Request(http.MethodDelete, "/routes/"+tc.paramID).Expect()
+			res := e.Request(http.MethodGet, "/audit").WithQueryString(tc.query).Expect()
 			res.Status(tc.expectedStatus)
+			if tc.expectedStatus == http.StatusOK {
+				res.JSON().Array().Element(0).Object().ValueEqual("actor", "admin").ValueEqual("route_id", 1)
+			}
 		})
 	}
 }
@@ -294,12 +712,10 @@ func TestSearchRoutes(t *testing.T) {
 	}
 	var routestrg mocks.RouteStorage
 	routeman := routemanager.NewRouteManager(&routestrg)
-	busstation := NewBusStation(routeman, cfg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
 
-	s := busstation.managerHandlers()
-	server := httptest.NewServer(s)
+	server, e := newAdminServer(t, busstation)
 	defer server.Close()
-	e := httpexpect.New(t, server.URL)
 
 	routes := []domain.Route{
 		{
@@ -308,8 +724,8 @@ func TestSearchRoutes(t *testing.T) {
 				StartPoint: "Vitebsk",
 				EndPoint:   "Minsk",
 			},
-			Start:     time.Date(2019, 04, 23, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Start:     time.Date(2019, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
@@ -319,19 +735,8 @@ func TestSearchRoutes(t *testing.T) {
 				StartPoint: "Grodno",
 				EndPoint:   "Minsk",
 			},
-			Start:     time.Date(2019, 04, 12, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
-			FreeSeats: 12,
-			AllSeats:  13,
-		},
-		{
-			ID: 3,
-			Points: domain.Points{
-				StartPoint: "Pinsk",
-				EndPoint:   "Mir",
-			},
-			Start:     time.Date(2019, 04, 10, 10, 0, 0, 0, time.UTC),
-			Cost:      1000,
+			Start:     time.Date(2019, 04, 12, 18, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
 			FreeSeats: 12,
 			AllSeats:  13,
 		},
@@ -339,48 +744,1161 @@ func TestSearchRoutes(t *testing.T) {
 
 	testCases := []struct {
 		name           string
-		date           string
-		endPoint       string
+		query          string
+		routeQuery     domain.RouteQuery
 		expectedStatus int
 		expectedRoutes []domain.Route
 		expectedError  error
 	}{
 		{
-			name:           "successful test",
-			date:           "2019-04-12",
-			endPoint:       "Minsk",
+			name:  "successful test",
+			query: "date=2019-04-12&point=Minsk",
+			routeQuery: domain.RouteQuery{
+				EndPoint: "Minsk",
+				From:     time.Date(2019, 04, 12, 0, 0, 0, 0, time.UTC),
+				To:       time.Date(2019, 04, 13, 0, 0, 0, 0, time.UTC),
+				Statuses: domain.ActiveStatuses(),
+				SortBy:   domain.SortByStart,
+			},
+			expectedStatus: http.StatusOK,
+			expectedRoutes: routes,
+			expectedError:  nil,
+		},
+		{
+			name:  "by both points, date range and time",
+			query: "startpoint=Grodno&endpoint=Minsk&date_from=2019-04-10&date_to=2019-04-12&time_from=12:00&time_to=20:30",
+			routeQuery: domain.RouteQuery{
+				StartPoint:   "Grodno",
+				EndPoint:     "Minsk",
+				From:         time.Date(2019, 04, 10, 0, 0, 0, 0, time.UTC),
+				To:           time.Date(2019, 04, 13, 0, 0, 0, 0, time.UTC),
+				DepartAfter:  12 * time.Hour,
+				DepartBefore: 20*time.Hour + 30*time.Minute,
+				Statuses:     domain.ActiveStatuses(),
+				SortBy:       domain.SortByStart,
+			},
 			expectedStatus: http.StatusOK,
-			expectedRoutes: routes[:2],
+			expectedRoutes: routes[1:],
 			expectedError:  nil,
 		},
 		{
-			name:           "no routes by endpoint",
-			date:           "2019-04-10",
-			endPoint:       "Grodno",
-			expectedStatus: http.StatusInternalServerError,
+			name:  "no routes",
+			query: "startpoint=Grodno",
+			routeQuery: domain.RouteQuery{StartPoint: "Grodno", Statuses: domain.ActiveStatuses(),
+				SortBy: domain.SortByStart},
+			expectedStatus: http.StatusOK,
 			expectedRoutes: nil,
-			expectedError:  errors.New("no such routes by this endpoint"),
+			expectedError:  nil,
+		},
+		{
+			name:  "by statuses",
+			query: "point=Minsk&status=cancelled,departed",
+			routeQuery: domain.RouteQuery{EndPoint: "Minsk",
+				Statuses: []string{domain.StatusCancelled, domain.StatusDeparted}, SortBy: domain.SortByStart},
+			expectedStatus: http.StatusOK,
+			expectedRoutes: routes[:1],
+			expectedError:  nil,
+		},
+		{
+			name:           "invalid status",
+			query:          "point=Minsk&status=lost",
+			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name:           "invalid date argument",
-			date:           "2019-04",
-			endPoint:       "Grodno",
+			query:          "date=2019-04&point=Grodno",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid time argument",
+			query:          "point=Grodno&time_from=25:00",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "no point",
+			query:          "date=2019-04-12",
 			expectedStatus: http.StatusBadRequest,
-			expectedRoutes: nil,
-			expectedError:  errors.New("no such routes by this endpoint"),
 		},
 	}
 
 	for _, tc := range testCases {
-		routestrg.On("RoutesByEndPoint", tc.endPoint).Return(tc.expectedRoutes, tc.expectedError)
+		routestrg.On("RoutesByQuery", mock.Anything, tc.routeQuery).Return(tc.expectedRoutes, len(tc.expectedRoutes),
+			tc.expectedError)
 	}
 
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			res := e.Request(http.MethodGet, "/route_search").
-				WithQueryString(fmt.Sprintf("date=%s&point=%s", tc.date, tc.endPoint)).Expect()
+			res := e.Request(http.MethodGet, "/route_search").WithQueryString(tc.query).Expect()
 			res.Status(tc.expectedStatus)
+			if tc.expectedStatus == http.StatusOK {
+				res.JSON().Array().Length().Equal(len(tc.expectedRoutes))
+			}
 		})
 	}
 }
+
+func TestBookSeat(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	route := domain.Route{
+		ID: 1,
+		Points: domain.Points{
+			StartPoint: "Grodno",
+			EndPoint:   "Minsk",
+		},
+		Start:     time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+	}
+
+	testCases := []struct {
+		name           string
+		routeID        int
+		paramID        string
+		passenger      string
+		expectedStatus int
+		expectedID     int
+		expectedError  error
+	}{
+		{
+			name:           "successful test",
+			routeID:        1,
+			paramID:        "1",
+			passenger:      "Ivanov",
+			expectedStatus: http.StatusCreated,
+			expectedID:     7,
+			expectedError:  nil,
+		},
+		{
+			name:           "no free seats",
+			routeID:        1,
+			paramID:        "1",
+			passenger:      "Petrov",
+			expectedStatus: http.StatusConflict,
+			expectedError:  domain.Conflict("no free seats"),
+		},
+		{
+			name:           "invalid id",
+			paramID:        "df2",
+			passenger:      "Ivanov",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	routestrg.On("RouteByID", mock.Anything, 1).Return(&route, nil)
+	for _, tc := range testCases {
+		tc := tc
+		routestrg.On("BookSeat", mock.Anything, mock.MatchedBy(func(t *domain.Ticket) bool {
+			return t.Passenger == tc.passenger && t.RouteID == tc.routeID
+		})).Return(tc.expectedID, tc.expectedError)
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := e.Request(http.MethodPost, "/routes/"+tc.paramID+"/bookings").
+				WithJSON(ticketServer{Passenger: tc.passenger}).Expect()
+			res.Status(tc.expectedStatus)
+			if tc.expectedStatus == http.StatusCreated {
+				res.JSON().Object().ValueEqual("id", tc.expectedID).ValueEqual("route_id", tc.routeID)
+			}
+		})
+	}
+}
+
+func TestCancelBooking(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	testCases := []struct {
+		name           string
+		ticketID       int
+		paramID        string
+		expectedStatus int
+		expectedError  error
+	}{
+		{
+			name:           "successful test",
+			ticketID:       1,
+			paramID:        "1",
+			expectedStatus: http.StatusOK,
+			expectedError:  nil,
+		},
+		{
+			name:           "no ticket",
+			ticketID:       2,
+			paramID:        "2",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  domain.NotFound("no such ticket"),
+		},
+		{
+			name:           "invalid id",
+			paramID:        "df2",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		routestrg.On("CancelBooking", mock.Anything, tc.ticketID).Return(tc.expectedError)
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := e.Request(http.MethodDelete, "/bookings/"+tc.paramID).Expect()
+			res.Status(tc.expectedStatus)
+		})
+	}
+}
+
+func TestUpdateRoute(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	routes := []domain.Route{
+		{
+			ID: 1,
+			Points: domain.Points{
+				StartPoint: "Vitebsk",
+				EndPoint:   "Minsk",
+			},
+			Start:     time.Date(2002, 04, 23, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+			AllSeats:  13,
+			Version:   1,
+		},
+		{
+			ID: 2,
+			Points: domain.Points{
+				StartPoint: "Grodno",
+				EndPoint:   "Minsk",
+			},
+			Start:     time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+			AllSeats:  13,
+			Version:   1,
+		},
+		{
+			ID: 3,
+			Points: domain.Points{
+				StartPoint: "Grodno",
+				EndPoint:   "Mir",
+			},
+			Start:     time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC),
+			Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+			FreeSeats: 12,
+			AllSeats:  13,
+			Version:   1,
+		},
+	}
+	cancelled := routes[1]
+	cancelled.ID, cancelled.Status = 4, domain.StatusCancelled
+	testCases := []struct {
+		name           string
+		route          *domain.Route
+		paramID        string
+		ifMatch        string
+		expectedStatus int
+		expectedError  error
+	}{
+		{
+			name:           "invalid date",
+			route:          &routes[0],
+			paramID:        "1",
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "successful test",
+			route:          &routes[1],
+			paramID:        "2",
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusOK,
+			expectedError:  nil,
+		},
+		{
+			name:           "no route",
+			route:          &routes[2],
+			paramID:        "3",
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  domain.NotFound("no such route"),
+		},
+		{
+			name:           "invalid id",
+			route:          &routes[1],
+			paramID:        "df2",
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "cancelled route",
+			route:          &cancelled,
+			paramID:        "4",
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "other version",
+			route:          &routes[1],
+			paramID:        "2",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "no If-Match",
+			route:          &routes[1],
+			paramID:        "2",
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+	}
+
+	routestrg.On("RouteByID", mock.Anything, 2).Return(&routes[1], nil)
+	routestrg.On("RouteByID", mock.Anything, 3).Return(&routes[2], nil)
+	routestrg.On("RouteByID", mock.Anything, 4).Return(&cancelled, nil)
+	for _, tc := range testCases {
+		routestrg.On("UpdateRoute", mock.Anything, tc.route).Return(tc.expectedError)
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rserver := routeToRouteServer(*tc.route)
+			rserver.ID = 0
+			req := e.Request(http.MethodPut, "/routes/"+tc.paramID).WithJSON(rserver)
+			if tc.ifMatch != "" {
+				req = req.WithHeader("If-Match", tc.ifMatch)
+			}
+			res := req.Expect()
+			res.Status(tc.expectedStatus)
+			if tc.expectedStatus == http.StatusOK {
+				res.Header("ETag").Equal(`"2"`)
+				res.JSON().Object().ValueEqual("version", 2)
+			}
+		})
+	}
+}
+
+func TestPatchRoute(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	start := time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC)
+	route := domain.Route{
+		ID: 1,
+		Points: domain.Points{
+			StartPoint: "Grodno",
+			EndPoint:   "Minsk",
+		},
+		Start:     start,
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+		Version:   1,
+	}
+	patched := route
+	patched.Points.EndPoint = "Mir"
+	patched.Cost.Amount = 1500
+
+	routestrg.On("RouteByID", mock.Anything, 1).Return(&route, nil)
+	routestrg.On("RouteByID", mock.Anything, 2).Return(nil, domain.NotFound("no such route"))
+	routestrg.On("UpdateRoute", mock.Anything, &patched).Return(nil)
+
+	testCases := []struct {
+		name           string
+		paramID        string
+		patch          map[string]interface{}
+		ifMatch        string
+		expectedStatus int
+	}{
+		{
+			name:    "successful test",
+			paramID: "1",
+			patch: map[string]interface{}{
+				"points": map[string]interface{}{"endpoint": "Mir"},
+				"cost":   15,
+			},
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "no route",
+			paramID:        "2",
+			patch:          map[string]interface{}{"cost": 15},
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid patch",
+			paramID:        "1",
+			patch:          map[string]interface{}{"cost": "free"},
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "other version",
+			paramID:        "1",
+			patch:          map[string]interface{}{"cost": 15},
+			ifMatch:        `"3"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "no If-Match",
+			paramID:        "1",
+			patch:          map[string]interface{}{"cost": 15},
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := e.Request(http.MethodPatch, "/routes/"+tc.paramID).
+				WithHeader("Content-Type", "application/merge-patch+json").WithJSON(tc.patch)
+			if tc.ifMatch != "" {
+				req = req.WithHeader("If-Match", tc.ifMatch)
+			}
+			res := req.Expect()
+			res.Status(tc.expectedStatus)
+			if tc.expectedStatus == http.StatusOK {
+				res.Header("ETag").Equal(`"2"`)
+				obj := res.JSON().Object()
+				obj.Value("points").Object().ValueEqual("startpoint", "Grodno").ValueEqual("endpoint", "Mir")
+				obj.ValueEqual("freeseats", 12)
+			}
+		})
+	}
+}
+
+func TestChangeRouteStatus(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	start := time.Date(time.Now().Year()+1, 04, 12, 10, 0, 0, 0, time.UTC)
+	route := domain.Route{
+		ID:        1,
+		Points:    domain.Points{StartPoint: "Grodno", EndPoint: "Minsk"},
+		Start:     start,
+		Cost:      domain.Money{Amount: 1000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+		Status:    domain.StatusScheduled,
+		Version:   1,
+	}
+	departed := route
+	departed.ID, departed.Status = 3, domain.StatusDeparted
+
+	routestrg.On("RouteByID", mock.Anything, 1).Return(&route, nil)
+	routestrg.On("RouteByID", mock.Anything, 2).Return(nil, domain.NotFound("no such route"))
+	routestrg.On("RouteByID", mock.Anything, 3).Return(&departed, nil)
+	routestrg.On("SetRouteStatus", mock.Anything, 1, 1, domain.StatusDelayed, 25*time.Minute).Return(nil)
+
+	testCases := []struct {
+		name           string
+		paramID        string
+		body           map[string]interface{}
+		ifMatch        string
+		expectedStatus int
+	}{
+		{
+			name:           "successful test",
+			paramID:        "1",
+			body:           map[string]interface{}{"status": "delayed", "delay": 25},
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "no route",
+			paramID:        "2",
+			body:           map[string]interface{}{"status": "cancelled"},
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "departed route",
+			paramID:        "3",
+			body:           map[string]interface{}{"status": "boarding"},
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "invalid status",
+			paramID:        "1",
+			body:           map[string]interface{}{"status": "lost"},
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid id",
+			paramID:        "df2",
+			body:           map[string]interface{}{"status": "cancelled"},
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "other version",
+			paramID:        "1",
+			body:           map[string]interface{}{"status": "cancelled"},
+			ifMatch:        `"5"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "no If-Match",
+			paramID:        "1",
+			body:           map[string]interface{}{"status": "cancelled"},
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := e.Request(http.MethodPut, "/routes/"+tc.paramID+"/status").WithJSON(tc.body)
+			if tc.ifMatch != "" {
+				req = req.WithHeader("If-Match", tc.ifMatch)
+			}
+			res := req.Expect()
+			res.Status(tc.expectedStatus)
+			if tc.expectedStatus == http.StatusOK {
+				res.Header("ETag").Equal(`"2"`)
+				obj := res.JSON().Object()
+				obj.ValueEqual("status", "delayed").ValueEqual("delay", 25)
+				obj.ValueEqual("expected_start", start.Add(25*time.Minute).Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	testCases := []struct {
+		name            string
+		header          string
+		expectedVersion int
+		expectedError   error
+	}{
+		{
+			name:            "strong tag",
+			header:          `"7"`,
+			expectedVersion: 7,
+		},
+		{
+			name:            "any version",
+			header:          "*",
+			expectedVersion: routemanager.AnyVersion,
+		},
+		{
+			name:          "no header",
+			expectedError: errPreconditionRequired,
+		},
+		{
+			name:          "weak tag",
+			header:        `W/"7"`,
+			expectedError: domain.ErrPrecondition,
+		},
+		{
+			name:          "unquoted tag",
+			header:        "7",
+			expectedError: domain.ErrPrecondition,
+		},
+		{
+			name:          "not a version",
+			header:        `"abc"`,
+			expectedError: domain.ErrPrecondition,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/routes/1", nil)
+			if tc.header != "" {
+				r.Header.Set("If-Match", tc.header)
+			}
+			version, err := ifMatch(r)
+			assert.Equal(t, tc.expectedVersion, version)
+			assert.Equal(t, tc.expectedError, domain.ErrorKind(err))
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		target   interface{}
+		patch    interface{}
+		expected interface{}
+	}{
+		{
+			name:     "replace value",
+			target:   map[string]interface{}{"a": "b"},
+			patch:    map[string]interface{}{"a": "c"},
+			expected: map[string]interface{}{"a": "c"},
+		},
+		{
+			name:     "remove value",
+			target:   map[string]interface{}{"a": "b", "b": "c"},
+			patch:    map[string]interface{}{"a": nil},
+			expected: map[string]interface{}{"b": "c"},
+		},
+		{
+			name:     "nested object",
+			target:   map[string]interface{}{"a": map[string]interface{}{"b": "c", "d": "e"}},
+			patch:    map[string]interface{}{"a": map[string]interface{}{"d": "f"}},
+			expected: map[string]interface{}{"a": map[string]interface{}{"b": "c", "d": "f"}},
+		},
+		{
+			name:     "not object patch",
+			target:   map[string]interface{}{"a": "b"},
+			patch:    []interface{}{"c"},
+			expected: []interface{}{"c"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, mergePatch(tc.target, tc.patch))
+		})
+	}
+}
+
+func TestPlanJourneys(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	day := time.Date(time.Now().Year()+1, 04, 12, 0, 0, 0, 0, time.UTC)
+	date := day.Format("2006-01-02")
+	routes := []domain.Route{
+		{
+			ID:        1,
+			Points:    domain.Points{StartPoint: "Brest", EndPoint: "Minsk"},
+			Start:     day.Add(8 * time.Hour),
+			Duration:  4 * time.Hour,
+			Cost:      domain.Money{Amount: 2000, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusScheduled,
+		},
+		{
+			ID:        2,
+			Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+			Start:     day.Add(13 * time.Hour),
+			Duration:  3 * time.Hour,
+			Cost:      domain.Money{Amount: 1050, Currency: "BYN"},
+			FreeSeats: 10,
+			Status:    domain.StatusScheduled,
+		},
+	}
+	routestrg.On("RoutesByQuery", mock.Anything, domain.RouteQuery{
+		From:     day.AddDate(0, 0, -1),
+		To:       day.AddDate(0, 0, defaultLegs),
+		Statuses: domain.ActiveStatuses(),
+		SortBy:   domain.SortByStart,
+	}).Return(routes, len(routes), nil)
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:           "successful test",
+			query:          "from=Brest&to=Vitebsk&date=" + date,
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name:           "long connection",
+			query:          "from=Brest&to=Vitebsk&min_connection=90&sort=cost&date=" + date,
+			expectedStatus: http.StatusOK,
+			expectedCount:  0,
+		},
+		{
+			name:           "invalid date argument",
+			query:          "from=Brest&to=Vitebsk&date=2019-04",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid rank key",
+			query:          "from=Brest&to=Vitebsk&sort=id&date=" + date,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := e.Request(http.MethodGet, "/journeys").WithQueryString(tc.query).Expect()
+			res.Status(tc.expectedStatus)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			journeys := res.JSON().Array()
+			journeys.Length().Equal(tc.expectedCount)
+			if tc.expectedCount > 0 {
+				j := journeys.Element(0).Object()
+				j.ValueEqual("transfers", 1)
+				j.ValueEqual("duration", 480)
+				j.ValueEqual("cost", "30.50")
+				legs := j.Value("legs").Array()
+				legs.Length().Equal(2)
+				leg := legs.Element(1).Object()
+				leg.ValueEqual("from", "Minsk")
+				leg.ValueEqual("to", "Vitebsk")
+				leg.ValueEqual("cost", "10.50")
+				leg.Value("route").Object().ValueEqual("id", 2)
+			}
+		})
+	}
+}
+
+func TestCreateRouteWithStops(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	start := time.Date(time.Now().Year()+1, 04, 12, 8, 0, 0, 0, time.UTC)
+	route := domain.Route{
+		Points: domain.Points{
+			StartPoint: "Brest",
+			EndPoint:   "Vitebsk",
+		},
+		Start:     start,
+		Cost:      domain.Money{Amount: 3000, Currency: "BYN"},
+		FreeSeats: 12,
+		AllSeats:  13,
+		Duration:  8 * time.Hour,
+		Stops: []domain.Stop{
+			{Point: "Brest", FreeSeats: 12},
+			{Point: "Minsk", Arrival: 4 * time.Hour, Departure: 4*time.Hour + 20*time.Minute, Fare: 2000,
+				FreeSeats: 12},
+			{Point: "Vitebsk", Arrival: 8 * time.Hour, Departure: 8 * time.Hour, Fare: 1000, FreeSeats: 12},
+		},
+		Status:  domain.StatusScheduled,
+		Version: 1,
+	}
+	routestrg.On("AddRoute", mock.Anything, &route).Return(1, nil)
+
+	body := map[string]interface{}{
+		"points":     map[string]interface{}{"startpoint": "Brest", "endpoint": "Vitebsk"},
+		"start_time": start,
+		"cost":       30,
+		"freeseats":  12,
+		"allseats":   13,
+		"duration":   480,
+		"stops": []map[string]interface{}{
+			{"point": "Brest"},
+			{"point": "Minsk", "arrival": 240, "departure": 260, "fare": 20},
+			{"point": "Vitebsk", "arrival": 480, "departure": 480, "fare": 10},
+		},
+	}
+	res := e.Request(http.MethodPost, "/routes").WithJSON(body).Expect()
+	res.Status(http.StatusOK)
+	stops := res.JSON().Object().Value("stops").Array()
+	stops.Length().Equal(3)
+	stops.Element(1).Object().ValueEqual("point", "Minsk").ValueEqual("freeseats", 12)
+
+	body["cost"] = 25
+	e.Request(http.MethodPost, "/routes").WithJSON(body).Expect().Status(http.StatusBadRequest)
+}
+
+func TestRouteCostIsExact(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.Anything).Return(1, nil)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	start := time.Date(time.Now().Year()+1, 04, 12, 8, 0, 0, 0, time.UTC)
+	routestrg.On("AddRoute", mock.Anything, mock.MatchedBy(func(r *domain.Route) bool {
+		return r.Cost == domain.Money{Amount: 1029, Currency: "BYN"}
+	})).Return(1, nil)
+	routestrg.On("AddRoute", mock.Anything, mock.MatchedBy(func(r *domain.Route) bool {
+		return r.Cost == domain.Money{Amount: 70050, Currency: "EUR"}
+	})).Return(2, nil)
+
+	testCases := []struct {
+		name             string
+		cost             interface{}
+		currency         string
+		expectedStatus   int
+		expectedCost     string
+		expectedCurrency string
+	}{
+		{
+			name:             "decimal string",
+			cost:             "10.29",
+			expectedStatus:   http.StatusOK,
+			expectedCost:     "10.29",
+			expectedCurrency: "BYN",
+		},
+		{
+			name:             "number",
+			cost:             10.29,
+			expectedStatus:   http.StatusOK,
+			expectedCost:     "10.29",
+			expectedCurrency: "BYN",
+		},
+		{
+			name:             "other currency",
+			cost:             "700.5",
+			currency:         "EUR",
+			expectedStatus:   http.StatusOK,
+			expectedCost:     "700.50",
+			expectedCurrency: "EUR",
+		},
+		{
+			name:           "too many fractional digits",
+			cost:           "10.299",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid amount",
+			cost:           "1e3",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid currency",
+			cost:           "10.29",
+			currency:       "byn",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			body := map[string]interface{}{
+				"points":     map[string]interface{}{"startpoint": "Brest", "endpoint": "Vitebsk"},
+				"start_time": start,
+				"cost":       tc.cost,
+				"currency":   tc.currency,
+				"freeseats":  12,
+				"allseats":   13,
+			}
+			res := e.Request(http.MethodPost, "/routes").WithJSON(body).Expect()
+			res.Status(tc.expectedStatus)
+			if tc.expectedStatus == http.StatusOK {
+				res.JSON().Object().ValueEqual("cost", tc.expectedCost).
+					ValueEqual("currency", tc.expectedCurrency)
+			}
+		})
+	}
+}
+
+func TestCreateSchedule(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	routestrg.On("AddSchedule", mock.Anything, &domain.Schedule{
+		Points:     domain.Points{StartPoint: "Minsk", EndPoint: "Grodno"},
+		Departure:  9*time.Hour + 30*time.Minute,
+		Weekdays:   []time.Weekday{time.Monday, time.Friday},
+		ValidFrom:  time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:    time.Date(2019, 5, 31, 0, 0, 0, 0, time.UTC),
+		Exceptions: []time.Time{time.Date(2019, 5, 10, 0, 0, 0, 0, time.UTC)},
+		Cost:       domain.Money{Amount: 1500, Currency: "BYN"},
+		AllSeats:   40,
+		Duration:   4 * time.Hour,
+	}).Return(2, nil)
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"points":     map[string]string{"startpoint": "Minsk", "endpoint": "Grodno"},
+			"departure":  "09:30",
+			"weekdays":   []string{"monday", "Friday"},
+			"valid_from": "2019-05-01",
+			"valid_to":   "2019-05-31",
+			"exceptions": []string{"2019-05-10"},
+			"cost":       15,
+			"allseats":   40,
+			"duration":   240,
+		}
+	}
+	testCases := []struct {
+		name            string
+		change          func(body map[string]interface{})
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{
+			name:           "successful test",
+			change:         func(body map[string]interface{}) {},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:            "wrong weekday",
+			change:          func(body map[string]interface{}) { body["weekdays"] = []string{"funday"} },
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "invalid weekday \"funday\"",
+		},
+		{
+			name:            "wrong departure",
+			change:          func(body map[string]interface{}) { body["departure"] = "9" },
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "invalid departure",
+		},
+		{
+			name:            "no weekdays",
+			change:          func(body map[string]interface{}) { body["weekdays"] = []string{} },
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "weekdays are empty",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			body := valid()
+			tc.change(body)
+			res := e.Request(http.MethodPost, "/schedules").WithJSON(body).Expect()
+			res.Status(tc.expectedStatus)
+			if tc.expectedMessage != "" {
+				res.JSON().Object().ValueEqual("code", "validation_error").
+					ValueEqual("message", tc.expectedMessage)
+				return
+			}
+			obj := res.JSON().Object()
+			obj.ValueEqual("id", 2)
+			obj.ValueEqual("departure", "09:30")
+			obj.ValueEqual("weekdays", []string{"monday", "friday"})
+			obj.ValueEqual("exceptions", []string{"2019-05-10"})
+		})
+	}
+}
+
+func TestGetSchedule(t *testing.T) {
+	cfg := &config.Config{
+		PortServer: 8000,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	routestrg.On("ScheduleByID", mock.Anything, 1).Return(&domain.Schedule{
+		ID:        1,
+		Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Grodno"},
+		Departure: 7*time.Hour + 5*time.Minute,
+		Weekdays:  []time.Weekday{time.Sunday},
+		ValidFrom: time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:   time.Date(2019, 5, 31, 0, 0, 0, 0, time.UTC),
+		Cost:      domain.Money{Amount: 1550, Currency: "BYN"},
+		AllSeats:  40,
+	}, nil)
+	routestrg.On("ScheduleByID", mock.Anything, 2).Return(nil, domain.NotFound("no such schedule"))
+
+	obj := e.GET("/schedules/1").Expect().Status(http.StatusOK).JSON().Object()
+	obj.ValueEqual("departure", "07:05")
+	obj.ValueEqual("weekdays", []string{"sunday"})
+	obj.ValueEqual("valid_to", "2019-05-31")
+	obj.ValueEqual("exceptions", []string{})
+	obj.ValueEqual("cost", "15.50")
+	obj.ValueEqual("currency", "BYN")
+
+	e.GET("/schedules/2").Expect().Status(http.StatusNotFound)
+	e.GET("/schedules/df").Expect().Status(http.StatusBadRequest)
+}
+
+func TestGenerateTrips(t *testing.T) {
+	cfg := &config.Config{
+		PortServer:      8000,
+		ScheduleHorizon: 14,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	today := time.Now().UTC()
+	schedule := domain.Schedule{
+		ID:        1,
+		Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Grodno"},
+		Weekdays:  []time.Weekday{today.AddDate(0, 0, 1).Weekday()},
+		ValidFrom: today.AddDate(0, 0, -1),
+		ValidTo:   today.AddDate(0, 0, 30),
+		AllSeats:  40,
+	}
+	routestrg.On("GetAllSchedules", mock.Anything).Return([]domain.Schedule{schedule}, nil)
+	routestrg.On("ScheduledStarts", mock.Anything, 1, mock.Anything, mock.Anything).Return(nil, nil)
+	routestrg.On("AddRoute", mock.Anything, mock.AnythingOfType("*domain.Route")).Return(1, nil)
+	runInTransaction(&routestrg)
+	routestrg.On("AddAuditEntry", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Actor == "root" && entry.Action == domain.ActionCreate
+	})).Return(1, nil).Twice()
+
+	e.POST("/schedules/generate").Expect().Status(http.StatusOK).JSON().Object().ValueEqual("created", 2)
+	routestrg.AssertExpectations(t)
+}
+
+func TestWriteError(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedBody   errorServer
+	}{
+		{
+			name:           "not found",
+			err:            domain.NotFound("no such route"),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   errorServer{Code: "not_found", Message: "no such route"},
+		},
+		{
+			name:           "validation",
+			err:            &domain.Error{Kind: domain.ErrValidation, Message: "invalid id", Details: "bad"},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errorServer{Code: "validation_error", Message: "invalid id", Details: "bad"},
+		},
+		{
+			name:           "conflict",
+			err:            domain.Conflict("no free seats"),
+			expectedStatus: http.StatusConflict,
+			expectedBody:   errorServer{Code: "conflict", Message: "no free seats"},
+		},
+		{
+			name:           "unavailable",
+			err:            domain.Unavailable("data hasn't read"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   errorServer{Code: "unavailable", Message: "data hasn't read"},
+		},
+		{
+			name:           "timeout",
+			err:            context.DeadlineExceeded,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: errorServer{Code: "unavailable", Message: "request timed out",
+				Details: context.DeadlineExceeded.Error()},
+		},
+		{
+			name:           "unknown",
+			err:            errors.New("no data"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   errorServer{Code: "internal_error", Message: "internal error"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeError(w, tc.err)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			var body errorServer
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+			assert.Equal(t, tc.expectedBody, body)
+		})
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+
+	cfg := &config.Config{
+		PortServer:     8000,
+		RequestTimeout: 5,
+	}
+	var routestrg mocks.RouteStorage
+	routeman := routemanager.NewRouteManager(&routestrg)
+	busstation := NewBusStation(routeman, nil, nil, cfg)
+
+	server, e := newAdminServer(t, busstation)
+	defer server.Close()
+
+	t.Run("storage gets context with deadline", func(t *testing.T) {
+		routestrg.On("RouteByID", mock.Anything, 1).Return(nil, domain.NotFound("no such route")).
+			Run(func(args mock.Arguments) {
+				deadline, ok := args.Get(0).(context.Context).Deadline()
+				assert.True(t, ok)
+				assert.WithinDuration(t, time.Now().Add(5*time.Second), deadline, time.Second)
+			}).Once()
+
+		e.GET("/routes/1").Expect().Status(http.StatusNotFound)
+		routestrg.AssertExpectations(t)
+	})
+
+	t.Run("expired context", func(t *testing.T) {
+		routestrg.On("RouteByID", mock.Anything, 2).Return(nil, context.DeadlineExceeded).Once()
+
+		obj := e.GET("/routes/2").Expect().Status(http.StatusServiceUnavailable).JSON().Object()
+		obj.Value("code").Equal("unavailable")
+		obj.Value("message").Equal("request timed out")
+		routestrg.AssertExpectations(t)
+	})
+}
@@ -1,19 +1,85 @@
 package server
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/JaneKetko/Buses/src/domain"
 )
 
+//amount - money amount in minor units which is encoded as decimal string like "10.29".
+//Decimal numbers are accepted as well, both are parsed without rounding.
+type amount int
+
+//MarshalJSON encodes amount as decimal string.
+func (a amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(domain.FormatAmount(int(a)))
+}
+
+//UnmarshalJSON decodes amount from decimal string or number.
+func (a *amount) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if value == "null" {
+		return nil
+	}
+	if strings.HasPrefix(value, `"`) {
+		err := json.Unmarshal(data, &value)
+		if err != nil {
+			return err
+		}
+	}
+	n, err := domain.ParseAmount(value)
+	if err != nil {
+		return err
+	}
+	*a = amount(n)
+	return nil
+}
+
+//currency returns default currency if code is empty.
+func currency(code string) string {
+	if code == "" {
+		return domain.DefaultCurrency
+	}
+	return code
+}
+
 //RouteServer - struct for storing info about route for decoding and encoding.
+//Status, delay in minutes and expected start are changed by status of route only,
+//they are ignored in decoded route. Version of decoded route is taken from If-Match header.
 type routeServer struct {
-	ID        int          `json:"id"`
-	Points    PointsServer `json:"points"`
-	Start     time.Time    `json:"start_time"`
-	Cost      float32      `json:"cost"`
-	FreeSeats int          `json:"freeseats"`
-	AllSeats  int          `json:"allseats"`
+	ID            int          `json:"id"`
+	Points        PointsServer `json:"points"`
+	Start         time.Time    `json:"start_time"`
+	Cost          amount       `json:"cost"`
+	Currency      string       `json:"currency"`
+	FreeSeats     int          `json:"freeseats"`
+	AllSeats      int          `json:"allseats"`
+	Duration      int          `json:"duration"`
+	Stops         []stopServer `json:"stops,omitempty"`
+	ScheduleID    int          `json:"schedule_id,omitempty"`
+	Status        string       `json:"status"`
+	Delay         int          `json:"delay"`
+	ExpectedStart time.Time    `json:"expected_start"`
+	Version       int          `json:"version"`
+}
+
+//statusServer - struct for decoding new status of route with delay in minutes.
+type statusServer struct {
+	Status string `json:"status"`
+	Delay  int    `json:"delay"`
+}
+
+//stopServer - struct for storing info about stop of route for decoding and encoding.
+//Arrival and departure are minutes from start of route.
+type stopServer struct {
+	Point     string `json:"point"`
+	Arrival   int    `json:"arrival"`
+	Departure int    `json:"departure"`
+	Fare      amount `json:"fare"`
+	FreeSeats int    `json:"freeseats"`
 }
 
 //PointsServer - struct for showing points of route for decoding and encoding.
@@ -25,7 +91,7 @@ type PointsServer struct {
 //routeServerToRoute convert routeServer to Route
 func routeServerToRoute(rServer routeServer) domain.Route {
 	var route domain.Route
-	cost := int(rServer.Cost * 100)
+	cost := domain.Money{Amount: int(rServer.Cost), Currency: currency(rServer.Currency)}
 	route = domain.Route{
 		ID: rServer.ID,
 		Points: domain.Points{
@@ -35,6 +101,16 @@ func routeServerToRoute(rServer routeServer) domain.Route {
 		Cost:      cost,
 		FreeSeats: rServer.FreeSeats,
 		AllSeats:  rServer.AllSeats,
+		Duration:  time.Duration(rServer.Duration) * time.Minute,
+	}
+	for _, stop := range rServer.Stops {
+		route.Stops = append(route.Stops, domain.Stop{
+			Point:     stop.Point,
+			Arrival:   time.Duration(stop.Arrival) * time.Minute,
+			Departure: time.Duration(stop.Departure) * time.Minute,
+			Fare:      int(stop.Fare),
+			FreeSeats: stop.FreeSeats,
+		})
 	}
 	return route
 }
@@ -42,16 +118,264 @@ func routeServerToRoute(rServer routeServer) domain.Route {
 //routeToRouteServer convert Route to routeServer
 func routeToRouteServer(r domain.Route) routeServer {
 	var route routeServer
-	cost := float32(r.Cost) / 100
 	route = routeServer{
 		ID: r.ID,
 		Points: PointsServer{
 			StartPoint: r.Points.StartPoint,
 			EndPoint:   r.Points.EndPoint},
-		Start:     r.Start,
-		Cost:      cost,
-		FreeSeats: r.FreeSeats,
-		AllSeats:  r.AllSeats,
+		Start:         r.Start,
+		Cost:          amount(r.Cost.Amount),
+		Currency:      r.Cost.Currency,
+		FreeSeats:     r.FreeSeats,
+		AllSeats:      r.AllSeats,
+		Duration:      int(r.Duration / time.Minute),
+		ScheduleID:    r.ScheduleID,
+		Status:        r.Status,
+		Delay:         int(r.Delay / time.Minute),
+		ExpectedStart: r.ExpectedStart(),
+		Version:       r.Version,
+	}
+	for _, stop := range r.Stops {
+		route.Stops = append(route.Stops, stopServer{
+			Point:     stop.Point,
+			Arrival:   int(stop.Arrival / time.Minute),
+			Departure: int(stop.Departure / time.Minute),
+			Fare:      amount(stop.Fare),
+			FreeSeats: stop.FreeSeats,
+		})
 	}
 	return route
 }
+
+//routesPage - struct for encoding page of routes with pagination info.
+type routesPage struct {
+	Routes []routeServer `json:"routes"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+//legServer - struct for encoding leg of journey.
+type legServer struct {
+	Route     routeServer `json:"route"`
+	From      string      `json:"from"`
+	To        string      `json:"to"`
+	Departure time.Time   `json:"departure"`
+	Arrival   time.Time   `json:"arrival"`
+	Cost      amount      `json:"cost"`
+	Currency  string      `json:"currency"`
+}
+
+//journeyServer - struct for encoding journey.
+type journeyServer struct {
+	Legs      []legServer `json:"legs"`
+	Departure time.Time   `json:"departure"`
+	Arrival   time.Time   `json:"arrival"`
+	Duration  int         `json:"duration"`
+	Cost      amount      `json:"cost"`
+	Currency  string      `json:"currency"`
+	Transfers int         `json:"transfers"`
+}
+
+//journeyToJourneyServer convert Journey to journeyServer
+func journeyToJourneyServer(j domain.Journey) journeyServer {
+	legs := make([]legServer, 0, len(j.Legs))
+	for _, leg := range j.Legs {
+		legs = append(legs, legServer{
+			Route:     routeToRouteServer(leg.Route),
+			From:      leg.From,
+			To:        leg.To,
+			Departure: leg.Departure,
+			Arrival:   leg.Arrival,
+			Cost:      amount(leg.Cost.Amount),
+			Currency:  leg.Cost.Currency,
+		})
+	}
+	return journeyServer{
+		Legs:      legs,
+		Departure: j.Departure(),
+		Arrival:   j.Arrival(),
+		Duration:  int(j.Duration() / time.Minute),
+		Cost:      amount(j.Cost().Amount),
+		Currency:  j.Cost().Currency,
+		Transfers: j.Transfers(),
+	}
+}
+
+//ticketServer - struct for storing info about ticket for decoding and encoding.
+type ticketServer struct {
+	ID        int       `json:"id"`
+	RouteID   int       `json:"route_id"`
+	Passenger string    `json:"passenger"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Cost      amount    `json:"cost"`
+	Currency  string    `json:"currency"`
+	Booked    time.Time `json:"booked"`
+}
+
+//ticketServerToTicket convert ticketServer to Ticket
+func ticketServerToTicket(tServer ticketServer) domain.Ticket {
+	return domain.Ticket{
+		ID:        tServer.ID,
+		RouteID:   tServer.RouteID,
+		Passenger: tServer.Passenger,
+		From:      tServer.From,
+		To:        tServer.To,
+		Booked:    tServer.Booked,
+	}
+}
+
+//ticketToTicketServer convert Ticket to ticketServer
+func ticketToTicketServer(t domain.Ticket) ticketServer {
+	return ticketServer{
+		ID:        t.ID,
+		RouteID:   t.RouteID,
+		Passenger: t.Passenger,
+		From:      t.From,
+		To:        t.To,
+		Cost:      amount(t.Cost.Amount),
+		Currency:  t.Cost.Currency,
+		Booked:    t.Booked,
+	}
+}
+
+//scheduleServer - struct for storing info about schedule for decoding and encoding.
+//Departure is time of day in format hh:mm, days are in format yyyy-mm-dd.
+type scheduleServer struct {
+	ID         int          `json:"id"`
+	Points     PointsServer `json:"points"`
+	Departure  string       `json:"departure"`
+	Weekdays   []string     `json:"weekdays"`
+	ValidFrom  string       `json:"valid_from"`
+	ValidTo    string       `json:"valid_to"`
+	Exceptions []string     `json:"exceptions"`
+	Cost       amount       `json:"cost"`
+	Currency   string       `json:"currency"`
+	AllSeats   int          `json:"allseats"`
+	Duration   int          `json:"duration"`
+}
+
+//parseWeekday finds weekday by its name ignoring case.
+func parseWeekday(name string) (time.Weekday, error) {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if strings.EqualFold(weekday.String(), name) {
+			return weekday, nil
+		}
+	}
+	return 0, domain.Invalid(fmt.Sprintf("invalid weekday %q", name))
+}
+
+//scheduleServerToSchedule convert scheduleServer to Schedule
+func scheduleServerToSchedule(sServer scheduleServer) (domain.Schedule, error) {
+	schedule := domain.Schedule{
+		ID: sServer.ID,
+		Points: domain.Points{
+			StartPoint: sServer.Points.StartPoint,
+			EndPoint:   sServer.Points.EndPoint},
+		Cost:     domain.Money{Amount: int(sServer.Cost), Currency: currency(sServer.Currency)},
+		AllSeats: sServer.AllSeats,
+		Duration: time.Duration(sServer.Duration) * time.Minute,
+	}
+
+	departure, err := time.Parse("15:04", sServer.Departure)
+	if err != nil {
+		return schedule, domain.Invalid("invalid departure")
+	}
+	schedule.Departure = domain.TimeOfDay(departure)
+	for _, name := range sServer.Weekdays {
+		weekday, err := parseWeekday(name)
+		if err != nil {
+			return schedule, err
+		}
+		schedule.Weekdays = append(schedule.Weekdays, weekday)
+	}
+	if schedule.ValidFrom, err = time.Parse("2006-01-02", sServer.ValidFrom); err != nil {
+		return schedule, domain.Invalid("invalid valid_from")
+	}
+	if schedule.ValidTo, err = time.Parse("2006-01-02", sServer.ValidTo); err != nil {
+		return schedule, domain.Invalid("invalid valid_to")
+	}
+	for _, value := range sServer.Exceptions {
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return schedule, domain.Invalid(fmt.Sprintf("invalid exception %q", value))
+		}
+		schedule.Exceptions = append(schedule.Exceptions, day)
+	}
+	return schedule, nil
+}
+
+//scheduleToScheduleServer convert Schedule to scheduleServer
+func scheduleToScheduleServer(s domain.Schedule) scheduleServer {
+	schedule := scheduleServer{
+		ID: s.ID,
+		Points: PointsServer{
+			StartPoint: s.Points.StartPoint,
+			EndPoint:   s.Points.EndPoint},
+		Departure:  time.Time{}.Add(s.Departure).Format("15:04"),
+		Weekdays:   make([]string, 0, len(s.Weekdays)),
+		ValidFrom:  s.ValidFrom.Format("2006-01-02"),
+		ValidTo:    s.ValidTo.Format("2006-01-02"),
+		Exceptions: make([]string, 0, len(s.Exceptions)),
+		Cost:       amount(s.Cost.Amount),
+		Currency:   s.Cost.Currency,
+		AllSeats:   s.AllSeats,
+		Duration:   int(s.Duration / time.Minute),
+	}
+	for _, weekday := range s.Weekdays {
+		schedule.Weekdays = append(schedule.Weekdays, strings.ToLower(weekday.String()))
+	}
+	for _, day := range s.Exceptions {
+		schedule.Exceptions = append(schedule.Exceptions, day.Format("2006-01-02"))
+	}
+	return schedule
+}
+
+//identityServer - struct for encoding and decoding identity of client with its roles.
+type identityServer struct {
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+//auditServer - struct for encoding entry of audit log with snapshots of route before and after change.
+type auditServer struct {
+	ID      int             `json:"id"`
+	RouteID int             `json:"route_id"`
+	Action  string          `json:"action"`
+	Actor   string          `json:"actor"`
+	Time    time.Time       `json:"time"`
+	Before  json.RawMessage `json:"before"`
+	After   json.RawMessage `json:"after"`
+}
+
+//encodeSnapshot encodes snapshot of route for audit log as routeServer.
+func encodeSnapshot(r *domain.Route) ([]byte, error) {
+	return json.Marshal(routeToRouteServer(*r))
+}
+
+//auditToAuditServer convert AuditEntry to auditServer
+func auditToAuditServer(e domain.AuditEntry) auditServer {
+	return auditServer{
+		ID:      e.ID,
+		RouteID: e.RouteID,
+		Action:  e.Action,
+		Actor:   e.Actor,
+		Time:    e.Time,
+		Before:  e.Before,
+		After:   e.After,
+	}
+}
+
+//componentServer - struct for encoding state of dependency of server and duration of its check.
+type componentServer struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+//healthServer - struct for encoding health of server and states of its dependencies.
+type healthServer struct {
+	Status     string                     `json:"status"`
+	Components map[string]componentServer `json:"components,omitempty"`
+}
@@ -0,0 +1,55 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//archiveTests returns tests of moving of old routes to archive.
+func archiveTests() []conformanceTest {
+	return []conformanceTest{
+		{"ArchiveRoutes", testArchiveRoutes},
+		{"ArchiveNothing", testArchiveNothing},
+	}
+}
+
+func testArchiveRoutes(t *testing.T, storage routemanager.RouteStorage) {
+	old := addRoute(t, storage, stopsRoute(at(8, 0), 20))
+	deleted := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(9, 0), 1000, 30))
+	kept := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+	oldTicket := bookSeat(t, storage, newTicket(old, "Minsk", "Grodno"))
+	keptTicket := bookSeat(t, storage, newTicket(kept, "", ""))
+	deleteRoute(t, storage, deleted)
+
+	archived, err := storage.ArchiveRoutes(context.Background(), at(10, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 2, archived)
+
+	_, err = storage.RouteByID(context.Background(), old)
+	assertKind(t, domain.ErrNotFound, err)
+	assertKind(t, domain.ErrNotFound, storage.RestoreRoute(context.Background(), deleted, 2))
+	assertKind(t, domain.ErrNotFound, storage.CancelBooking(context.Background(), oldTicket))
+	assert.Equal(t, []int{kept}, routeIDs(findRoutes(t, storage, domain.RouteQuery{})))
+	require.NoError(t, storage.CancelBooking(context.Background(), keptTicket))
+
+	archived, err = storage.ArchiveRoutes(context.Background(), at(10, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 0, archived)
+}
+
+func testArchiveNothing(t *testing.T, storage routemanager.RouteStorage) {
+	archived, err := storage.ArchiveRoutes(context.Background(), at(10, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 0, archived)
+
+	id := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+	archived, err = storage.ArchiveRoutes(context.Background(), at(10, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 0, archived)
+	assert.Equal(t, id, getRoute(t, storage, id).ID)
+}
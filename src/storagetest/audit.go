@@ -0,0 +1,111 @@
+package storagetest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//auditTests returns tests of audit log of changes of routes.
+func auditTests() []conformanceTest {
+	return []conformanceTest{
+		{"AddAuditEntry", testAddAuditEntry},
+		{"AuditEntriesFilters", testAuditEntriesFilters},
+	}
+}
+
+//addEntry adds entry to audit log of storage and returns its id.
+func addEntry(t *testing.T, storage routemanager.RouteStorage, e domain.AuditEntry) int {
+	id, err := storage.AddAuditEntry(context.Background(), &e)
+	require.NoError(t, err)
+	return id
+}
+
+//entryIDs returns ids of audit entries in the same order.
+func entryIDs(entries []domain.AuditEntry) []int {
+	ids := make([]int, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+//snapshot encodes route as JSON snapshot of audit entry.
+func snapshot(t *testing.T, route domain.Route) json.RawMessage {
+	data, err := json.Marshal(route)
+	require.NoError(t, err)
+	return data
+}
+
+func testAddAuditEntry(t *testing.T, storage routemanager.RouteStorage) {
+	route := stopsRoute(at(10, 0), 20)
+	route.ID = 7
+	before := snapshot(t, route)
+	route.Status, route.Delay = domain.StatusDelayed, 15*time.Minute
+	after := snapshot(t, route)
+
+	first := addEntry(t, storage, domain.AuditEntry{RouteID: 7, Action: domain.ActionCreate, Actor: "admin",
+		Time: at(9, 0), After: before})
+	second := addEntry(t, storage, domain.AuditEntry{RouteID: 7, Action: domain.ActionStatus, Actor: "dispatcher",
+		Time: at(9, 30), Before: before, After: after})
+	third := addEntry(t, storage, domain.AuditEntry{RouteID: 7, Action: domain.ActionDelete, Actor: "admin",
+		Time: at(9, 45), Before: after})
+	assert.True(t, first < second && second < third)
+
+	entries, err := storage.AuditEntries(context.Background(), domain.AuditQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.AuditEntry{
+		{ID: first, RouteID: 7, Action: domain.ActionCreate, Actor: "admin", Time: at(9, 0), After: before},
+		{ID: second, RouteID: 7, Action: domain.ActionStatus, Actor: "dispatcher", Time: at(9, 30),
+			Before: before, After: after},
+		{ID: third, RouteID: 7, Action: domain.ActionDelete, Actor: "admin", Time: at(9, 45), Before: after},
+	}, entries)
+}
+
+func testAuditEntriesFilters(t *testing.T, storage routemanager.RouteStorage) {
+	route := snapshot(t, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+	ids := []int{
+		addEntry(t, storage, domain.AuditEntry{RouteID: 1, Action: domain.ActionCreate, Actor: "admin",
+			Time: at(8, 0), After: route}),
+		addEntry(t, storage, domain.AuditEntry{RouteID: 2, Action: domain.ActionCreate, Actor: "dispatcher",
+			Time: at(8, 30), After: route}),
+		addEntry(t, storage, domain.AuditEntry{RouteID: 1, Action: domain.ActionUpdate, Actor: "dispatcher",
+			Time: at(9, 0), Before: route, After: route}),
+		addEntry(t, storage, domain.AuditEntry{RouteID: 1, Action: domain.ActionDelete, Actor: "admin",
+			Time: at(9, 30), Before: route}),
+	}
+
+	testCases := []struct {
+		name     string
+		query    domain.AuditQuery
+		expected []int
+	}{
+		{"all entries", domain.AuditQuery{}, ids},
+		{"by route", domain.AuditQuery{RouteID: 1}, []int{ids[0], ids[2], ids[3]}},
+		{"by actor", domain.AuditQuery{Actor: "dispatcher"}, []int{ids[1], ids[2]}},
+		{"from time", domain.AuditQuery{From: at(8, 30)}, ids[1:]},
+		{"to time", domain.AuditQuery{To: at(9, 0)}, ids[:2]},
+		{"route and actor", domain.AuditQuery{RouteID: 1, Actor: "admin"}, []int{ids[0], ids[3]}},
+		{"page", domain.AuditQuery{Limit: 2, Offset: 1}, ids[1:3]},
+		{"page after end", domain.AuditQuery{Limit: 2, Offset: 4}, nil},
+		{"no such actor", domain.AuditQuery{Actor: "cashier"}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := storage.AuditEntries(context.Background(), tc.query)
+			require.NoError(t, err)
+			if len(tc.expected) == 0 {
+				assert.Empty(t, entries)
+				return
+			}
+			assert.Equal(t, tc.expected, entryIDs(entries))
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//IdempotencyFactory - function which creates new empty storage of idempotency records for the test.
+type IdempotencyFactory func(t *testing.T) routemanager.IdempotencyStorage
+
+//RunIdempotency runs conformance tests of storage of idempotency records,
+//every test gets new storage from factory.
+func RunIdempotency(t *testing.T, newStorage IdempotencyFactory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, storage routemanager.IdempotencyStorage)
+	}{
+		{"AddIdempotencyRecord", testAddIdempotencyRecord},
+		{"ExpiredIdempotencyRecords", testExpiredIdempotencyRecords},
+		{"DeleteIdempotencyRecord", testDeleteIdempotencyRecord},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStorage(t))
+		})
+	}
+}
+
+//expiresIn returns time of expiration of record after d in precision of storage.
+func expiresIn(d time.Duration) time.Time {
+	return time.Now().Add(d).UTC().Truncate(time.Second)
+}
+
+//getRecord gets idempotency record from storage by key.
+func getRecord(t *testing.T, storage routemanager.IdempotencyStorage, key string) domain.IdempotencyRecord {
+	t.Helper()
+	rec, err := storage.IdempotencyRecordByKey(context.Background(), key)
+	require.NoError(t, err)
+	return *rec
+}
+
+func testAddIdempotencyRecord(t *testing.T, storage routemanager.IdempotencyStorage) {
+	ctx := context.Background()
+	rec := domain.IdempotencyRecord{Key: "k1", Hash: "h1", Expires: expiresIn(time.Hour)}
+	require.NoError(t, storage.AddIdempotencyRecord(ctx, &rec))
+	assert.Equal(t, rec, getRecord(t, storage, "k1"))
+
+	other := domain.IdempotencyRecord{Key: "k1", Hash: "h2", Expires: expiresIn(time.Hour)}
+	assertKind(t, domain.ErrConflict, storage.AddIdempotencyRecord(ctx, &other))
+
+	rec.Status, rec.ContentType, rec.ETag = 200, "application/json", `"1"`
+	rec.Body = []byte(`{"id":1}`)
+	require.NoError(t, storage.CompleteIdempotencyRecord(ctx, &rec))
+	assert.Equal(t, rec, getRecord(t, storage, "k1"))
+
+	_, err := storage.IdempotencyRecordByKey(ctx, "k2")
+	assertKind(t, domain.ErrNotFound, err)
+	assertKind(t, domain.ErrNotFound, storage.CompleteIdempotencyRecord(ctx,
+		&domain.IdempotencyRecord{Key: "k2", Status: 200}))
+}
+
+func testExpiredIdempotencyRecords(t *testing.T, storage routemanager.IdempotencyStorage) {
+	ctx := context.Background()
+	expired := domain.IdempotencyRecord{Key: "old", Hash: "h1", Status: 200, Expires: expiresIn(-time.Hour)}
+	require.NoError(t, storage.AddIdempotencyRecord(ctx, &expired))
+
+	rec := domain.IdempotencyRecord{Key: "new", Hash: "h2", Expires: expiresIn(time.Hour)}
+	require.NoError(t, storage.AddIdempotencyRecord(ctx, &rec))
+	_, err := storage.IdempotencyRecordByKey(ctx, "old")
+	assertKind(t, domain.ErrNotFound, err)
+
+	reused := domain.IdempotencyRecord{Key: "old", Hash: "h3", Expires: expiresIn(time.Hour)}
+	require.NoError(t, storage.AddIdempotencyRecord(ctx, &reused))
+	assert.Equal(t, reused, getRecord(t, storage, "old"))
+}
+
+func testDeleteIdempotencyRecord(t *testing.T, storage routemanager.IdempotencyStorage) {
+	ctx := context.Background()
+	rec := domain.IdempotencyRecord{Key: "k1", Hash: "h1", Expires: expiresIn(time.Hour)}
+	require.NoError(t, storage.AddIdempotencyRecord(ctx, &rec))
+
+	require.NoError(t, storage.DeleteIdempotencyRecord(ctx, "k1"))
+	_, err := storage.IdempotencyRecordByKey(ctx, "k1")
+	assertKind(t, domain.ErrNotFound, err)
+	assertKind(t, domain.ErrNotFound, storage.DeleteIdempotencyRecord(ctx, "k1"))
+	require.NoError(t, storage.AddIdempotencyRecord(ctx, &rec))
+}
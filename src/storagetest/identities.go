@@ -0,0 +1,86 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JaneKetko/Buses/src/accessmanager"
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//IdentityFactory - function which creates new empty storage of identities for the test.
+type IdentityFactory func(t *testing.T) accessmanager.IdentityStorage
+
+//RunIdentities runs conformance tests of storage of identities, every test gets new storage from factory.
+func RunIdentities(t *testing.T, newStorage IdentityFactory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, storage accessmanager.IdentityStorage)
+	}{
+		{"SaveIdentity", testSaveIdentity},
+		{"GetAllIdentities", testGetAllIdentities},
+		{"DeleteIdentity", testDeleteIdentity},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStorage(t))
+		})
+	}
+}
+
+func testSaveIdentity(t *testing.T, storage accessmanager.IdentityStorage) {
+	ctx := context.Background()
+	_, err := storage.IdentityByName(ctx, "alice")
+	assertKind(t, domain.ErrNotFound, err)
+
+	err = storage.SaveIdentity(ctx, &domain.Identity{Name: "alice",
+		Roles: []string{domain.RolePassenger, domain.RoleCashier}})
+	require.NoError(t, err)
+	identity, err := storage.IdentityByName(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, &domain.Identity{Name: "alice",
+		Roles: []string{domain.RoleCashier, domain.RolePassenger}}, identity)
+
+	err = storage.SaveIdentity(ctx, &domain.Identity{Name: "alice", Roles: []string{domain.RoleDispatcher}})
+	require.NoError(t, err)
+	identity, err = storage.IdentityByName(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, &domain.Identity{Name: "alice", Roles: []string{domain.RoleDispatcher}}, identity)
+}
+
+func testGetAllIdentities(t *testing.T, storage accessmanager.IdentityStorage) {
+	ctx := context.Background()
+	identities, err := storage.GetAllIdentities(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, identities)
+
+	for _, identity := range []domain.Identity{
+		{Name: "carol", Roles: []string{domain.RolePassenger}},
+		{Name: "alice", Roles: []string{domain.RoleAdmin, domain.RoleDispatcher}},
+		{Name: "bob", Roles: []string{domain.RoleCashier}},
+	} {
+		identity := identity
+		require.NoError(t, storage.SaveIdentity(ctx, &identity))
+	}
+
+	identities, err = storage.GetAllIdentities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Identity{
+		{Name: "alice", Roles: []string{domain.RoleAdmin, domain.RoleDispatcher}},
+		{Name: "bob", Roles: []string{domain.RoleCashier}},
+		{Name: "carol", Roles: []string{domain.RolePassenger}},
+	}, identities)
+}
+
+func testDeleteIdentity(t *testing.T, storage accessmanager.IdentityStorage) {
+	ctx := context.Background()
+	require.NoError(t, storage.SaveIdentity(ctx, &domain.Identity{Name: "bob", Roles: []string{domain.RoleCashier}}))
+
+	require.NoError(t, storage.DeleteIdentity(ctx, "bob"))
+	_, err := storage.IdentityByName(ctx, "bob")
+	assertKind(t, domain.ErrNotFound, err)
+	assertKind(t, domain.ErrNotFound, storage.DeleteIdentity(ctx, "bob"))
+}
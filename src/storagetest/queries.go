@@ -0,0 +1,142 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//queryTests returns tests of filtering, sorting and paginating of routes.
+func queryTests() []conformanceTest {
+	return []conformanceTest{
+		{"RoutesByQueryFilters", testRoutesByQueryFilters},
+		{"RoutesByQueryOrder", testRoutesByQueryOrder},
+		{"RoutesByQueryPages", testRoutesByQueryPages},
+	}
+}
+
+//queryFixture adds routes for query tests and returns their ids.
+func queryFixture(t *testing.T, storage routemanager.RouteStorage) []int {
+	full := newRoute("Minsk", "Brest", at(7, 0), 2000, 30)
+	full.FreeSeats = 0
+	return []int{
+		addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1500, 40)),
+		addRoute(t, storage, stopsRoute(at(8, 0), 20)),
+		addRoute(t, storage, full),
+		addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0).Add(24*time.Hour), 1200, 10)),
+		addRoute(t, storage, newRoute("Lida", "Grodno", at(18, 30), 1000, 25)),
+	}
+}
+
+func testRoutesByQueryFilters(t *testing.T, storage routemanager.RouteStorage) {
+	ids := queryFixture(t, storage)
+
+	testCases := []struct {
+		name     string
+		query    domain.RouteQuery
+		expected []int
+	}{
+		{"no filters", domain.RouteQuery{}, ids},
+		{"start point", domain.RouteQuery{StartPoint: "Minsk"}, ids[:4]},
+		{"end point", domain.RouteQuery{EndPoint: "Vitebsk"}, []int{ids[0], ids[3]}},
+		{"points through stops", domain.RouteQuery{StartPoint: "Lida", EndPoint: "Grodno"}, []int{ids[1], ids[4]}},
+		{"start point at stop", domain.RouteQuery{StartPoint: "Lida"}, []int{ids[1], ids[4]}},
+		{"end point at stop", domain.RouteQuery{StartPoint: "Minsk", EndPoint: "Lida"}, []int{ids[1]}},
+		{"reversed stops", domain.RouteQuery{StartPoint: "Grodno", EndPoint: "Lida"}, nil},
+		{"unknown point", domain.RouteQuery{EndPoint: "Pinsk"}, nil},
+		{"date range", domain.RouteQuery{From: at(8, 0), To: at(18, 30)}, []int{ids[0], ids[1]}},
+		{"date from", domain.RouteQuery{From: at(12, 0)}, []int{ids[3], ids[4]}},
+		{"depart after", domain.RouteQuery{DepartAfter: 10 * time.Hour}, []int{ids[0], ids[3], ids[4]}},
+		{"depart before", domain.RouteQuery{DepartBefore: 8 * time.Hour}, []int{ids[1], ids[2]}},
+		{"depart window", domain.RouteQuery{DepartAfter: 8 * time.Hour, DepartBefore: 10 * time.Hour},
+			[]int{ids[0], ids[1], ids[3]}},
+		{"free seats", domain.RouteQuery{MinFreeSeats: 20}, []int{ids[0], ids[1], ids[4]}},
+		{"max cost", domain.RouteQuery{MaxCost: 1500}, []int{ids[0], ids[3], ids[4]}},
+		{"all filters", domain.RouteQuery{StartPoint: "Minsk", EndPoint: "Vitebsk", From: at(0, 0),
+			To: at(0, 0).Add(48 * time.Hour), DepartAfter: 9 * time.Hour, MinFreeSeats: 10, MaxCost: 1200},
+			[]int{ids[3]}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			routes, total, err := storage.RoutesByQuery(context.Background(), tc.query)
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.expected), total)
+			if len(tc.expected) == 0 {
+				assert.Empty(t, routes)
+				return
+			}
+			assert.Equal(t, tc.expected, routeIDs(routes))
+		})
+	}
+}
+
+func testRoutesByQueryOrder(t *testing.T, storage routemanager.RouteStorage) {
+	ids := queryFixture(t, storage)
+
+	testCases := []struct {
+		name     string
+		sortBy   string
+		desc     bool
+		expected []int
+	}{
+		{"by id", "", false, ids},
+		{"by id ignores desc", "", true, ids},
+		{"by start", domain.SortByStart, false, []int{ids[2], ids[1], ids[0], ids[4], ids[3]}},
+		{"by start desc", domain.SortByStart, true, []int{ids[3], ids[4], ids[0], ids[1], ids[2]}},
+		{"by cost", domain.SortByCost, false, []int{ids[4], ids[3], ids[0], ids[2], ids[1]}},
+		{"by cost desc", domain.SortByCost, true, []int{ids[1], ids[2], ids[0], ids[3], ids[4]}},
+		{"by free seats", domain.SortByFreeSeats, false, []int{ids[2], ids[3], ids[1], ids[4], ids[0]}},
+		{"by free seats desc", domain.SortByFreeSeats, true, []int{ids[0], ids[4], ids[1], ids[3], ids[2]}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			routes, total, err := storage.RoutesByQuery(context.Background(),
+				domain.RouteQuery{SortBy: tc.sortBy, Desc: tc.desc})
+			require.NoError(t, err)
+			assert.Equal(t, len(ids), total)
+			assert.Equal(t, tc.expected, routeIDs(routes))
+		})
+	}
+}
+
+func testRoutesByQueryPages(t *testing.T, storage routemanager.RouteStorage) {
+	var ids []int
+	for i := 0; i < 5; i++ {
+		ids = append(ids, addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30)))
+	}
+	addRoute(t, storage, newRoute("Minsk", "Brest", at(10, 0), 1000, 30))
+
+	testCases := []struct {
+		name     string
+		limit    int
+		offset   int
+		expected []int
+	}{
+		{"first page", 2, 0, ids[:2]},
+		{"middle page", 2, 2, ids[2:4]},
+		{"last page", 2, 4, ids[4:]},
+		{"after last page", 2, 5, nil},
+		{"equal starts keep id order", 3, 1, ids[1:4]},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			routes, total, err := storage.RoutesByQuery(context.Background(), domain.RouteQuery{
+				EndPoint: "Vitebsk", SortBy: domain.SortByStart, Limit: tc.limit, Offset: tc.offset})
+			require.NoError(t, err)
+			assert.Equal(t, len(ids), total)
+			if len(tc.expected) == 0 {
+				assert.Empty(t, routes)
+				return
+			}
+			assert.Equal(t, tc.expected, routeIDs(routes))
+		})
+	}
+}
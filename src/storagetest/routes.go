@@ -0,0 +1,315 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//routeTests returns tests of adding, reading, updating, deleting and restoring of routes.
+func routeTests() []conformanceTest {
+	return []conformanceTest{
+		{"AddRoute", testAddRoute},
+		{"AddRouteWithStops", testAddRouteWithStops},
+		{"RouteByIDMissing", testRouteByIDMissing},
+		{"AllRoutes", testAllRoutes},
+		{"DuplicatePoints", testDuplicatePoints},
+		{"ReturnedRoutesAreCopies", testReturnedRoutesAreCopies},
+		{"UpdateRoute", testUpdateRoute},
+		{"UpdateRouteMissing", testUpdateRouteMissing},
+		{"RouteVersions", testRouteVersions},
+		{"DeleteRow", testDeleteRow},
+		{"DeletedRouteIsHidden", testDeletedRouteIsHidden},
+		{"RestoreRoute", testRestoreRoute},
+		{"ConcurrentAddRoute", testConcurrentAddRoute},
+		{"InTransaction", testInTransaction},
+		{"InTransactionRollback", testInTransactionRollback},
+	}
+}
+
+func testAddRoute(t *testing.T, storage routemanager.RouteStorage) {
+	route := newRoute("Minsk", "Vitebsk", at(10, 30).Add(500*time.Millisecond), 1550, 40)
+	route.FreeSeats = 38
+	route.Duration = 3*time.Hour + 20*time.Minute + 15*time.Second
+
+	id1, err := storage.AddRoute(context.Background(), &route)
+	require.NoError(t, err)
+	id2, err := storage.AddRoute(context.Background(), &route)
+	require.NoError(t, err)
+	assert.NotEqual(t, id1, id2)
+	assert.Equal(t, 0, route.ID)
+
+	expected := route
+	expected.ID, expected.Version = id1, 1
+	expected.Start = at(10, 30)
+	expected.Duration = 3*time.Hour + 20*time.Minute
+	assert.Equal(t, expected, getRoute(t, storage, id1))
+}
+
+func testAddRouteWithStops(t *testing.T, storage routemanager.RouteStorage) {
+	route := stopsRoute(at(8, 0), 20)
+	id := addRoute(t, storage, route)
+
+	route.ID, route.Version = id, 1
+	assert.Equal(t, route, getRoute(t, storage, id))
+}
+
+func testRouteByIDMissing(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+
+	_, err := storage.RouteByID(context.Background(), id+1)
+	assertKind(t, domain.ErrNotFound, err)
+	_, err = storage.RouteByID(context.Background(), 0)
+	assertKind(t, domain.ErrNotFound, err)
+}
+
+func testAllRoutes(t *testing.T, storage routemanager.RouteStorage) {
+	assert.Empty(t, findRoutes(t, storage, domain.RouteQuery{}))
+
+	ids := []int{
+		addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(12, 0), 1000, 30)),
+		addRoute(t, storage, stopsRoute(at(8, 0), 20)),
+		addRoute(t, storage, newRoute("Brest", "Minsk", at(6, 0), 2000, 40)),
+	}
+
+	routes := findRoutes(t, storage, domain.RouteQuery{})
+	assert.Equal(t, ids, routeIDs(routes))
+	assert.Len(t, routes[1].Stops, 3)
+}
+
+func testDuplicatePoints(t *testing.T, storage routemanager.RouteStorage) {
+	first := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+	second := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(14, 0), 1200, 30))
+
+	route := getRoute(t, storage, second)
+	route.Points.EndPoint = "Polotsk"
+	require.NoError(t, storage.UpdateRoute(context.Background(), &route))
+
+	assert.Equal(t, domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"}, getRoute(t, storage, first).Points)
+	assert.Equal(t, domain.Points{StartPoint: "Minsk", EndPoint: "Polotsk"}, getRoute(t, storage, second).Points)
+
+	route.Points.EndPoint = "Vitebsk"
+	route.Version++
+	require.NoError(t, storage.UpdateRoute(context.Background(), &route))
+	routes := findRoutes(t, storage, domain.RouteQuery{EndPoint: "Vitebsk"})
+	assert.Equal(t, []int{first, second}, routeIDs(routes))
+}
+
+func testReturnedRoutesAreCopies(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, stopsRoute(at(8, 0), 20))
+
+	route := getRoute(t, storage, id)
+	route.Cost.Amount = 1
+	route.Stops[1].Point = "Novogrudok"
+	routes := findRoutes(t, storage, domain.RouteQuery{})
+	routes[0].Stops[2].FreeSeats = 0
+
+	route = getRoute(t, storage, id)
+	assert.Equal(t, 2500, route.Cost.Amount)
+	assert.Equal(t, "Lida", route.Stops[1].Point)
+	assert.Equal(t, 20, route.Stops[2].FreeSeats)
+}
+
+func testUpdateRoute(t *testing.T, storage routemanager.RouteStorage) {
+	schedule := addSchedule(t, storage, newSchedule())
+	route := stopsRoute(at(8, 0), 20)
+	route.ScheduleID = schedule
+	id := addRoute(t, storage, route)
+
+	updated := newRoute("Minsk", "Brest", at(9, 15), 3000, 50)
+	updated.ID, updated.Version = id, 1
+	updated.FreeSeats = 45
+	updated.Stops = []domain.Stop{
+		{Point: "Minsk", FreeSeats: 45},
+		{Point: "Brest", Arrival: 2 * time.Hour, Departure: 2 * time.Hour, Fare: 3000, FreeSeats: 45},
+	}
+	require.NoError(t, storage.UpdateRoute(context.Background(), &updated))
+
+	expected := updated
+	expected.ScheduleID, expected.Version = schedule, 2
+	assert.Equal(t, expected, getRoute(t, storage, id))
+
+	updated.Stops, updated.Version = nil, 2
+	require.NoError(t, storage.UpdateRoute(context.Background(), &updated))
+	assert.Empty(t, getRoute(t, storage, id).Stops)
+}
+
+func testUpdateRouteMissing(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+
+	route := newRoute("Minsk", "Brest", at(9, 0), 3000, 50)
+	route.ID = id + 1
+	err := storage.UpdateRoute(context.Background(), &route)
+	assertKind(t, domain.ErrNotFound, err)
+
+	assert.Equal(t, []int{id}, routeIDs(findRoutes(t, storage, domain.RouteQuery{})))
+}
+
+func testRouteVersions(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, stopsRoute(at(8, 0), 20))
+	other := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+	route := getRoute(t, storage, id)
+	assert.Equal(t, 1, route.Version)
+
+	ticket := bookSeat(t, storage, newTicket(id, "Minsk", "Lida"))
+	assert.Equal(t, 2, getRoute(t, storage, id).Version)
+	require.NoError(t, storage.CancelBooking(context.Background(), ticket))
+	assert.Equal(t, 3, getRoute(t, storage, id).Version)
+	bookSeat(t, storage, newTicket(other, "", ""))
+	assert.Equal(t, 2, getRoute(t, storage, other).Version)
+
+	route.Cost.Amount = 4000
+	assertKind(t, domain.ErrPrecondition, storage.UpdateRoute(context.Background(), &route))
+	err := storage.SetRouteStatus(context.Background(), id, 2, domain.StatusCancelled, 0)
+	assertKind(t, domain.ErrPrecondition, err)
+	assertKind(t, domain.ErrPrecondition, storage.DeleteRow(context.Background(), id, 4))
+
+	stored := getRoute(t, storage, id)
+	assert.Equal(t, 3, stored.Version)
+	assert.Equal(t, 2500, stored.Cost.Amount)
+	assert.Equal(t, domain.StatusScheduled, stored.Status)
+
+	route.Version = 3
+	require.NoError(t, storage.UpdateRoute(context.Background(), &route))
+	require.NoError(t, storage.SetRouteStatus(context.Background(), id, 4, domain.StatusCancelled, 0))
+	assert.Equal(t, 5, getRoute(t, storage, id).Version)
+	assert.Equal(t, 2, getRoute(t, storage, other).Version)
+}
+
+func testDeleteRow(t *testing.T, storage routemanager.RouteStorage) {
+	kept := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+	id := addRoute(t, storage, stopsRoute(at(8, 0), 20))
+	ticket, err := storage.BookSeat(context.Background(), &domain.Ticket{RouteID: id, Passenger: "Ivanov",
+		From: "Minsk", To: "Lida", Booked: at(7, 0)})
+	require.NoError(t, err)
+
+	deleteRoute(t, storage, id)
+	_, err = storage.RouteByID(context.Background(), id)
+	assertKind(t, domain.ErrNotFound, err)
+	assertKind(t, domain.ErrNotFound, storage.DeleteRow(context.Background(), id, 3))
+	assertKind(t, domain.ErrNotFound, storage.DeleteRow(context.Background(), id+1, 1))
+
+	assert.Equal(t, []int{kept}, routeIDs(findRoutes(t, storage, domain.RouteQuery{})))
+	require.NoError(t, storage.CancelBooking(context.Background(), ticket))
+}
+
+func testConcurrentAddRoute(t *testing.T, storage routemanager.RouteStorage) {
+	const n = 10
+	ids := make(chan int, n)
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			route := newRoute("Minsk", "Vitebsk", at(6+i, 0), 1000, 30)
+			id, err := storage.AddRoute(context.Background(), &route)
+			ids <- id
+			errs <- err
+		}(i)
+	}
+
+	unique := make(map[int]bool)
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+		unique[<-ids] = true
+	}
+	assert.Len(t, unique, n)
+
+	assert.Len(t, findRoutes(t, storage, domain.RouteQuery{}), n)
+}
+
+func testDeletedRouteIsHidden(t *testing.T, storage routemanager.RouteStorage) {
+	kept := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+	id := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(12, 0), 1000, 30))
+	stops := addRoute(t, storage, stopsRoute(at(8, 0), 20))
+	deleteRoute(t, storage, id)
+	deleteRoute(t, storage, stops)
+
+	assert.Equal(t, []int{kept}, routeIDs(findRoutes(t, storage, domain.RouteQuery{EndPoint: "Vitebsk"})))
+	assert.Empty(t, findRoutes(t, storage, domain.RouteQuery{EndPoint: "Grodno"}))
+
+	routes, total, err := storage.RoutesByQuery(context.Background(), domain.RouteQuery{StartPoint: "Minsk"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []int{kept}, routeIDs(routes))
+
+	_, err = storage.BookSeat(context.Background(), &domain.Ticket{RouteID: id, Passenger: "Ivanov"})
+	assertKind(t, domain.ErrNotFound, err)
+	_, err = storage.BookSeat(context.Background(), &domain.Ticket{RouteID: stops, Passenger: "Ivanov",
+		From: "Minsk", To: "Lida"})
+	assertKind(t, domain.ErrNotFound, err)
+
+	route := newRoute("Minsk", "Brest", at(9, 0), 3000, 50)
+	route.ID = id
+	assertKind(t, domain.ErrNotFound, storage.UpdateRoute(context.Background(), &route))
+	err = storage.SetRouteStatus(context.Background(), id, 2, domain.StatusCancelled, 0)
+	assertKind(t, domain.ErrNotFound, err)
+}
+
+func testRestoreRoute(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, stopsRoute(at(8, 0), 20))
+	ticket := bookSeat(t, storage, newTicket(id, "Minsk", "Lida"))
+	expected := getRoute(t, storage, id)
+
+	assertKind(t, domain.ErrNotFound, storage.RestoreRoute(context.Background(), id, expected.Version))
+	require.NoError(t, storage.DeleteRow(context.Background(), id, expected.Version))
+	assertKind(t, domain.ErrPrecondition, storage.RestoreRoute(context.Background(), id, expected.Version))
+	require.NoError(t, storage.RestoreRoute(context.Background(), id, expected.Version+1))
+	expected.Version += 2
+	assert.Equal(t, expected, getRoute(t, storage, id))
+	assertKind(t, domain.ErrNotFound, storage.RestoreRoute(context.Background(), id, expected.Version))
+	assertKind(t, domain.ErrNotFound, storage.RestoreRoute(context.Background(), id+1, 1))
+
+	require.NoError(t, storage.CancelBooking(context.Background(), ticket))
+	assert.Equal(t, []int{20, 20, 20}, stopSeats(getRoute(t, storage, id)))
+}
+
+func testInTransaction(t *testing.T, storage routemanager.RouteStorage) {
+	var ids []int
+	err := storage.InTransaction(context.Background(), func(ctx context.Context) error {
+		for _, route := range []domain.Route{
+			newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30),
+			newRoute("Minsk", "Lida", at(12, 0), 800, 20),
+		} {
+			route := route
+			id, err := storage.AddRoute(ctx, &route)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ids, routeIDs(findRoutes(t, storage, domain.RouteQuery{})))
+}
+
+func testInTransactionRollback(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+	before := getRoute(t, storage, id)
+	errAbort := errors.New("abort")
+
+	err := storage.InTransaction(context.Background(), func(ctx context.Context) error {
+		route := newRoute("Minsk", "Lida", at(12, 0), 800, 20)
+		if _, err := storage.AddRoute(ctx, &route); err != nil {
+			return err
+		}
+		route = before
+		route.Cost.Amount = 1500
+		if err := storage.UpdateRoute(ctx, &route); err != nil {
+			return err
+		}
+		ticket := newTicket(id, "", "")
+		if _, err := storage.BookSeat(ctx, &ticket); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	assert.Equal(t, errAbort, err)
+	assert.Equal(t, []int{id}, routeIDs(findRoutes(t, storage, domain.RouteQuery{})))
+	assert.Equal(t, before, getRoute(t, storage, id))
+}
@@ -0,0 +1,207 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//scheduleTests returns tests of schedules and routes generated by them.
+func scheduleTests() []conformanceTest {
+	return []conformanceTest{
+		{"AddSchedule", testAddSchedule},
+		{"ScheduleByIDMissing", testScheduleByIDMissing},
+		{"GetAllSchedules", testGetAllSchedules},
+		{"UpdateSchedule", testUpdateSchedule},
+		{"DeleteSchedule", testDeleteSchedule},
+		{"ScheduledStarts", testScheduledStarts},
+		{"AddScheduledRouteTwice", testAddScheduledRouteTwice},
+	}
+}
+
+//date returns midnight of the day of May 2030.
+func date(d int) time.Time {
+	return time.Date(2030, 5, d, 0, 0, 0, 0, time.UTC)
+}
+
+//newSchedule returns schedule of trips Minsk - Vitebsk on working days of May 2030.
+func newSchedule() domain.Schedule {
+	return domain.Schedule{
+		Points:    domain.Points{StartPoint: "Minsk", EndPoint: "Vitebsk"},
+		Departure: 9*time.Hour + 30*time.Minute,
+		Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday,
+			time.Friday},
+		ValidFrom:  date(1),
+		ValidTo:    date(31),
+		Exceptions: []time.Time{date(1), date(9)},
+		Cost:       domain.Money{Amount: 1500, Currency: "BYN"},
+		AllSeats:   40,
+		Duration:   3 * time.Hour,
+	}
+}
+
+//addSchedule adds schedule to storage and returns its id.
+func addSchedule(t *testing.T, storage routemanager.RouteStorage, s domain.Schedule) int {
+	t.Helper()
+	id, err := storage.AddSchedule(context.Background(), &s)
+	require.NoError(t, err)
+	return id
+}
+
+//getSchedule gets schedule from storage by id.
+func getSchedule(t *testing.T, storage routemanager.RouteStorage, id int) domain.Schedule {
+	t.Helper()
+	s, err := storage.ScheduleByID(context.Background(), id)
+	require.NoError(t, err)
+	return *s
+}
+
+func testAddSchedule(t *testing.T, storage routemanager.RouteStorage) {
+	schedule := newSchedule()
+	schedule.Exceptions = []time.Time{date(9), date(1)}
+	id1, err := storage.AddSchedule(context.Background(), &schedule)
+	require.NoError(t, err)
+	assert.Equal(t, 0, schedule.ID)
+	id2 := addSchedule(t, storage, domain.Schedule{Points: schedule.Points, Weekdays: []time.Weekday{time.Sunday},
+		ValidFrom: date(5), ValidTo: date(5), Cost: domain.Money{Amount: 900, Currency: "USD"}, AllSeats: 10})
+	assert.NotEqual(t, id1, id2)
+
+	expected := newSchedule()
+	expected.ID = id1
+	assert.Equal(t, expected, getSchedule(t, storage, id1))
+
+	second := getSchedule(t, storage, id2)
+	assert.Equal(t, []time.Weekday{time.Sunday}, second.Weekdays)
+	assert.Empty(t, second.Exceptions)
+	assert.Equal(t, domain.Money{Amount: 900, Currency: "USD"}, second.Cost)
+}
+
+func testScheduleByIDMissing(t *testing.T, storage routemanager.RouteStorage) {
+	id := addSchedule(t, storage, newSchedule())
+
+	_, err := storage.ScheduleByID(context.Background(), id+1)
+	assertKind(t, domain.ErrNotFound, err)
+}
+
+func testGetAllSchedules(t *testing.T, storage routemanager.RouteStorage) {
+	schedules, err := storage.GetAllSchedules(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, schedules)
+
+	var ids []int
+	for i := 0; i < 3; i++ {
+		ids = append(ids, addSchedule(t, storage, newSchedule()))
+	}
+
+	schedules, err = storage.GetAllSchedules(context.Background())
+	require.NoError(t, err)
+	require.Len(t, schedules, len(ids))
+	for i, s := range schedules {
+		assert.Equal(t, ids[i], s.ID)
+		assert.Equal(t, []time.Time{date(1), date(9)}, s.Exceptions)
+	}
+}
+
+func testUpdateSchedule(t *testing.T, storage routemanager.RouteStorage) {
+	id := addSchedule(t, storage, newSchedule())
+	other := addSchedule(t, storage, newSchedule())
+
+	updated := newSchedule()
+	updated.ID = id
+	updated.Points.EndPoint = "Polotsk"
+	updated.Departure = 18 * time.Hour
+	updated.Weekdays = []time.Weekday{time.Saturday}
+	updated.ValidTo = date(20)
+	updated.Exceptions = []time.Time{date(18)}
+	require.NoError(t, storage.UpdateSchedule(context.Background(), &updated))
+	assert.Equal(t, updated, getSchedule(t, storage, id))
+
+	expected := newSchedule()
+	expected.ID = other
+	assert.Equal(t, expected, getSchedule(t, storage, other))
+
+	updated.Exceptions = nil
+	require.NoError(t, storage.UpdateSchedule(context.Background(), &updated))
+	assert.Empty(t, getSchedule(t, storage, id).Exceptions)
+
+	updated.ID = other + 1
+	assertKind(t, domain.ErrNotFound, storage.UpdateSchedule(context.Background(), &updated))
+}
+
+func testDeleteSchedule(t *testing.T, storage routemanager.RouteStorage) {
+	id := addSchedule(t, storage, newSchedule())
+	kept := addSchedule(t, storage, newSchedule())
+	trip := newSchedule().Trip(date(14))
+	trip.ScheduleID = id
+	route := addRoute(t, storage, trip)
+
+	require.NoError(t, storage.DeleteSchedule(context.Background(), id))
+	_, err := storage.ScheduleByID(context.Background(), id)
+	assertKind(t, domain.ErrNotFound, err)
+	assertKind(t, domain.ErrNotFound, storage.DeleteSchedule(context.Background(), id))
+
+	assert.Equal(t, 0, getRoute(t, storage, route).ScheduleID)
+	starts, err := storage.ScheduledStarts(context.Background(), id, date(1), date(31))
+	require.NoError(t, err)
+	assert.Empty(t, starts)
+
+	schedules, err := storage.GetAllSchedules(context.Background())
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	assert.Equal(t, kept, schedules[0].ID)
+}
+
+func testScheduledStarts(t *testing.T, storage routemanager.RouteStorage) {
+	id := addSchedule(t, storage, newSchedule())
+	other := addSchedule(t, storage, newSchedule())
+	for _, d := range []int{16, 14, 15, 17} {
+		trip := newSchedule().Trip(date(d))
+		trip.ScheduleID = id
+		routeID := addRoute(t, storage, trip)
+		if d == 15 {
+			deleteRoute(t, storage, routeID)
+		}
+	}
+	trip := newSchedule().Trip(date(15))
+	trip.ScheduleID = other
+	addRoute(t, storage, trip)
+	addRoute(t, storage, newSchedule().Trip(date(15)))
+
+	departure := 9*time.Hour + 30*time.Minute
+	starts, err := storage.ScheduledStarts(context.Background(), id, date(14).Add(departure), date(17).Add(departure))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{date(14).Add(departure), date(15).Add(departure), date(16).Add(departure)}, starts)
+
+	starts, err = storage.ScheduledStarts(context.Background(), id, date(18), date(31))
+	require.NoError(t, err)
+	assert.Empty(t, starts)
+}
+
+func testAddScheduledRouteTwice(t *testing.T, storage routemanager.RouteStorage) {
+	id := addSchedule(t, storage, newSchedule())
+	trip := newSchedule().Trip(date(14))
+	trip.ScheduleID = id
+	routeID := addRoute(t, storage, trip)
+
+	_, err := storage.AddRoute(context.Background(), &trip)
+	assert.Equal(t, domain.Conflict("schedule already has route with such start"), err)
+	deleteRoute(t, storage, routeID)
+	_, err = storage.AddRoute(context.Background(), &trip)
+	assert.Equal(t, domain.Conflict("schedule already has route with such start"), err)
+
+	trip.ScheduleID = 0
+	addRoute(t, storage, trip)
+	addRoute(t, storage, trip)
+	other := trip
+	other.ScheduleID = addSchedule(t, storage, newSchedule())
+	addRoute(t, storage, other)
+
+	starts, err := storage.ScheduledStarts(context.Background(), id, date(1), date(31))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{trip.Start}, starts)
+}
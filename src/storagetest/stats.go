@@ -0,0 +1,42 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//statsTests returns tests of counting of seats of upcoming routes.
+func statsTests() []conformanceTest {
+	return []conformanceTest{
+		{"SeatStats", testSeatStats},
+		{"SeatStatsEmpty", testSeatStatsEmpty},
+	}
+}
+
+func testSeatStats(t *testing.T, storage routemanager.RouteStorage) {
+	addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(8, 0), 1000, 30))
+	booked := addRoute(t, storage, stopsRoute(at(10, 0), 20))
+	addRoute(t, storage, newRoute("Minsk", "Brest", at(12, 0), 1000, 40))
+	cancelled := addRoute(t, storage, newRoute("Minsk", "Brest", at(13, 0), 1000, 50))
+	deleted := addRoute(t, storage, newRoute("Minsk", "Gomel", at(14, 0), 1000, 60))
+	bookSeat(t, storage, newTicket(booked, "Minsk", "Lida"))
+	require.NoError(t, storage.SetRouteStatus(context.Background(), cancelled, 1, domain.StatusCancelled, 0))
+	deleteRoute(t, storage, deleted)
+
+	stats, err := storage.SeatStats(context.Background(), at(10, 0))
+	require.NoError(t, err)
+	assert.Equal(t, domain.SeatStats{Routes: 2, FreeSeats: 59, AllSeats: 60}, stats)
+}
+
+func testSeatStatsEmpty(t *testing.T, storage routemanager.RouteStorage) {
+	addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(8, 0), 1000, 30))
+
+	stats, err := storage.SeatStats(context.Background(), at(10, 0))
+	require.NoError(t, err)
+	assert.Equal(t, domain.SeatStats{}, stats)
+}
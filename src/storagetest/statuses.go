@@ -0,0 +1,115 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//statusTests returns tests of statuses and delays of routes.
+func statusTests() []conformanceTest {
+	return []conformanceTest{
+		{"NewRouteIsScheduled", testNewRouteIsScheduled},
+		{"SetRouteStatus", testSetRouteStatus},
+		{"SetRouteStatusErrors", testSetRouteStatusErrors},
+		{"UpdateRouteKeepsStatus", testUpdateRouteKeepsStatus},
+		{"RoutesByQueryStatuses", testRoutesByQueryStatuses},
+	}
+}
+
+func testNewRouteIsScheduled(t *testing.T, storage routemanager.RouteStorage) {
+	route := newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30)
+	route.Status = domain.StatusDeparted
+	route.Delay = 20 * time.Minute
+	id := addRoute(t, storage, route)
+
+	route = getRoute(t, storage, id)
+	assert.Equal(t, domain.StatusScheduled, route.Status)
+	assert.Equal(t, time.Duration(0), route.Delay)
+}
+
+func testSetRouteStatus(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+	other := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(12, 0), 1000, 30))
+
+	require.NoError(t, storage.SetRouteStatus(context.Background(), id, 1, domain.StatusDelayed,
+		25*time.Minute+10*time.Second))
+	route := getRoute(t, storage, id)
+	assert.Equal(t, domain.StatusDelayed, route.Status)
+	assert.Equal(t, 25*time.Minute, route.Delay)
+	assert.Equal(t, at(10, 25), route.ExpectedStart())
+
+	require.NoError(t, storage.SetRouteStatus(context.Background(), id, 2, domain.StatusDelayed,
+		25*time.Minute))
+	require.NoError(t, storage.SetRouteStatus(context.Background(), id, 3, domain.StatusBoarding,
+		25*time.Minute))
+	route = getRoute(t, storage, id)
+	assert.Equal(t, domain.StatusBoarding, route.Status)
+	assert.Equal(t, 25*time.Minute, route.Delay)
+
+	route = getRoute(t, storage, other)
+	assert.Equal(t, domain.StatusScheduled, route.Status)
+	assert.Equal(t, time.Duration(0), route.Delay)
+}
+
+func testSetRouteStatusErrors(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+
+	err := storage.SetRouteStatus(context.Background(), id, 2, domain.StatusDeparted, 0)
+	assertKind(t, domain.ErrPrecondition, err)
+	err = storage.SetRouteStatus(context.Background(), id+1, 1, domain.StatusCancelled, 0)
+	assertKind(t, domain.ErrNotFound, err)
+	assert.Equal(t, domain.StatusScheduled, getRoute(t, storage, id).Status)
+}
+
+func testUpdateRouteKeepsStatus(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 30))
+	require.NoError(t, storage.SetRouteStatus(context.Background(), id, 1, domain.StatusDelayed,
+		15*time.Minute))
+
+	updated := newRoute("Minsk", "Polotsk", at(11, 0), 1200, 30)
+	updated.ID, updated.Version = id, 2
+	require.NoError(t, storage.UpdateRoute(context.Background(), &updated))
+
+	route := getRoute(t, storage, id)
+	assert.Equal(t, "Polotsk", route.Points.EndPoint)
+	assert.Equal(t, domain.StatusDelayed, route.Status)
+	assert.Equal(t, 15*time.Minute, route.Delay)
+}
+
+func testRoutesByQueryStatuses(t *testing.T, storage routemanager.RouteStorage) {
+	ids := queryFixture(t, storage)
+	require.NoError(t, storage.SetRouteStatus(context.Background(), ids[0], 1, domain.StatusCancelled, 0))
+	require.NoError(t, storage.SetRouteStatus(context.Background(), ids[3], 1, domain.StatusDelayed,
+		10*time.Minute))
+
+	testCases := []struct {
+		name     string
+		statuses []string
+		expected []int
+	}{
+		{"any status", nil, ids},
+		{"one status", []string{domain.StatusCancelled}, []int{ids[0]}},
+		{"active statuses", domain.ActiveStatuses(), ids[1:]},
+		{"no such routes", []string{domain.StatusDeparted}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			routes, total, err := storage.RoutesByQuery(context.Background(),
+				domain.RouteQuery{Statuses: tc.statuses})
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.expected), total)
+			if len(tc.expected) == 0 {
+				assert.Empty(t, routes)
+				return
+			}
+			assert.Equal(t, tc.expected, routeIDs(routes))
+		})
+	}
+}
@@ -0,0 +1,120 @@
+//Package storagetest implements conformance tests which every implementation of
+//routemanager.RouteStorage and accessmanager.IdentityStorage must pass, so all storages behave identically.
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//Factory - function which creates new empty storage for the test.
+type Factory func(t *testing.T) routemanager.RouteStorage
+
+//conformanceTest - struct for describing one test of the suite.
+type conformanceTest struct {
+	name string
+	test func(t *testing.T, storage routemanager.RouteStorage)
+}
+
+//Run runs all conformance tests, every test gets new storage from factory.
+func Run(t *testing.T, newStorage Factory) {
+	var tests []conformanceTest
+	tests = append(tests, routeTests()...)
+	tests = append(tests, queryTests()...)
+	tests = append(tests, statusTests()...)
+	tests = append(tests, ticketTests()...)
+	tests = append(tests, scheduleTests()...)
+	tests = append(tests, archiveTests()...)
+	tests = append(tests, auditTests()...)
+	tests = append(tests, statsTests()...)
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStorage(t))
+		})
+	}
+}
+
+//at returns time of the day of routes of the suite.
+func at(hour, minute int) time.Time {
+	return time.Date(2030, 5, 14, hour, minute, 0, 0, time.UTC)
+}
+
+//newRoute returns scheduled route without stops which has all seats free.
+func newRoute(from, to string, start time.Time, cost, seats int) domain.Route {
+	return domain.Route{
+		Points:    domain.Points{StartPoint: from, EndPoint: to},
+		Start:     start,
+		Cost:      domain.Money{Amount: cost, Currency: "BYN"},
+		FreeSeats: seats,
+		AllSeats:  seats,
+		Duration:  2 * time.Hour,
+		Status:    domain.StatusScheduled,
+	}
+}
+
+//stopsRoute returns route Minsk - Lida - Grodno with stops which has all seats free.
+func stopsRoute(start time.Time, seats int) domain.Route {
+	r := newRoute("Minsk", "Grodno", start, 2500, seats)
+	r.Duration = 4 * time.Hour
+	r.Stops = []domain.Stop{
+		{Point: "Minsk", Departure: 0, FreeSeats: seats},
+		{Point: "Lida", Arrival: 2 * time.Hour, Departure: 2*time.Hour + 10*time.Minute, Fare: 1500,
+			FreeSeats: seats},
+		{Point: "Grodno", Arrival: 4 * time.Hour, Departure: 4 * time.Hour, Fare: 1000, FreeSeats: seats},
+	}
+	return r
+}
+
+//addRoute adds route to storage and returns its id.
+func addRoute(t *testing.T, storage routemanager.RouteStorage, r domain.Route) int {
+	t.Helper()
+	id, err := storage.AddRoute(context.Background(), &r)
+	require.NoError(t, err)
+	return id
+}
+
+//getRoute gets route from storage by id.
+func getRoute(t *testing.T, storage routemanager.RouteStorage, id int) domain.Route {
+	t.Helper()
+	r, err := storage.RouteByID(context.Background(), id)
+	require.NoError(t, err)
+	return *r
+}
+
+//deleteRoute marks route as deleted with its current version.
+func deleteRoute(t *testing.T, storage routemanager.RouteStorage, id int) {
+	t.Helper()
+	require.NoError(t, storage.DeleteRow(context.Background(), id, getRoute(t, storage, id).Version))
+}
+
+//findRoutes gets all routes which match query from storage.
+func findRoutes(t *testing.T, storage routemanager.RouteStorage, q domain.RouteQuery) []domain.Route {
+	t.Helper()
+	routes, _, err := storage.RoutesByQuery(context.Background(), q)
+	require.NoError(t, err)
+	return routes
+}
+
+//routeIDs returns ids of routes in their order.
+func routeIDs(routes []domain.Route) []int {
+	ids := make([]int, 0, len(routes))
+	for _, r := range routes {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+//assertKind checks that error has kind.
+func assertKind(t *testing.T, kind, err error) {
+	t.Helper()
+	if assert.Error(t, err) {
+		assert.Equal(t, kind, domain.ErrorKind(err), err.Error())
+	}
+}
@@ -0,0 +1,206 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JaneKetko/Buses/src/domain"
+	"github.com/JaneKetko/Buses/src/routemanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//ticketTests returns tests of booking of seats and cancelling of bookings.
+func ticketTests() []conformanceTest {
+	return []conformanceTest{
+		{"BookSeat", testBookSeat},
+		{"BookSeatBetweenStops", testBookSeatBetweenStops},
+		{"BookSeatErrors", testBookSeatErrors},
+		{"CancelBooking", testCancelBooking},
+		{"CancelBookingBetweenStops", testCancelBookingBetweenStops},
+		{"CancelBookingOfRemovedStop", testCancelBookingOfRemovedStop},
+		{"RouteTickets", testRouteTickets},
+		{"ConcurrentBookSeat", testConcurrentBookSeat},
+	}
+}
+
+//newTicket returns ticket of passenger for the route between stops.
+func newTicket(routeID int, from, to string) domain.Ticket {
+	return domain.Ticket{RouteID: routeID, Passenger: "Ivanov", From: from, To: to,
+		Cost: domain.Money{Amount: 1000, Currency: "BYN"}, Booked: at(6, 0)}
+}
+
+//bookSeat books seat by ticket and returns id of the ticket.
+func bookSeat(t *testing.T, storage routemanager.RouteStorage, ticket domain.Ticket) int {
+	t.Helper()
+	id, err := storage.BookSeat(context.Background(), &ticket)
+	require.NoError(t, err)
+	return id
+}
+
+//stopSeats returns free seats on segments of the route.
+func stopSeats(r domain.Route) []int {
+	seats := make([]int, 0, len(r.Stops))
+	for _, stop := range r.Stops {
+		seats = append(seats, stop.FreeSeats)
+	}
+	return seats
+}
+
+func testBookSeat(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 2))
+	other := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(12, 0), 1000, 2))
+
+	ticket := newTicket(id, "", "")
+	first, err := storage.BookSeat(context.Background(), &ticket)
+	require.NoError(t, err)
+	assert.Equal(t, 0, ticket.ID)
+	second := bookSeat(t, storage, ticket)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, 0, getRoute(t, storage, id).FreeSeats)
+	assert.Equal(t, 2, getRoute(t, storage, other).FreeSeats)
+
+	_, err = storage.BookSeat(context.Background(), &ticket)
+	assertKind(t, domain.ErrConflict, err)
+	assert.Equal(t, 0, getRoute(t, storage, id).FreeSeats)
+}
+
+func testBookSeatBetweenStops(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, stopsRoute(at(8, 0), 2))
+
+	bookSeat(t, storage, newTicket(id, "Minsk", "Lida"))
+	route := getRoute(t, storage, id)
+	assert.Equal(t, []int{1, 2, 2}, stopSeats(route))
+	assert.Equal(t, 1, route.FreeSeats)
+
+	bookSeat(t, storage, newTicket(id, "Lida", "Grodno"))
+	bookSeat(t, storage, newTicket(id, "Lida", "Grodno"))
+	route = getRoute(t, storage, id)
+	assert.Equal(t, []int{1, 0, 2}, stopSeats(route))
+	assert.Equal(t, 0, route.FreeSeats)
+
+	bookSeat(t, storage, newTicket(id, "Minsk", "Lida"))
+	_, err := storage.BookSeat(context.Background(), &domain.Ticket{RouteID: id, From: "Minsk", To: "Grodno"})
+	assertKind(t, domain.ErrConflict, err)
+	assert.Equal(t, []int{0, 0, 2}, stopSeats(getRoute(t, storage, id)))
+}
+
+func testBookSeatErrors(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, stopsRoute(at(8, 0), 2))
+	plain := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 2))
+
+	testCases := []struct {
+		name   string
+		ticket domain.Ticket
+		kind   error
+	}{
+		{"missing route", newTicket(plain+1, "", ""), domain.ErrNotFound},
+		{"missing route with stops", newTicket(plain+1, "Minsk", "Lida"), domain.ErrNotFound},
+		{"unknown stop", newTicket(id, "Minsk", "Brest"), domain.ErrValidation},
+		{"no stops", newTicket(id, "", ""), domain.ErrValidation},
+		{"reversed stops", newTicket(id, "Grodno", "Lida"), domain.ErrValidation},
+		{"same stop", newTicket(id, "Lida", "Lida"), domain.ErrValidation},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ticket := tc.ticket
+			_, err := storage.BookSeat(context.Background(), &ticket)
+			assertKind(t, tc.kind, err)
+		})
+	}
+	assert.Equal(t, []int{2, 2, 2}, stopSeats(getRoute(t, storage, id)))
+}
+
+func testCancelBooking(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 2))
+	first := bookSeat(t, storage, newTicket(id, "", ""))
+	second := bookSeat(t, storage, newTicket(id, "", ""))
+
+	require.NoError(t, storage.CancelBooking(context.Background(), first))
+	assert.Equal(t, 1, getRoute(t, storage, id).FreeSeats)
+	assertKind(t, domain.ErrNotFound, storage.CancelBooking(context.Background(), first))
+	assert.Equal(t, 1, getRoute(t, storage, id).FreeSeats)
+	assertKind(t, domain.ErrNotFound, storage.CancelBooking(context.Background(), second+1))
+
+	require.NoError(t, storage.CancelBooking(context.Background(), second))
+	assert.Equal(t, 2, getRoute(t, storage, id).FreeSeats)
+}
+
+func testCancelBookingBetweenStops(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, stopsRoute(at(8, 0), 2))
+	first := bookSeat(t, storage, newTicket(id, "Minsk", "Grodno"))
+	second := bookSeat(t, storage, newTicket(id, "Lida", "Grodno"))
+
+	require.NoError(t, storage.CancelBooking(context.Background(), first))
+	route := getRoute(t, storage, id)
+	assert.Equal(t, []int{2, 1, 2}, stopSeats(route))
+	assert.Equal(t, 1, route.FreeSeats)
+
+	require.NoError(t, storage.CancelBooking(context.Background(), second))
+	route = getRoute(t, storage, id)
+	assert.Equal(t, []int{2, 2, 2}, stopSeats(route))
+	assert.Equal(t, 2, route.FreeSeats)
+}
+
+func testCancelBookingOfRemovedStop(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, stopsRoute(at(8, 0), 2))
+	ticket := bookSeat(t, storage, newTicket(id, "Lida", "Grodno"))
+	route := getRoute(t, storage, id)
+	route.Stops = []domain.Stop{route.Stops[0], route.Stops[2]}
+	require.NoError(t, storage.UpdateRoute(context.Background(), &route))
+
+	assertKind(t, domain.ErrValidation, storage.CancelBooking(context.Background(), ticket))
+	tickets, err := storage.RouteTickets(context.Background(), id)
+	require.NoError(t, err)
+	require.Len(t, tickets, 1)
+	assert.Equal(t, ticket, tickets[0].ID)
+}
+
+func testRouteTickets(t *testing.T, storage routemanager.RouteStorage) {
+	id := addRoute(t, storage, stopsRoute(at(8, 0), 3))
+	other := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, 2))
+	first := newTicket(id, "Minsk", "Lida")
+	first.ID = bookSeat(t, storage, first)
+	second := newTicket(id, "Lida", "Grodno")
+	second.ID = bookSeat(t, storage, second)
+	cancelled := bookSeat(t, storage, newTicket(id, "Minsk", "Grodno"))
+	bookSeat(t, storage, newTicket(other, "Minsk", "Vitebsk"))
+	require.NoError(t, storage.CancelBooking(context.Background(), cancelled))
+
+	tickets, err := storage.RouteTickets(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Ticket{first, second}, tickets)
+
+	tickets, err = storage.RouteTickets(context.Background(), other+1)
+	require.NoError(t, err)
+	assert.Empty(t, tickets)
+}
+
+func testConcurrentBookSeat(t *testing.T, storage routemanager.RouteStorage) {
+	const seats, passengers = 3, 10
+	plain := addRoute(t, storage, newRoute("Minsk", "Vitebsk", at(10, 0), 1000, seats))
+	stops := addRoute(t, storage, stopsRoute(at(8, 0), seats))
+
+	for _, ticket := range []domain.Ticket{newTicket(plain, "", ""), newTicket(stops, "Minsk", "Grodno")} {
+		errs := make(chan error, passengers)
+		for i := 0; i < passengers; i++ {
+			go func(ticket domain.Ticket) {
+				_, err := storage.BookSeat(context.Background(), &ticket)
+				errs <- err
+			}(ticket)
+		}
+
+		var booked int
+		for i := 0; i < passengers; i++ {
+			err := <-errs
+			if err == nil {
+				booked++
+				continue
+			}
+			assertKind(t, domain.ErrConflict, err)
+		}
+		assert.Equal(t, seats, booked)
+		assert.Equal(t, 0, getRoute(t, storage, ticket.RouteID).FreeSeats)
+	}
+}